@@ -0,0 +1,158 @@
+package main
+
+import (
+	"adsb-api/internal/db"
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/utility/apiKey"
+	"adsb-api/internal/utility/logger"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+)
+
+const usage = `usage: apikey create <name> [-scopes live,history,export] [-rate-limit <requests per minute>]
+       apikey list
+       apikey revoke <name>
+
+Manages the API keys of the REST API. A new key is only printed once by create, as only its hash is stored.
+A key has every scope unless -scopes is given, and the API_KEY_RATE_LIMIT unless -rate-limit is given.
+Revoking a key deletes it.`
+
+// main method for the command line tool that manages API keys
+func main() {
+	if len(os.Args) < 2 {
+		exitUsage()
+	}
+
+	// Initialize environment variables
+	global.InitEnvironment()
+	// Initialize logger
+	logger.InitLogger()
+
+	var key models.ApiKeyModel
+	switch os.Args[1] {
+	case "create":
+		key = parseCreate(os.Args[2:])
+	case "list":
+		if len(os.Args) != 2 {
+			exitUsage()
+		}
+	case "revoke":
+		if len(os.Args) != 3 {
+			exitUsage()
+		}
+	default:
+		exitUsage()
+	}
+
+	// Initialize the database
+	database, err := db.InitDB(db.ReceptionPoolConfig())
+	if err != nil {
+		log.Fatal().Msgf("error opening database: %q", err)
+	}
+
+	defer func() {
+		err = database.Close()
+		if err != nil {
+			log.Fatal().Msgf(errorMsg.ErrorClosingDatabase+": %q", err)
+		}
+	}()
+
+	if err := database.CreateApiKeyTable(); err != nil {
+		log.Fatal().Msgf(errorMsg.ErrorCreatingDatabaseTables+": %q", err)
+	}
+
+	switch os.Args[1] {
+	case "create":
+		create(database, key)
+	case "list":
+		list(database)
+	case "revoke":
+		revoke(database, os.Args[2])
+	}
+}
+
+// exitUsage prints the usage of the tool and exits.
+func exitUsage() {
+	fmt.Fprintln(os.Stderr, usage)
+	os.Exit(2)
+}
+
+// parseCreate parses the arguments of create into the key to create.
+func parseCreate(args []string) models.ApiKeyModel {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		exitUsage()
+	}
+	key := models.ApiKeyModel{Name: args[0]}
+
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	flags.Usage = exitUsage
+	scopes := flags.String("scopes", strings.Join(global.ApiKeyScopes, ","), "")
+	flags.IntVar(&key.RateLimit, "rate-limit", 0, "")
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 || key.RateLimit < 0 {
+		exitUsage()
+	}
+
+	var err error
+	key.Scopes, err = apiKey.ParseScopes(*scopes)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return key
+}
+
+// create generates a new API key, stores it as key and prints it.
+func create(database db.Database, key models.ApiKeyModel) {
+	generated, err := apiKey.Generate()
+	if err != nil {
+		log.Fatal().Msgf(errorMsg.ErrorCreatingApiKey+": %q", err)
+	}
+	key.Prefix = apiKey.Prefix(generated)
+
+	if err := database.InsertApiKey(key, apiKey.Hash(generated)); err != nil {
+		log.Fatal().Msgf(errorMsg.ErrorCreatingApiKey+": %q", err)
+	}
+
+	log.Info().Msgf("API key %s created with the scopes: %s", key.Name, strings.Join(key.Scopes, ", "))
+	fmt.Println(generated)
+}
+
+// list prints every API key, without the keys themselves.
+func list(database db.Database) {
+	keys, err := database.SelectApiKeys()
+	if err != nil {
+		log.Fatal().Msgf(errorMsg.ErrorListingApiKeys+": %q", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPREFIX\tSCOPES\tRATE LIMIT\tCREATED")
+	for _, key := range keys {
+		rateLimit := fmt.Sprint(key.RateLimit)
+		if key.RateLimit == 0 {
+			rateLimit = "default"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key.Name, key.Prefix, strings.Join(key.Scopes, ","), rateLimit,
+			key.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	_ = w.Flush()
+}
+
+// revoke deletes the API key name.
+func revoke(database db.Database, name string) {
+	deleted, err := database.DeleteApiKey(name)
+	if err != nil {
+		log.Fatal().Msgf(errorMsg.ErrorRevokingApiKey+": %q", err)
+	}
+	if !deleted {
+		log.Fatal().Msgf(errorMsg.ErrorRevokingApiKey+": there is no API key named %s", name)
+	}
+
+	log.Info().Msgf("API key %s revoked", name)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"adsb-api/internal/db"
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/utility/archive"
+	"adsb-api/internal/utility/logger"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+)
+
+const usage = `usage: archive restore <archive directory>
+
+Restores an archive written by the cleanup job back into aircraft_history.
+Rows that already exist in aircraft_history are skipped, so a restore that failed part way can be run again.`
+
+// main method for the command line tool that manages history archives
+func main() {
+	if len(os.Args) != 3 || os.Args[1] != "restore" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Initialize environment variables
+	global.InitEnvironment()
+	// Initialize logger
+	logger.InitLogger()
+
+	rows, err := restore(os.Args[2])
+	if err != nil {
+		log.Fatal().Msgf(errorMsg.ErrorRestoringArchive+": %q", err)
+	}
+
+	log.Info().Msgf("%d rows restored from %s", rows, os.Args[2])
+}
+
+// restore inserts the rows of the archive at path into aircraft_history as they are read, one batch at a time, and
+// returns the number of rows restored. The database is closed before returning.
+func restore(path string) (rows int, err error) {
+	// Initialize the database
+	database, err := db.InitDB(db.ReceptionPoolConfig())
+	if err != nil {
+		return 0, fmt.Errorf("error opening database: %w", err)
+	}
+
+	defer func() {
+		closeErr := database.Close()
+		if closeErr != nil && err == nil {
+			err = fmt.Errorf(errorMsg.ErrorClosingDatabase+": %w", closeErr)
+		}
+	}()
+
+	return archive.ReadArchive(path, db.MaxHistoryRowsPerInsert, database.BulkInsertAircraftHistory)
+}
@@ -20,7 +20,7 @@ func main() {
 	// Initialize logger
 	logger.InitLogger()
 	// Initialize the database
-	database, err := db.InitDB()
+	database, err := db.InitDB(db.ReceptionPoolConfig())
 	if err != nil {
 		log.Fatal().Msgf("error opening database: %q", err)
 	}
@@ -42,16 +42,36 @@ func main() {
 		log.Fatal().Msgf(errorMsg.ErrorCreatingDatabaseTables+": %q", err)
 	}
 
-	if err := sbsSvc.ScheduleCleanUpJob(global.CleanupSchedule, global.MaxDaysHistory); err != nil {
+	if global.RollupResolution > 0 || global.ArchiveDir != "" {
+		err = sbsSvc.ScheduleTieredCleanUpJob(global.CleanupSchedule, global.MaxDaysHistory, global.RollupResolution,
+			global.MaxDaysRollup, global.ArchiveDir)
+	} else {
+		err = sbsSvc.ScheduleCleanUpJob(global.CleanupSchedule, global.MaxDaysHistory)
+	}
+	if err != nil {
 		log.Fatal().Msgf("error initiazling cleanupJob job :%v", err)
 	}
 
+	if global.ReceiverPositionSet {
+		err = sbsSvc.ScheduleCoverageJob(global.CoverageSchedule, global.ReceiverLatitude, global.ReceiverLongitude,
+			global.CoverageSectors, global.CoverageAltitudeBand, global.MaxDaysCoverage)
+		if err != nil {
+			log.Fatal().Msgf("error initiazling coverageJob job :%v", err)
+		}
+		log.Info().Msgf("Scheduled coverage job with cron schedule: %s | Receiver: %f, %f | Sectors: %d | "+
+			"AltitudeBand: %d feet | MaxDaysCoverage: %d", global.CoverageSchedule, global.ReceiverLatitude,
+			global.ReceiverLongitude, global.CoverageSectors, global.CoverageAltitudeBand, global.MaxDaysCoverage)
+	} else {
+		log.Info().Msgf("RECEIVER_LATITUDE and RECEIVER_LONGITUDE have not been set. The coverage will not be computed")
+	}
+
 	sbsSvc.StartScheduler()
 
 	log.Info().Msgf("Reception API successfully connected to database with: User: %s | Database: %s | Host: %s | port: %d",
 		global.DbUser, global.DbName, global.DbHost, global.DbPort)
 
-	log.Info().Msgf("Scheduled clean up job with cron schedule: %s", global.CleanupSchedule)
+	log.Info().Msgf("Scheduled clean up job with cron schedule: %s | RollupResolution: %d seconds | MaxDaysRollup: %d | "+
+		"ArchiveDir: %q", global.CleanupSchedule, global.RollupResolution, global.MaxDaysRollup, global.ArchiveDir)
 
 	log.Info().Msgf("Starting the process for receiving SBS data. \n"+
 		"SBS source : %q | WaitingTime: %d seconds | CleanupSchedule: %s | UpdatingPeriod: %d seconds | MaxDaysHistory: %d",
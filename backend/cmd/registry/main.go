@@ -0,0 +1,67 @@
+package main
+
+import (
+	"adsb-api/internal/db"
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/utility/logger"
+	"adsb-api/internal/utility/registry"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+)
+
+const usage = `usage: registry import <csv file>
+
+Imports an aircraft database CSV file, like the OpenSky Network aircraft database, into aircraft_registry.
+The file must have a header row with an icao24 (or icao, hex) column. Existing aircraft are updated.`
+
+// batchSize is the number of registry rows inserted in each query
+const batchSize = 5000
+
+// main method for the command line tool that imports aircraft registry data
+func main() {
+	if len(os.Args) != 3 || os.Args[1] != "import" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Initialize environment variables
+	global.InitEnvironment()
+	// Initialize logger
+	logger.InitLogger()
+
+	file, err := os.Open(os.Args[2])
+	if err != nil {
+		log.Fatal().Msgf(errorMsg.ErrorImportingRegistry+": %q", err)
+	}
+	defer file.Close()
+
+	// Initialize the database
+	database, err := db.InitDB(db.ReceptionPoolConfig())
+	if err != nil {
+		log.Fatal().Msgf("error opening database: %q", err)
+	}
+
+	defer func() {
+		err = database.Close()
+		if err != nil {
+			log.Fatal().Msgf(errorMsg.ErrorClosingDatabase+": %q", err)
+		}
+	}()
+
+	if err := database.CreateAircraftRegistryTable(); err != nil {
+		log.Fatal().Msgf(errorMsg.ErrorCreatingDatabaseTables+": %q", err)
+	}
+
+	read, skipped, err := registry.ReadCsv(file, batchSize, func(rows []models.AircraftRegistryModel) error {
+		return database.BulkUpsertAircraftRegistry(rows)
+	})
+	if err != nil {
+		log.Fatal().Msgf(errorMsg.ErrorImportingRegistry+": %q", err)
+	}
+
+	log.Info().Msgf("%d aircraft imported from %s, %d rows without a valid ICAO address skipped", read, os.Args[2], skipped)
+}
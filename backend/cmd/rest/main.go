@@ -6,11 +6,24 @@ import (
 	"adsb-api/internal/global/errorMsg"
 	"adsb-api/internal/handler/aircraftCurrentHandler"
 	"adsb-api/internal/handler/aircraftHistoryHandler"
+	"adsb-api/internal/handler/aircraftLiveHandler"
+	"adsb-api/internal/handler/aircraftRegistryHandler"
+	"adsb-api/internal/handler/aircraftSearchHandler"
+	"adsb-api/internal/handler/aircraftStreamHandler"
+	"adsb-api/internal/handler/auth"
+	"adsb-api/internal/handler/coverageHandler"
 	"adsb-api/internal/handler/defaultHandler"
+	"adsb-api/internal/handler/exportHandler"
+	"adsb-api/internal/handler/heatmapHandler"
+	"adsb-api/internal/handler/openapiHandler"
+	"adsb-api/internal/handler/router"
+	"adsb-api/internal/handler/statsHandler"
 	"adsb-api/internal/service/restService"
+	"adsb-api/internal/service/streamService"
 	"adsb-api/internal/utility/logger"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -22,7 +35,7 @@ func main() {
 	// Initialize logger
 	logger.InitLogger()
 	// Initialize the database
-	database, err := db.InitDB()
+	database, err := db.InitDB(db.RestPoolConfig(), global.DbReplicaDsns...)
 	if err != nil {
 		log.Fatal().Msgf("error opening database: %q", err)
 	}
@@ -36,12 +49,11 @@ func main() {
 
 	log.Info().Msgf("Reception API successfully connected to database with: User: %s | Database: %s | Host: %s | port: %d",
 		global.DbUser, global.DbName, global.DbHost, global.DbPort)
+	log.Info().Msgf("Read replicas: %d | MaxLag: %d seconds", len(global.DbReplicaDsns), global.DbReplicaMaxLag)
 
 	restSvc := restService.InitRestService(database)
-
-	http.HandleFunc(global.DefaultPath, defaultHandler.DefaultHandler)
-	http.HandleFunc(global.AircraftCurrentPath, aircraftCurrentHandler.CurrentAircraftHandler(restSvc))
-	http.HandleFunc(global.AircraftHistoryPath, aircraftHistoryHandler.HistoryAircraftHandler(restSvc))
+	streamSvc := streamService.InitStreamService(restSvc, global.StreamHistorySize)
+	go streamSvc.Run(time.Duration(global.StreamPollInterval)*time.Second, nil)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -50,6 +62,26 @@ func main() {
 	}
 
 	log.Info().Msgf("Listening on port: " + port)
-	log.Fatal().Msgf(http.ListenAndServe(":"+port, nil).Error())
+	log.Fatal().Msgf(http.ListenAndServe(":"+port, router.New(routes(restSvc, streamSvc), auth.New(restSvc).Middleware)).Error())
+
+}
 
+// routes returns every endpoint of the API. Each of them has to be documented in the OpenAPI document of
+// global.OpenApiPath, with the API key scope it requires.
+func routes(restSvc restService.RestService, streamSvc streamService.StreamService) []router.Route {
+	return []router.Route{
+		router.Get(global.DefaultPath+"{$}", defaultHandler.DefaultHandler),
+		router.Get(global.OpenApiPath, openapiHandler.OpenApiHandler),
+		router.Get(global.AircraftCurrentPath+"{$}", aircraftCurrentHandler.CurrentAircraftHandler(restSvc)).Scoped("live"),
+		router.Get(global.AircraftCurrentPath+"{icao}", aircraftCurrentHandler.CurrentAircraftHandler(restSvc)).Scoped("live"),
+		router.Get(global.AircraftHistoryPath+"{icao}", aircraftHistoryHandler.HistoryAircraftHandler(restSvc)).Scoped("history"),
+		router.Get(global.AircraftRegistryPath+"{icao}", aircraftRegistryHandler.RegistryAircraftHandler(restSvc)),
+		router.Get(global.AircraftStreamPath, aircraftStreamHandler.StreamAircraftHandler(streamSvc)).Scoped("live"),
+		router.Get(global.AircraftLivePath, aircraftLiveHandler.LiveAircraftHandler(streamSvc)).Scoped("live"),
+		router.Get(global.AircraftSearchPath, aircraftSearchHandler.SearchAircraftHandler(restSvc)).Scoped("history"),
+		router.Get(global.StatsPath+"{stat}", statsHandler.StatsHandler(restSvc)).Scoped("history"),
+		router.Get(global.CoveragePath, coverageHandler.CoverageHandler(restSvc)).Scoped("history"),
+		router.Get(global.HeatmapPath, heatmapHandler.HeatmapHandler(restSvc)).Scoped("history"),
+		router.Get(global.ExportHistoryPath, exportHandler.ExportHistoryHandler(restSvc)).Scoped("export"),
+	}
 }
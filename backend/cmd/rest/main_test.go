@@ -0,0 +1,120 @@
+package main
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/handler/openapiHandler"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRoutesDocumented fails if a route is registered without being documented in the OpenAPI document, or if the
+// document has a path that no route serves.
+func TestRoutesDocumented(t *testing.T) {
+	global.InitTestEnvironment()
+
+	doc, err := openapiHandler.Document()
+	if err != nil {
+		t.Fatalf("error building openapi document: %q", err)
+	}
+	paths, ok := doc["paths"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("openapi document has no paths")
+	}
+
+	registered := routes(nil, nil)
+
+	for _, route := range registered {
+		documented := false
+		for path := range paths {
+			documented = documented || matches(route.Pattern, path)
+		}
+		assert.True(t, documented, "route %s is not in the openapi document", route.Pattern)
+	}
+
+	for path := range paths {
+		served := false
+		for _, route := range registered {
+			served = served || matches(route.Pattern, path)
+		}
+		assert.True(t, served, "path %s of the openapi document has no route", path)
+	}
+}
+
+// TestRoutesScoped fails if the API key scope of a route is not the one it is meant to have, or not the scope of its
+// operation in the OpenAPI document.
+func TestRoutesScoped(t *testing.T) {
+	global.InitTestEnvironment()
+
+	scopes := map[string]string{
+		global.DefaultPath + "{$}":             "",
+		global.OpenApiPath:                     "",
+		global.AircraftCurrentPath + "{$}":     "live",
+		global.AircraftCurrentPath + "{icao}":  "live",
+		global.AircraftStreamPath:              "live",
+		global.AircraftLivePath:                "live",
+		global.AircraftHistoryPath + "{icao}":  "history",
+		global.AircraftSearchPath:              "history",
+		global.StatsPath + "{stat}":            "history",
+		global.CoveragePath:                    "history",
+		global.HeatmapPath:                     "history",
+		global.AircraftRegistryPath + "{icao}": "",
+		global.ExportHistoryPath:               "export",
+	}
+	for _, route := range routes(nil, nil) {
+		scope, ok := scopes[route.Pattern]
+		assert.True(t, ok, "route %s has no expected scope", route.Pattern)
+		assert.Equal(t, scope, route.Scope, "scope of route %s", route.Pattern)
+	}
+
+	doc, err := openapiHandler.Document()
+	if err != nil {
+		t.Fatalf("error building openapi document: %q", err)
+	}
+	paths, ok := doc["paths"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("openapi document has no paths")
+	}
+
+	for _, route := range routes(nil, nil) {
+		for path, item := range paths {
+			if !matches(route.Pattern, path) {
+				continue
+			}
+			operation, _ := item.(map[string]interface{})["get"].(map[string]interface{})
+			assert.Equal(t, route.Scope, operationScope(operation), "scope of %s", path)
+		}
+	}
+}
+
+// operationScope returns the scope of the bearerAuth requirement of the security of operation, or an empty string if
+// operation has the security of the document.
+func operationScope(operation map[string]interface{}) string {
+	security, _ := operation["security"].([]interface{})
+	for _, requirement := range security {
+		scopes, _ := requirement.(map[string]interface{})["bearerAuth"].([]interface{})
+		if len(scopes) == 1 {
+			scope, _ := scopes[0].(string)
+			return scope
+		}
+	}
+	return ""
+}
+
+// matches reports whether the route pattern serves the path of the openapi document, where a segment in braces of
+// either matches any segment, e.g. /stats/{stat} matches /stats/traffic.
+func matches(pattern, path string) bool {
+	patternSegments := strings.Split(strings.TrimSuffix(pattern, "{$}"), "/")
+	pathSegments := strings.Split(path, "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i := range patternSegments {
+		if patternSegments[i] != pathSegments[i] && !strings.HasPrefix(patternSegments[i], "{") &&
+			!strings.HasPrefix(pathSegments[i], "{") {
+			return false
+		}
+	}
+	return true
+}
@@ -4,39 +4,108 @@ import (
 	"adsb-api/internal/global"
 	"adsb-api/internal/global/errorMsg"
 	"adsb-api/internal/global/models"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// timestampFormat is the format of timestamp query parameters. The TIMESTAMP columns have no time zone, so times are
+// given in UTC.
+const timestampFormat = "2006-01-02 15:04:05.999999"
+
+// MaxHistoryRowsPerInsert is the maximum number of rows inserted by one query of BulkInsertAircraftHistory
+// (65535 is the max number of parameters postgres supports and there are 7 history parameters)
+const MaxHistoryRowsPerInsert = 65535 / 7
+
 // Database represents the interface for interacting with a database.
 type Database interface {
 	CreateAircraftCurrentTable() error
 	DropAircraftCurrentTable() error
 	BulkInsertAircraftCurrent(aircraft []models.AircraftCurrentModel) error
+	BulkUpsertAircraftCurrent(aircraft []models.AircraftCurrentModel) error
 	SelectAllColumnsAircraftCurrent() ([]models.AircraftCurrentModel, error)
+	SelectAircraftCurrentFiltered(filter models.AircraftCurrentFilter) ([]models.AircraftCurrentModel, error)
+	SelectAircraftCurrentVersion() (models.AircraftCurrentVersion, error)
+	SelectAircraftCurrentChangedSince(seq int64) ([]models.AircraftCurrentModel, error)
+	SelectAircraftCurrentByIcao(search string) (*models.AircraftCurrentModel, error)
+
+	CreateAircraftCurrentRemovedTable() error
+	DeleteAircraftCurrentExcept(icaos []string) error
+	DeleteOldAircraftCurrentRemoved(seconds int) error
+	SelectAircraftCurrentRemovedSince(seq int64) ([]string, error)
 
 	CreateAircraftHistoryTable() error
 	CreateAircraftHistoryTimestampIndex() error
 	InsertHistoryFromCurrent() error
 	SelectAllColumnHistoryByIcao(search string) ([]models.AircraftHistoryModel, error)
 	SelectAllColumnHistoryByIcaoFilterByTimestamp(search string, hour int) ([]models.AircraftHistoryModel, error)
+	SelectHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter) ([]models.AircraftHistoryModel, error)
+	StreamHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter, handle func(models.AircraftHistoryModel) error) error
 
 	DeleteOldHistory(days int) error
 
+	CreateAircraftHistoryRollupTable() error
+	CreateAircraftHistoryRollupTimestampIndex() error
+	SelectHistoryCutoff(days int) (string, error)
+	InsertHistoryRollup(cutoff string, resolution int) error
+	DeleteHistoryBefore(cutoff string) error
+	DeleteOldHistoryRollup(days int) error
+	SelectAllColumnHistoryRollupByIcao(search string) ([]models.AircraftHistoryModel, error)
+	SelectAllColumnHistoryRollupByIcaoFilterByTimestamp(search string, hour int) ([]models.AircraftHistoryModel, error)
+	SelectHistoryRollupByIcaoTimeRange(search string, filter models.AircraftHistoryFilter) ([]models.AircraftHistoryModel, error)
+	StreamHistoryRollupByIcaoTimeRange(search string, filter models.AircraftHistoryFilter, handle func(models.AircraftHistoryModel) error) error
+
+	StreamHistoryBefore(cutoff string, handle func(models.AircraftHistoryModel) error) error
+	BulkInsertAircraftHistory(aircraft []models.AircraftHistoryModel) error
+
+	CreateAircraftSightingTable() error
+	UpsertAircraftSightingsFromCurrent() error
+	DeleteOldAircraftSightings(days int) error
+	SearchAircraftSightings(search string, limit int) ([]models.AircraftSightingModel, error)
+
+	SelectTrafficStats(filter models.StatsFilter, interval string) ([]models.TrafficStatsModel, error)
+	SelectBusiestHours(filter models.StatsFilter, limit int) ([]models.TrafficStatsModel, error)
+	SelectAltitudeStats(filter models.StatsFilter, band int) ([]models.AltitudeStatsModel, error)
+	SelectAirlineStats(filter models.StatsFilter, limit int) ([]models.AirlineStatsModel, error)
+
+	CreateAircraftCoverageTable() error
+	UpsertCoverageFromHistory(latitude float64, longitude float64, sectors int, band int) error
+	DeleteOldCoverage(days int) error
+	SelectCoverage(filter models.CoverageFilter) ([]models.CoverageModel, error)
+
+	SelectHeatmap(filter models.HeatmapFilter) ([]models.HeatmapCellModel, error)
+	StreamHistoryExport(filter models.HistoryExportFilter, handle func(models.AircraftHistoryModel) error) error
+
+	CreateAircraftRegistryTable() error
+	BulkUpsertAircraftRegistry(registry []models.AircraftRegistryModel) error
+	SelectAircraftRegistryByIcao(search string) (*models.AircraftRegistryModel, error)
+
+	CreateApiKeyTable() error
+	InsertApiKey(key models.ApiKeyModel, hash string) error
+	SelectApiKeyByHash(hash string) (*models.ApiKeyModel, error)
+	SelectApiKeys() ([]models.ApiKeyModel, error)
+	DeleteApiKey(name string) (bool, error)
+
 	Begin() error
 	Commit() error
 	Rollback() error
+	Snapshot(read func(snapshot Database) error) error
 
 	Close() error
 }
 
 // Context represents a context object that holds a database connection and transaction.
+// Queries outside a transaction are routed to the read replicas, if any.
 type Context struct {
-	db *sql.DB
-	tx *sql.Tx
+	db       *sql.DB
+	tx       *sql.Tx
+	replicas *replicaSet
 }
 
 func (ctx *Context) Exec(query string, args ...interface{}) (sql.Result, error) {
@@ -50,9 +119,49 @@ func (ctx *Context) Query(query string, args ...interface{}) (*sql.Rows, error)
 	if ctx.tx != nil {
 		return ctx.tx.Query(query, args...)
 	}
+
+	if r := ctx.replicas.reader(); r != nil {
+		rows, err := r.db.Query(query, args...)
+		if !isConnectionError(err) {
+			return rows, err
+		}
+		// take the replica out of rotation until the next health check and retry on the primary
+		r.fail(err)
+	}
 	return ctx.db.Query(query, args...)
 }
 
+// Row is the result of QueryRow. As the error of a query is only returned by Scan, a query that failed on a replica
+// because of its connection is retried on the primary by Scan.
+type Row struct {
+	row     *sql.Row
+	replica *replica
+	retry   func() *sql.Row
+}
+
+// Scan copies the columns of the row into dest, as sql.Row.Scan.
+func (row *Row) Scan(dest ...interface{}) error {
+	err := row.row.Scan(dest...)
+	if row.replica != nil && isConnectionError(err) {
+		// take the replica out of rotation until the next health check and retry on the primary
+		row.replica.fail(err)
+		return row.retry().Scan(dest...)
+	}
+	return err
+}
+
+func (ctx *Context) QueryRow(query string, args ...interface{}) *Row {
+	if ctx.tx != nil {
+		return &Row{row: ctx.tx.QueryRow(query, args...)}
+	}
+	if r := ctx.replicas.reader(); r != nil {
+		return &Row{row: r.db.QueryRow(query, args...), replica: r, retry: func() *sql.Row {
+			return ctx.db.QueryRow(query, args...)
+		}}
+	}
+	return &Row{row: ctx.db.QueryRow(query, args...)}
+}
+
 // Begin begins Context transaction
 func (ctx *Context) Begin() error {
 	if ctx.tx != nil {
@@ -92,29 +201,167 @@ func (ctx *Context) Rollback() error {
 	return nil
 }
 
-// InitDB initializes the PostgresSQL database and returns the connection pointer.
-func InitDB() (*Context, error) {
-	dbLogin := fmt.Sprintf("host=%s port=%d user=%s "+"password=%s dbname=%s sslmode=disable",
-		global.DbHost, global.DbPort, global.DbUser, global.DbPassword, global.DbName)
+// Snapshot runs read with a Database whose queries all see the same snapshot of the data, in a read-only REPEATABLE READ
+// transaction on one replica, or on the primary if no replica is healthy. Unlike Begin, it can be used by concurrent
+// requests sharing the Context. Inside a transaction, read is run within it.
+func (ctx *Context) Snapshot(read func(snapshot Database) error) error {
+	if ctx.tx != nil {
+		return read(ctx)
+	}
+
+	options := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	var tx *sql.Tx
+	var err error
+	r := ctx.replicas.reader()
+	if r != nil {
+		tx, err = r.db.BeginTx(context.Background(), options)
+		if isConnectionError(err) {
+			// take the replica out of rotation until the next health check and retry on the primary
+			r.fail(err)
+			r = nil
+		}
+	}
+	if r == nil {
+		tx, err = ctx.db.BeginTx(context.Background(), options)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// nothing is written, so the transaction is rolled back when read is done
+	err = read(&Context{db: ctx.db, tx: tx})
+	rollbackErr := tx.Rollback()
+	if err != nil {
+		return err
+	}
+	if rollbackErr != nil {
+		return fmt.Errorf("failed to rollback transaction: %w", rollbackErr)
+	}
+	return nil
+}
+
+// PoolConfig holds the connection pool settings a service applies to its database connection.
+// Zero values keep the database/sql defaults.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// RestPoolConfig returns the connection pool profile of the REST API.
+func RestPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    global.RestDbMaxOpenConns,
+		MaxIdleConns:    global.RestDbMaxIdleConns,
+		ConnMaxLifetime: time.Duration(global.RestDbConnMaxLifetime) * time.Second,
+	}
+}
+
+// ReceptionPoolConfig returns the connection pool profile of the reception service.
+func ReceptionPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    global.ReceptionDbMaxOpenConns,
+		MaxIdleConns:    global.ReceptionDbMaxIdleConns,
+		ConnMaxLifetime: time.Duration(global.ReceptionDbConnMaxLifetime) * time.Second,
+	}
+}
+
+// DataSourceName returns the connection string for the database.
+// DB_DSN is returned as is when set, otherwise the connection string is built from the database variables.
+func DataSourceName() string {
+	if global.DbDsn != "" {
+		return global.DbDsn
+	}
+
+	params := []string{
+		"host=" + quoteDsnValue(global.DbHost),
+		"port=" + strconv.Itoa(global.DbPort),
+		"user=" + quoteDsnValue(global.DbUser),
+		"password=" + quoteDsnValue(global.DbPassword),
+		"dbname=" + quoteDsnValue(global.DbName),
+		"sslmode=" + quoteDsnValue(global.DbSslMode),
+	}
+
+	optional := []struct{ key, value string }{
+		{"sslrootcert", global.DbSslRootCert},
+		{"sslcert", global.DbSslCert},
+		{"sslkey", global.DbSslKey},
+	}
+	for _, param := range optional {
+		if param.value != "" {
+			params = append(params, param.key+"="+quoteDsnValue(param.value))
+		}
+	}
+
+	if global.DbStatementTimeout > 0 {
+		params = append(params, "statement_timeout="+strconv.Itoa(global.DbStatementTimeout))
+	}
+
+	return strings.Join(params, " ")
+}
+
+// quoteDsnValue quotes value for a key=value connection string, escaping backslashes and single quotes.
+func quoteDsnValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
 
-	dbConn, err := sql.Open("postgres", dbLogin)
+// InitDB initializes the PostgresSQL database, applies the connection pool profile and returns the connection pointer.
+// Reads are load-balanced across the optional read replicas, which are health checked every
+// DB_REPLICA_CHECK_INTERVAL seconds.
+func InitDB(pool PoolConfig, replicaDsns ...string) (*Context, error) {
+	dbConn, err := sql.Open("postgres", DataSourceName())
 	if err != nil {
 		return nil, err
 	}
+	applyPoolConfig(dbConn, pool)
+
 	if err = dbConn.Ping(); err != nil {
 		return nil, err
 	}
-	return &Context{db: dbConn}, nil
+
+	ctx := &Context{db: dbConn}
+	if len(replicaDsns) == 0 {
+		return ctx, nil
+	}
+
+	ctx.replicas, err = openReplicas(replicaDsns, pool)
+	if err != nil {
+		dbConn.Close()
+		return nil, err
+	}
+	go ctx.replicas.monitor(time.Duration(global.DbReplicaCheckInterval) * time.Second)
+
+	return ctx, nil
+}
+
+// applyPoolConfig applies the connection pool profile to conn.
+func applyPoolConfig(conn *sql.DB, pool PoolConfig) {
+	conn.SetMaxOpenConns(pool.MaxOpenConns)
+	if pool.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	conn.SetConnMaxLifetime(pool.ConnMaxLifetime)
 }
 
-// Close closes Context db connection
+// Close closes Context db connection and the read replica connections
 func (ctx *Context) Close() error {
+	if err := ctx.replicas.close(); err != nil {
+		return err
+	}
 	return ctx.db.Close()
 }
 
-// CreateAircraftCurrentTable creates a table for storing current aircraft data if it does not already exist
+// CreateAircraftCurrentTable creates a table for storing current aircraft data if it does not already exist.
+// Every row gets a number from aircraft_current_seq, and changed_at is set, when it is inserted or changed, so that the
+// changes since a number can be selected. first_seen and messages cover the time the aircraft has been in the table without a break,
+// batch_messages only the last batch.
+// Columns added after the table was first created are added to an existing table.
 func (ctx *Context) CreateAircraftCurrentTable() error {
-	query := `CREATE TABLE IF NOT EXISTS aircraft_current(
+	queries := []string{
+		`CREATE SEQUENCE IF NOT EXISTS aircraft_current_seq`,
+		`CREATE TABLE IF NOT EXISTS aircraft_current(
 				 icao VARCHAR(6) NOT NULL,
 				 callsign VARCHAR(10) NOT NULL,
 				 altitude INT NOT NULL,
@@ -124,22 +371,70 @@ func (ctx *Context) CreateAircraftCurrentTable() error {
 				 track INT NOT NULL,
 				 vspeed INT NOT NULL,
 				 timestamp TIMESTAMP NOT NULL,
+				 on_ground BOOLEAN NOT NULL DEFAULT FALSE,
+				 seq BIGINT NOT NULL DEFAULT nextval('aircraft_current_seq'),
+				 first_seen TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC'),
+				 messages INT NOT NULL DEFAULT 0,
+				 batch_messages INT NOT NULL DEFAULT 0,
+				 changed_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC'),
+				 PRIMARY KEY (icao))`,
+		`ALTER TABLE aircraft_current ADD COLUMN IF NOT EXISTS on_ground BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE aircraft_current ADD COLUMN IF NOT EXISTS seq BIGINT NOT NULL DEFAULT nextval('aircraft_current_seq')`,
+		`ALTER TABLE aircraft_current ADD COLUMN IF NOT EXISTS first_seen TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC')`,
+		`ALTER TABLE aircraft_current ADD COLUMN IF NOT EXISTS messages INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE aircraft_current ADD COLUMN IF NOT EXISTS batch_messages INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE aircraft_current ADD COLUMN IF NOT EXISTS changed_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC')`,
+	}
+
+	for _, query := range queries {
+		_, err := ctx.Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateAircraftCurrentRemovedTable creates a table for the aircraft removed from aircraft_current if it does not
+// already exist. The seq column continues the numbering of aircraft_current, so removals can be selected along with
+// the changes since a number.
+func (ctx *Context) CreateAircraftCurrentRemovedTable() error {
+	query := `CREATE TABLE IF NOT EXISTS aircraft_current_removed(
+				 icao VARCHAR(6) NOT NULL,
+				 seq BIGINT NOT NULL,
+				 removed_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC'),
 				 PRIMARY KEY (icao))`
+
 	_, err := ctx.Exec(query)
 	return err
 }
 
-// CreateAircraftHistoryTable creates a table for storing aircraft history data if it does not already exist
+// CreateAircraftHistoryTable creates a table for storing aircraft history data if it does not already exist.
+// callsign, altitude and messages are kept for the traffic statistics and track exports, they are NULL for history
+// recorded before they were added. Columns added after the table was first created are added to an existing table.
 func (ctx *Context) CreateAircraftHistoryTable() error {
-	query := `CREATE TABLE IF NOT EXISTS aircraft_history(
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS aircraft_history(
 				 icao VARCHAR(6) NOT NULL,
 				 lat DECIMAL NOT NULL,
 				 long DECIMAL NOT NULL,
 				 timestamp TIMESTAMP NOT NULL,
-				 PRIMARY KEY (icao,timestamp))`
+				 callsign VARCHAR(10),
+				 altitude INT,
+				 messages INT,
+				 PRIMARY KEY (icao,timestamp))`,
+		`ALTER TABLE aircraft_history ADD COLUMN IF NOT EXISTS callsign VARCHAR(10)`,
+		`ALTER TABLE aircraft_history ADD COLUMN IF NOT EXISTS altitude INT`,
+		`ALTER TABLE aircraft_history ADD COLUMN IF NOT EXISTS messages INT`,
+	}
 
-	_, err := ctx.Exec(query)
-	return err
+	for _, query := range queries {
+		_, err := ctx.Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // CreateAircraftHistoryTimestampIndex creates an index called timestamp_index on aircraft_history timestamp column
@@ -160,11 +455,37 @@ func (ctx *Context) DropAircraftCurrentTable() error {
 
 // BulkInsertAircraftCurrent inserts an array of new aircraft data into aircraft_current
 func (ctx *Context) BulkInsertAircraftCurrent(aircraft []models.AircraftCurrentModel) error {
+	query := `INSERT INTO aircraft_current (icao, callsign, altitude, lat, long, speed, track, vspeed, timestamp, on_ground, messages, first_seen, batch_messages) VALUES %s`
+	return ctx.bulkWriteAircraftCurrent(query, aircraft)
+}
+
+// BulkUpsertAircraftCurrent inserts an array of aircraft data into aircraft_current, updating the aircraft that are
+// already there. Only rows that actually change get a new number from aircraft_current_seq and a new changed_at. The
+// messages of an aircraft that is already there are added to its count, and it keeps its first_seen.
+func (ctx *Context) BulkUpsertAircraftCurrent(aircraft []models.AircraftCurrentModel) error {
+	query := `INSERT INTO aircraft_current (icao, callsign, altitude, lat, long, speed, track, vspeed, timestamp, on_ground, messages, first_seen, batch_messages) VALUES %s
+			  ON CONFLICT (icao) DO UPDATE SET
+				 callsign = EXCLUDED.callsign, altitude = EXCLUDED.altitude, lat = EXCLUDED.lat, long = EXCLUDED.long,
+				 speed = EXCLUDED.speed, track = EXCLUDED.track, vspeed = EXCLUDED.vspeed, timestamp = EXCLUDED.timestamp,
+				 on_ground = EXCLUDED.on_ground, messages = aircraft_current.messages + EXCLUDED.messages,
+				 batch_messages = EXCLUDED.batch_messages, seq = nextval('aircraft_current_seq'),
+				 changed_at = EXCLUDED.changed_at
+			  WHERE (aircraft_current.callsign, aircraft_current.altitude, aircraft_current.lat, aircraft_current.long,
+					 aircraft_current.speed, aircraft_current.track, aircraft_current.vspeed, aircraft_current.timestamp,
+					 aircraft_current.on_ground)
+				 IS DISTINCT FROM (EXCLUDED.callsign, EXCLUDED.altitude, EXCLUDED.lat, EXCLUDED.long, EXCLUDED.speed,
+					 EXCLUDED.track, EXCLUDED.vspeed, EXCLUDED.timestamp, EXCLUDED.on_ground)`
+	return ctx.bulkWriteAircraftCurrent(query, aircraft)
+}
+
+// bulkWriteAircraftCurrent executes query, where '%s' is replaced by the VALUES of the aircraft, in as few statements
+// as postgres allows. The timestamp of each aircraft is used as its first_seen, and its messages as its batch_messages.
+func (ctx *Context) bulkWriteAircraftCurrent(query string, aircraft []models.AircraftCurrentModel) error {
 	/*
 		Maximum number of aircraft per query
-		(65535 is the max number of parameters postgres supports and there are 9 aircraft parameters)
+		(65535 is the max number of parameters postgres supports and there are 13 aircraft parameters)
 	*/
-	const maxAircraft = 65535 / 9
+	const maxAircraft = 65535 / 13
 
 	for i := 0; i < len(aircraft); i += maxAircraft {
 		end := i + maxAircraft
@@ -178,14 +499,13 @@ func (ctx *Context) BulkInsertAircraftCurrent(aircraft []models.AircraftCurrentM
 		)
 
 		for j, ac := range aircraft[i:end] {
-			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-				j*9+1, j*9+2, j*9+3, j*9+4, j*9+5, j*9+6, j*9+7, j*9+8, j*9+9))
+			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				j*13+1, j*13+2, j*13+3, j*13+4, j*13+5, j*13+6, j*13+7, j*13+8, j*13+9, j*13+10, j*13+11, j*13+12, j*13+13))
 
 			vals = append(vals, ac.Icao, ac.Callsign, ac.Altitude, ac.Latitude, ac.Longitude,
-				ac.Speed, ac.Track, ac.VerticalRate, ac.Timestamp)
+				ac.Speed, ac.Track, ac.VerticalRate, ac.Timestamp, ac.OnGround, ac.Messages, ac.Timestamp, ac.Messages)
 		}
 
-		query := `INSERT INTO aircraft_current (icao, callsign, altitude, lat, long, speed, track, vspeed, timestamp) VALUES %s`
 		stmt := fmt.Sprintf(query, strings.Join(placeholders, ","))
 		_, err := ctx.Exec(stmt, vals...)
 		if err != nil {
@@ -196,20 +516,172 @@ func (ctx *Context) BulkInsertAircraftCurrent(aircraft []models.AircraftCurrentM
 	return nil
 }
 
-// InsertHistoryFromCurrent inserts all data from aircraft_current table to aircraft_history.
+// DeleteAircraftCurrentExcept deletes every aircraft from aircraft_current that is not in icaos, and records them in
+// aircraft_current_removed. Aircraft in icaos are no longer recorded as removed.
+func (ctx *Context) DeleteAircraftCurrentExcept(icaos []string) error {
+	if icaos == nil {
+		// a nil array is NULL, which would match no aircraft
+		icaos = []string{}
+	}
+
+	query := `WITH removed AS (
+				 DELETE FROM aircraft_current WHERE NOT (icao = ANY($1)) RETURNING icao)
+			  INSERT INTO aircraft_current_removed (icao, seq)
+			  SELECT icao, nextval('aircraft_current_seq') FROM removed
+			  ON CONFLICT (icao) DO UPDATE SET seq = EXCLUDED.seq, removed_at = EXCLUDED.removed_at`
+	_, err := ctx.Exec(query, pq.Array(icaos))
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM aircraft_current_removed WHERE icao = ANY($1)`
+	_, err = ctx.Exec(query, pq.Array(icaos))
+	return err
+}
+
+// DeleteOldAircraftCurrentRemoved deletes the aircraft that were removed from aircraft_current more than seconds ago.
+func (ctx *Context) DeleteOldAircraftCurrentRemoved(seconds int) error {
+	query := `DELETE FROM aircraft_current_removed
+			  WHERE removed_at < (now() AT TIME ZONE 'UTC') - make_interval(secs => $1)`
+
+	_, err := ctx.Exec(query, seconds)
+	return err
+}
+
+// InsertHistoryFromCurrent inserts all data from aircraft_current table to aircraft_history, along with the messages of
+// the last batch of each aircraft.
 func (ctx *Context) InsertHistoryFromCurrent() error {
-	query := `INSERT INTO aircraft_history (icao, lat, long, timestamp) 
-			  SELECT icao, lat, long, timestamp
+	query := `INSERT INTO aircraft_history (icao, lat, long, timestamp, callsign, altitude, messages) 
+			  SELECT icao, lat, long, timestamp, callsign, altitude, batch_messages
 			  FROM aircraft_current`
 	_, err := ctx.Exec(query)
 	return err
 }
 
-// SelectAllColumnsAircraftCurrent retrieves a list of all aircraft from aircraft_current that are older than global.WaitingTime + 2
-func (ctx *Context) SelectAllColumnsAircraftCurrent() (aircraft []models.AircraftCurrentModel, err error) {
-	query := `SELECT icao, callsign, altitude, lat, long, speed, track, vspeed, timestamp FROM aircraft_current`
+// SelectAllColumnsAircraftCurrent retrieves a list of all aircraft from aircraft_current that are older than global.WaitingTime + 2.
+// Aircraft found in aircraft_registry are joined with their registry data.
+func (ctx *Context) SelectAllColumnsAircraftCurrent() ([]models.AircraftCurrentModel, error) {
+	return ctx.selectAircraftCurrent("")
+}
+
+// SelectAircraftCurrentFiltered retrieves a list of the aircraft from aircraft_current matching every filter that is set.
+// Aircraft found in aircraft_registry are joined with their registry data.
+func (ctx *Context) SelectAircraftCurrentFiltered(filter models.AircraftCurrentFilter) ([]models.AircraftCurrentModel, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
 
-	rows, err := ctx.Query(query)
+	// addCondition adds a condition where '?' is replaced by the placeholder of arg
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.Bbox != nil {
+		addCondition("c.lat >= ?", filter.Bbox.MinLat)
+		addCondition("c.lat <= ?", filter.Bbox.MaxLat)
+		if filter.Bbox.MinLon <= filter.Bbox.MaxLon {
+			addCondition("c.long >= ?", filter.Bbox.MinLon)
+			addCondition("c.long <= ?", filter.Bbox.MaxLon)
+		} else {
+			// the bounding box crosses the antimeridian
+			args = append(args, filter.Bbox.MinLon, filter.Bbox.MaxLon)
+			conditions = append(conditions, fmt.Sprintf("(c.long >= $%d OR c.long <= $%d)", len(args)-1, len(args)))
+		}
+	}
+	if filter.MinAltitude != nil {
+		addCondition("c.altitude >= ?", *filter.MinAltitude)
+	}
+	if filter.MaxAltitude != nil {
+		addCondition("c.altitude <= ?", *filter.MaxAltitude)
+	}
+	if filter.MinSpeed != nil {
+		addCondition("c.speed >= ?", *filter.MinSpeed)
+	}
+	if filter.MaxSpeed != nil {
+		addCondition("c.speed <= ?", *filter.MaxSpeed)
+	}
+	if filter.CallsignPrefix != "" {
+		addCondition(`UPPER(c.callsign) LIKE ? ESCAPE '\'`, escapeLike(strings.ToUpper(filter.CallsignPrefix))+"%")
+	}
+	if filter.OnGround != nil {
+		addCondition("c.on_ground = ?", *filter.OnGround)
+	}
+
+	if len(conditions) == 0 {
+		return ctx.selectAircraftCurrent("")
+	}
+	return ctx.selectAircraftCurrent("WHERE "+strings.Join(conditions, " AND "), args...)
+}
+
+// SelectAircraftCurrentByIcao retrieves the aircraft from aircraft_current with the given icao, joined with its
+// registry data if it is found in aircraft_registry. Returns nil if the aircraft is not in aircraft_current.
+func (ctx *Context) SelectAircraftCurrentByIcao(search string) (*models.AircraftCurrentModel, error) {
+	aircraft, err := ctx.selectAircraftCurrent("WHERE UPPER(c.icao) = UPPER($1)", search)
+	if err != nil || len(aircraft) == 0 {
+		return nil, err
+	}
+	return &aircraft[0], nil
+}
+
+// SelectAircraftCurrentVersion retrieves the last number given to a change of aircraft_current, and the time of that
+// change. The time is the Unix epoch if aircraft_current has never had an aircraft.
+func (ctx *Context) SelectAircraftCurrentVersion() (models.AircraftCurrentVersion, error) {
+	query := `SELECT GREATEST((SELECT COALESCE(MAX(seq), 0) FROM aircraft_current),
+							  (SELECT COALESCE(MAX(seq), 0) FROM aircraft_current_removed)),
+					 COALESCE(GREATEST((SELECT MAX(changed_at) FROM aircraft_current),
+									   (SELECT MAX(removed_at) FROM aircraft_current_removed)), 'epoch')`
+
+	var version models.AircraftCurrentVersion
+	err := ctx.QueryRow(query).Scan(&version.Seq, &version.Time)
+	return version, err
+}
+
+// SelectAircraftCurrentChangedSince retrieves the aircraft from aircraft_current that were inserted or changed after
+// the change numbered seq. Aircraft found in aircraft_registry are joined with their registry data.
+func (ctx *Context) SelectAircraftCurrentChangedSince(seq int64) ([]models.AircraftCurrentModel, error) {
+	return ctx.selectAircraftCurrent("WHERE c.seq > $1", seq)
+}
+
+// SelectAircraftCurrentRemovedSince retrieves the ICAO of the aircraft removed from aircraft_current after the change
+// numbered seq.
+func (ctx *Context) SelectAircraftCurrentRemovedSince(seq int64) (icaos []string, err error) {
+	query := `SELECT icao FROM aircraft_current_removed WHERE seq > $1 ORDER BY icao`
+
+	rows, err := ctx.Query(query, seq)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var icao string
+		err = rows.Scan(&icao)
+		if err != nil {
+			return nil, err
+		}
+		icaos = append(icaos, icao)
+	}
+
+	return icaos, rows.Err()
+}
+
+// selectAircraftCurrent retrieves the aircraft from aircraft_current matching the where clause.
+func (ctx *Context) selectAircraftCurrent(where string, args ...interface{}) (aircraft []models.AircraftCurrentModel, err error) {
+	query := `SELECT c.icao, c.callsign, c.altitude, c.lat, c.long, c.speed, c.track, c.vspeed, c.timestamp, c.on_ground,
+			         c.first_seen, c.messages, COALESCE(r.registration, ''), COALESCE(r.type_code, ''),
+			         COALESCE(r.manufacturer, ''), COALESCE(r.model, ''), COALESCE(r.operator, ''), COALESCE(r.year, 0)
+			  FROM aircraft_current c
+			  LEFT JOIN aircraft_registry r ON r.icao = UPPER(c.icao) ` + where
+
+	rows, err := ctx.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -224,10 +696,14 @@ func (ctx *Context) SelectAllColumnsAircraftCurrent() (aircraft []models.Aircraf
 	for rows.Next() {
 		var ac models.AircraftCurrentModel
 		err = rows.Scan(&ac.Icao, &ac.Callsign, &ac.Altitude, &ac.Latitude, &ac.Longitude, &ac.Speed, &ac.Track,
-			&ac.VerticalRate, &ac.Timestamp)
+			&ac.VerticalRate, &ac.Timestamp, &ac.OnGround, &ac.FirstSeen, &ac.Messages, &ac.Registry.Registration,
+			&ac.Registry.TypeCode, &ac.Registry.Manufacturer, &ac.Registry.Model, &ac.Registry.Operator, &ac.Registry.Year)
 		if err != nil {
 			return nil, err
 		}
+		if ac.Registry != (models.AircraftRegistryModel{}) {
+			ac.Registry.Icao = strings.ToUpper(ac.Icao)
+		}
 
 		aircraft = append(aircraft, ac)
 	}
@@ -235,9 +711,14 @@ func (ctx *Context) SelectAllColumnsAircraftCurrent() (aircraft []models.Aircraf
 	return aircraft, nil
 }
 
+// escapeLike escapes the LIKE wildcards in value, so that it is matched literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
 // SelectAllColumnHistoryByIcao retrieves a list from aircraft_history of rows matching the icao parameter.
 func (ctx *Context) SelectAllColumnHistoryByIcao(search string) (aircraft []models.AircraftHistoryModel, err error) {
-	query := `SELECT icao, lat, long, timestamp FROM aircraft_history WHERE icao = $1 ORDER BY timestamp DESC`
+	query := `SELECT icao, lat, long, timestamp, altitude FROM aircraft_history WHERE icao = $1 ORDER BY timestamp DESC`
 
 	rows, err := ctx.Query(query, search)
 	if err != nil {
@@ -253,7 +734,7 @@ func (ctx *Context) SelectAllColumnHistoryByIcao(search string) (aircraft []mode
 
 	for rows.Next() {
 		var ac models.AircraftHistoryModel
-		err = rows.Scan(&ac.Icao, &ac.Latitude, &ac.Longitude, &ac.Timestamp)
+		err = rows.Scan(&ac.Icao, &ac.Latitude, &ac.Longitude, &ac.Timestamp, &ac.Altitude)
 		if err != nil {
 			return nil, err
 		}
@@ -278,7 +759,7 @@ func (ctx *Context) DeleteOldHistory(days int) error {
 // SelectAllColumnHistoryByIcaoFilterByTimestamp selects history by aircraft icao
 // and filters every row with a newer timestamp than given hour
 func (ctx *Context) SelectAllColumnHistoryByIcaoFilterByTimestamp(search string, hour int) (aircraft []models.AircraftHistoryModel, err error) {
-	query := `SELECT icao, lat, long, timestamp FROM aircraft_history 
+	query := `SELECT icao, lat, long, timestamp, altitude FROM aircraft_history 
          		 WHERE icao = $1 AND timestamp > (SELECT (MAX(timestamp) - ($2 * INTERVAL '1 hour'))
 				 FROM aircraft_history WHERE icao = $1) 
          		 ORDER BY timestamp DESC`
@@ -297,7 +778,7 @@ func (ctx *Context) SelectAllColumnHistoryByIcaoFilterByTimestamp(search string,
 
 	for rows.Next() {
 		var ac models.AircraftHistoryModel
-		err = rows.Scan(&ac.Icao, &ac.Latitude, &ac.Longitude, &ac.Timestamp)
+		err = rows.Scan(&ac.Icao, &ac.Latitude, &ac.Longitude, &ac.Timestamp, &ac.Altitude)
 		if err != nil {
 			return nil, err
 		}
@@ -307,3 +788,925 @@ func (ctx *Context) SelectAllColumnHistoryByIcaoFilterByTimestamp(search string,
 
 	return aircraft, nil
 }
+
+// SelectHistoryByIcaoTimeRange selects the history of an aircraft within the time range of filter, ordered and limited
+// by filter. The range is matched against the (icao, timestamp) primary key index.
+func (ctx *Context) SelectHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter) (aircraft []models.AircraftHistoryModel, err error) {
+	err = ctx.StreamHistoryByIcaoTimeRange(search, filter, func(ac models.AircraftHistoryModel) error {
+		aircraft = append(aircraft, ac)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return aircraft, nil
+}
+
+// StreamHistoryByIcaoTimeRange calls handle for every row of the history of an aircraft within the time range of
+// filter, ordered and limited by filter, without holding more than one row in memory. Stops at the first error
+// returned by handle.
+func (ctx *Context) StreamHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter,
+	handle func(models.AircraftHistoryModel) error) error {
+	return ctx.streamHistoryTimeRange("aircraft_history", "", search, filter, handle)
+}
+
+// streamHistoryTimeRange calls handle for every row of the history of an aircraft in table matching where, ordered and
+// limited by filter. where is added to the conditions of the query, and can use $1 for the icao.
+func (ctx *Context) streamHistoryTimeRange(table string, where string, search string,
+	filter models.AircraftHistoryFilter, handle func(models.AircraftHistoryModel) error) (err error) {
+	conditions := []string{"icao = $1"}
+	args := []interface{}{search}
+
+	if where != "" {
+		conditions = append(conditions, where)
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From.UTC().Format(timestampFormat))
+		conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To.UTC().Format(timestampFormat))
+		conditions = append(conditions, fmt.Sprintf("timestamp < $%d", len(args)))
+	}
+
+	order := "DESC"
+	cursorOperator := "<"
+	if filter.Ascending {
+		order = "ASC"
+		cursorOperator = ">"
+	}
+	if !filter.Cursor.IsZero() {
+		args = append(args, filter.Cursor.UTC().Format(timestampFormat))
+		conditions = append(conditions, fmt.Sprintf("timestamp %s $%d", cursorOperator, len(args)))
+	}
+
+	query := fmt.Sprintf(`SELECT icao, lat, long, timestamp, altitude FROM %s WHERE %s ORDER BY timestamp %s`,
+		table, strings.Join(conditions, " AND "), order)
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := ctx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var ac models.AircraftHistoryModel
+		err = rows.Scan(&ac.Icao, &ac.Latitude, &ac.Longitude, &ac.Timestamp, &ac.Altitude)
+		if err != nil {
+			return err
+		}
+
+		err = handle(ac)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// CreateAircraftHistoryRollupTable creates a table for storing downsampled aircraft history if it does not already
+// exist, and adds columns introduced later to an existing table.
+func (ctx *Context) CreateAircraftHistoryRollupTable() error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS aircraft_history_rollup(
+				 icao VARCHAR(6) NOT NULL,
+				 lat DECIMAL NOT NULL,
+				 long DECIMAL NOT NULL,
+				 timestamp TIMESTAMP NOT NULL,
+				 altitude INT,
+				 PRIMARY KEY (icao,timestamp))`,
+		`ALTER TABLE aircraft_history_rollup ADD COLUMN IF NOT EXISTS altitude INT`,
+	}
+
+	for _, query := range queries {
+		_, err := ctx.Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateAircraftHistoryRollupTimestampIndex creates an index called rollup_timestamp_index on aircraft_history_rollup
+// timestamp column
+func (ctx *Context) CreateAircraftHistoryRollupTimestampIndex() error {
+	query := `CREATE INDEX IF NOT EXISTS rollup_timestamp_index ON aircraft_history_rollup(timestamp)`
+
+	_, err := ctx.Exec(query)
+	return err
+}
+
+// SelectHistoryCutoff retrieves the timestamp MAX(timestamp) - days in aircraft_history.
+// Rows older than the cutoff are the rows DeleteOldHistory would delete. Returns an empty string if the table is empty.
+func (ctx *Context) SelectHistoryCutoff(days int) (string, error) {
+	query := `SELECT MAX(timestamp) - ($1 * INTERVAL '1 day') FROM aircraft_history`
+
+	var cutoff sql.NullString
+	err := ctx.QueryRow(query, days).Scan(&cutoff)
+	return cutoff.String, err
+}
+
+// InsertHistoryRollup downsamples the rows in aircraft_history older than cutoff into aircraft_history_rollup.
+// The first point of every aircraft in each resolution seconds long time bucket is kept.
+func (ctx *Context) InsertHistoryRollup(cutoff string, resolution int) error {
+	query := `INSERT INTO aircraft_history_rollup (icao, lat, long, timestamp, altitude)
+			  SELECT DISTINCT ON (icao, bucket) icao, lat, long, timestamp, altitude
+			  FROM (SELECT icao, lat, long, timestamp, altitude, FLOOR(EXTRACT(EPOCH FROM timestamp) / $2) AS bucket
+			        FROM aircraft_history
+			        WHERE timestamp < $1) AS old_history
+			  ORDER BY icao, bucket, timestamp
+			  ON CONFLICT (icao, timestamp) DO NOTHING`
+
+	_, err := ctx.Exec(query, cutoff, resolution)
+	return err
+}
+
+// DeleteHistoryBefore will delete rows in aircraft_history older than cutoff
+func (ctx *Context) DeleteHistoryBefore(cutoff string) error {
+	query := `DELETE FROM aircraft_history WHERE timestamp < $1`
+
+	_, err := ctx.Exec(query, cutoff)
+	return err
+}
+
+// DeleteOldHistoryRollup will delete rows in aircraft_history_rollup older than MAX(timestamp) days
+func (ctx *Context) DeleteOldHistoryRollup(days int) error {
+	query := `DELETE FROM aircraft_history_rollup
+			  WHERE timestamp <
+			        (SELECT MAX(timestamp) - ($1 * INTERVAL '1 day')
+			         FROM aircraft_history_rollup)`
+
+	_, err := ctx.Exec(query, days)
+	return err
+}
+
+// SelectAllColumnHistoryRollupByIcao retrieves a list from aircraft_history_rollup of rows matching the icao
+// parameter. Only rows older than the oldest row in aircraft_history are selected, so the result continues where the
+// raw history ends.
+func (ctx *Context) SelectAllColumnHistoryRollupByIcao(search string) (aircraft []models.AircraftHistoryModel, err error) {
+	query := `SELECT icao, lat, long, timestamp, altitude FROM aircraft_history_rollup
+			  WHERE icao = $1 AND timestamp <
+			        COALESCE((SELECT MIN(timestamp) FROM aircraft_history WHERE icao = $1), 'infinity')
+			  ORDER BY timestamp DESC`
+
+	rows, err := ctx.Query(query, search)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var ac models.AircraftHistoryModel
+		err = rows.Scan(&ac.Icao, &ac.Latitude, &ac.Longitude, &ac.Timestamp, &ac.Altitude)
+		if err != nil {
+			return nil, err
+		}
+
+		aircraft = append(aircraft, ac)
+	}
+
+	return aircraft, nil
+}
+
+// SelectAllColumnHistoryRollupByIcaoFilterByTimestamp selects rollup history by aircraft icao that is older than the
+// oldest row in aircraft_history, and filters every row with a newer timestamp than given hour. The hour is relative to
+// the latest timestamp of the aircraft, like in SelectAllColumnHistoryByIcaoFilterByTimestamp.
+func (ctx *Context) SelectAllColumnHistoryRollupByIcaoFilterByTimestamp(search string, hour int) (aircraft []models.AircraftHistoryModel, err error) {
+	query := `SELECT icao, lat, long, timestamp, altitude FROM aircraft_history_rollup
+			  WHERE icao = $1 AND timestamp <
+			        COALESCE((SELECT MIN(timestamp) FROM aircraft_history WHERE icao = $1), 'infinity')
+			  AND timestamp > (SELECT (COALESCE(
+			        (SELECT MAX(timestamp) FROM aircraft_history WHERE icao = $1),
+			        (SELECT MAX(timestamp) FROM aircraft_history_rollup WHERE icao = $1)) - ($2 * INTERVAL '1 hour')))
+			  ORDER BY timestamp DESC`
+
+	rows, err := ctx.Query(query, search, hour)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var ac models.AircraftHistoryModel
+		err = rows.Scan(&ac.Icao, &ac.Latitude, &ac.Longitude, &ac.Timestamp, &ac.Altitude)
+		if err != nil {
+			return nil, err
+		}
+
+		aircraft = append(aircraft, ac)
+	}
+
+	return aircraft, nil
+}
+
+// SelectHistoryRollupByIcaoTimeRange selects the downsampled history of an aircraft within the time range of filter,
+// ordered and limited by filter. Only rollup rows older than the raw history of the aircraft are selected.
+func (ctx *Context) SelectHistoryRollupByIcaoTimeRange(search string, filter models.AircraftHistoryFilter) (aircraft []models.AircraftHistoryModel, err error) {
+	err = ctx.StreamHistoryRollupByIcaoTimeRange(search, filter, func(ac models.AircraftHistoryModel) error {
+		aircraft = append(aircraft, ac)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return aircraft, nil
+}
+
+// StreamHistoryRollupByIcaoTimeRange calls handle for every row of the downsampled history of an aircraft within the
+// time range of filter, ordered and limited by filter. Only rollup rows older than the raw history of the aircraft are
+// streamed. Stops at the first error returned by handle.
+func (ctx *Context) StreamHistoryRollupByIcaoTimeRange(search string, filter models.AircraftHistoryFilter,
+	handle func(models.AircraftHistoryModel) error) error {
+	return ctx.streamHistoryTimeRange("aircraft_history_rollup",
+		`timestamp < COALESCE((SELECT MIN(timestamp) FROM aircraft_history WHERE icao = $1), 'infinity')`, search, filter,
+		handle)
+}
+
+// StreamHistoryBefore calls handle for every row in aircraft_history older than cutoff, with all its columns, ordered by
+// timestamp, without holding more than one row in memory. Stops at the first error returned by handle.
+func (ctx *Context) StreamHistoryBefore(cutoff string, handle func(models.AircraftHistoryModel) error) (err error) {
+	query := `SELECT icao, lat, long, timestamp, altitude, callsign, messages
+			  FROM aircraft_history
+			  WHERE timestamp < $1
+			  ORDER BY timestamp`
+
+	rows, err := ctx.Query(query, cutoff)
+	if err != nil {
+		return err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var ac models.AircraftHistoryModel
+		err = rows.Scan(&ac.Icao, &ac.Latitude, &ac.Longitude, &ac.Timestamp, &ac.Altitude, &ac.Callsign, &ac.Messages)
+		if err != nil {
+			return err
+		}
+
+		err = handle(ac)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// BulkInsertAircraftHistory inserts an array of history rows into aircraft_history, with their altitude, callsign and
+// messages. Rows that already exist are skipped, so the same rows can be inserted more than once.
+func (ctx *Context) BulkInsertAircraftHistory(aircraft []models.AircraftHistoryModel) error {
+	for i := 0; i < len(aircraft); i += MaxHistoryRowsPerInsert {
+		end := i + MaxHistoryRowsPerInsert
+		if end > len(aircraft) {
+			end = len(aircraft)
+		}
+
+		var (
+			placeholders []string
+			vals         []interface{}
+		)
+
+		for j, ac := range aircraft[i:end] {
+			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				j*7+1, j*7+2, j*7+3, j*7+4, j*7+5, j*7+6, j*7+7))
+
+			vals = append(vals, ac.Icao, ac.Latitude, ac.Longitude, ac.Timestamp, ac.Altitude, ac.Callsign, ac.Messages)
+		}
+
+		query := `INSERT INTO aircraft_history (icao, lat, long, timestamp, altitude, callsign, messages) VALUES %s
+				  ON CONFLICT (icao, timestamp) DO NOTHING`
+		stmt := fmt.Sprintf(query, strings.Join(placeholders, ","))
+		_, err := ctx.Exec(stmt, vals...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateAircraftSightingTable creates a table for every callsign each aircraft has been seen with if it does not
+// already exist, along with the prefix indexes for searching it by callsign and icao.
+func (ctx *Context) CreateAircraftSightingTable() error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS aircraft_sighting(
+				 icao VARCHAR(6) NOT NULL,
+				 callsign VARCHAR(10) NOT NULL,
+				 first_seen TIMESTAMP NOT NULL,
+				 last_seen TIMESTAMP NOT NULL,
+				 PRIMARY KEY (icao, callsign))`,
+		`CREATE INDEX IF NOT EXISTS sighting_callsign_index ON aircraft_sighting(UPPER(callsign) text_pattern_ops)`,
+		`CREATE INDEX IF NOT EXISTS sighting_icao_index ON aircraft_sighting(UPPER(icao) text_pattern_ops)`,
+	}
+
+	for _, query := range queries {
+		_, err := ctx.Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpsertAircraftSightingsFromCurrent records the callsign of every aircraft in aircraft_current in aircraft_sighting,
+// updating when it was last seen if it has been seen with that callsign before.
+func (ctx *Context) UpsertAircraftSightingsFromCurrent() error {
+	query := `INSERT INTO aircraft_sighting (icao, callsign, first_seen, last_seen)
+			  SELECT icao, callsign, timestamp, timestamp FROM aircraft_current
+			  ON CONFLICT (icao, callsign) DO UPDATE SET
+				 last_seen = GREATEST(aircraft_sighting.last_seen, EXCLUDED.last_seen)`
+
+	_, err := ctx.Exec(query)
+	return err
+}
+
+// DeleteOldAircraftSightings deletes the sightings last seen more than days before the latest sighting.
+func (ctx *Context) DeleteOldAircraftSightings(days int) error {
+	query := `DELETE FROM aircraft_sighting
+			  WHERE last_seen <
+			        (SELECT MAX(last_seen) - ($1 * INTERVAL '1 day')
+			         FROM aircraft_sighting)`
+
+	_, err := ctx.Exec(query, days)
+	return err
+}
+
+// SearchAircraftSightings retrieves at most limit sightings from aircraft_sighting where the callsign or the icao
+// starts with search, ignoring case, most recently seen first. Sightings of aircraft in aircraft_current with the same
+// callsign are marked as current.
+func (ctx *Context) SearchAircraftSightings(search string, limit int) (sightings []models.AircraftSightingModel, err error) {
+	query := `SELECT s.icao, s.callsign, s.first_seen, s.last_seen, c.icao IS NOT NULL
+			  FROM aircraft_sighting s
+			  LEFT JOIN aircraft_current c ON c.icao = s.icao AND c.callsign = s.callsign
+			  WHERE UPPER(s.callsign) LIKE $1 ESCAPE '\' OR UPPER(s.icao) LIKE $1 ESCAPE '\'
+			  ORDER BY s.last_seen DESC, s.icao, s.callsign
+			  LIMIT $2`
+
+	rows, err := ctx.Query(query, escapeLike(strings.ToUpper(search))+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var sighting models.AircraftSightingModel
+		err = rows.Scan(&sighting.Icao, &sighting.Callsign, &sighting.FirstSeen, &sighting.LastSeen, &sighting.Current)
+		if err != nil {
+			return nil, err
+		}
+		sightings = append(sightings, sighting)
+	}
+
+	return sightings, rows.Err()
+}
+
+// SelectTrafficStats selects the traffic in aircraft_history within the time range of filter for every hour or day,
+// given by interval, oldest first. Hours or days without traffic are left out.
+func (ctx *Context) SelectTrafficStats(filter models.StatsFilter, interval string) ([]models.TrafficStatsModel, error) {
+	query := `SELECT date_trunc($3, timestamp) AS bucket, COUNT(DISTINCT icao), COUNT(*), COALESCE(SUM(messages), 0)
+			  FROM aircraft_history
+			  WHERE timestamp >= $1 AND timestamp < $2
+			  GROUP BY bucket
+			  ORDER BY bucket`
+
+	return ctx.selectTrafficStats(query, filter.From.UTC().Format(timestampFormat),
+		filter.To.UTC().Format(timestampFormat), interval)
+}
+
+// SelectBusiestHours selects the limit hours within the time range of filter with the most unique aircraft in
+// aircraft_history, busiest first.
+func (ctx *Context) SelectBusiestHours(filter models.StatsFilter, limit int) ([]models.TrafficStatsModel, error) {
+	query := `SELECT date_trunc('hour', timestamp) AS bucket, COUNT(DISTINCT icao) AS aircraft, COUNT(*) AS positions,
+				 COALESCE(SUM(messages), 0)
+			  FROM aircraft_history
+			  WHERE timestamp >= $1 AND timestamp < $2
+			  GROUP BY bucket
+			  ORDER BY aircraft DESC, positions DESC, bucket
+			  LIMIT $3`
+
+	return ctx.selectTrafficStats(query, filter.From.UTC().Format(timestampFormat),
+		filter.To.UTC().Format(timestampFormat), limit)
+}
+
+// selectTrafficStats selects the traffic with query, which selects the time, aircraft, positions and messages columns.
+func (ctx *Context) selectTrafficStats(query string, args ...interface{}) (stats []models.TrafficStatsModel, err error) {
+	rows, err := ctx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var stat models.TrafficStatsModel
+		err = rows.Scan(&stat.Time, &stat.Aircraft, &stat.Positions, &stat.Messages)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// SelectAltitudeStats selects a histogram of the altitudes in aircraft_history within the time range of filter, in
+// bands of band feet, lowest first. Bands without traffic and rows without an altitude are left out.
+func (ctx *Context) SelectAltitudeStats(filter models.StatsFilter, band int) (stats []models.AltitudeStatsModel, err error) {
+	query := `SELECT FLOOR(altitude::DECIMAL / $3::INT)::INT * $3::INT AS band, COUNT(DISTINCT icao), COUNT(*)
+			  FROM aircraft_history
+			  WHERE timestamp >= $1 AND timestamp < $2 AND altitude IS NOT NULL
+			  GROUP BY band
+			  ORDER BY band`
+
+	rows, err := ctx.Query(query, filter.From.UTC().Format(timestampFormat), filter.To.UTC().Format(timestampFormat), band)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var stat models.AltitudeStatsModel
+		err = rows.Scan(&stat.Min, &stat.Aircraft, &stat.Positions)
+		if err != nil {
+			return nil, err
+		}
+		stat.Max = stat.Min + band
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// SelectAirlineStats selects the limit callsign prefixes in aircraft_history within the time range of filter with the
+// most unique aircraft, most first. Only callsigns of three letters followed by a digit, the ICAO designator of an
+// airline and a flight number, are counted.
+func (ctx *Context) SelectAirlineStats(filter models.StatsFilter, limit int) (stats []models.AirlineStatsModel, err error) {
+	query := `SELECT UPPER(LEFT(callsign, 3)) AS prefix, COUNT(DISTINCT icao) AS aircraft,
+				 COUNT(DISTINCT UPPER(callsign)), COUNT(*)
+			  FROM aircraft_history
+			  WHERE timestamp >= $1 AND timestamp < $2 AND callsign ~ '^[A-Za-z]{3}[0-9]'
+			  GROUP BY prefix
+			  ORDER BY aircraft DESC, prefix
+			  LIMIT $3`
+
+	rows, err := ctx.Query(query, filter.From.UTC().Format(timestampFormat), filter.To.UTC().Format(timestampFormat), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var stat models.AirlineStatsModel
+		err = rows.Scan(&stat.Prefix, &stat.Aircraft, &stat.Callsigns, &stat.Positions)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// CreateAircraftCoverageTable creates a table for the receiver coverage if it does not already exist. It keeps the
+// farthest position observed each day in every bearing sector and altitude band, so the coverage outlives the history.
+func (ctx *Context) CreateAircraftCoverageTable() error {
+	query := `CREATE TABLE IF NOT EXISTS aircraft_coverage(
+				 day DATE NOT NULL,
+				 band INT NOT NULL,
+				 sector INT NOT NULL,
+				 range DOUBLE PRECISION NOT NULL,
+				 lat DECIMAL NOT NULL,
+				 long DECIMAL NOT NULL,
+				 PRIMARY KEY (day, band, sector))`
+
+	_, err := ctx.Exec(query)
+	return err
+}
+
+// UpsertCoverageFromHistory records the farthest position from the receiver at latitude and longitude in
+// aircraft_history for every day, bearing sector and altitude band of band feet in aircraft_coverage, keeping the
+// farther position if there already is one. The circle around the receiver is divided into sectors sectors, and
+// positions below ground are in the lowest band. Only history since the start of the last day with coverage is read,
+// as the days before are already recorded.
+func (ctx *Context) UpsertCoverageFromHistory(latitude float64, longitude float64, sectors int, band int) error {
+	query := `WITH receiver AS (SELECT RADIANS($1::DOUBLE PRECISION) AS lat, RADIANS($2::DOUBLE PRECISION) AS long),
+			  positions AS (
+				 SELECT h.timestamp::DATE AS day,
+						FLOOR(GREATEST(h.altitude, 0)::DECIMAL / $4::INT)::INT * $4::INT AS band,
+						RADIANS(h.lat::DOUBLE PRECISION) AS lat, RADIANS(h.long::DOUBLE PRECISION) - r.long AS dlong,
+						r.lat AS rlat, h.lat AS hlat, h.long AS hlong
+				 FROM aircraft_history h, receiver r
+				 WHERE h.altitude IS NOT NULL AND h.timestamp >=
+					   (SELECT COALESCE(MAX(day), '-infinity') FROM aircraft_coverage))
+			  INSERT INTO aircraft_coverage (day, band, sector, range, lat, long)
+			  SELECT DISTINCT ON (day, band, sector) day, band, sector, range, hlat, hlong
+			  FROM (SELECT day, band, hlat, hlong,
+						   FLOOR(MOD((DEGREES(ATAN2(SIN(dlong) * COS(lat),
+								 COS(rlat) * SIN(lat) - SIN(rlat) * COS(lat) * COS(dlong))) + 360)::DECIMAL, 360)
+								 / (360.0 / $3::INT))::INT AS sector,
+						   2 * 6371 * ASIN(LEAST(1, SQRT(POWER(SIN((lat - rlat) / 2), 2) +
+								 COS(rlat) * COS(lat) * POWER(SIN(dlong / 2), 2)))) AS range
+					FROM positions) p
+			  ORDER BY day, band, sector, range DESC
+			  ON CONFLICT (day, band, sector) DO UPDATE SET
+				 range = EXCLUDED.range, lat = EXCLUDED.lat, long = EXCLUDED.long
+			  WHERE EXCLUDED.range > aircraft_coverage.range`
+
+	_, err := ctx.Exec(query, latitude, longitude, sectors, band)
+	return err
+}
+
+// DeleteOldCoverage deletes the coverage of the days more than days before the last day with coverage.
+func (ctx *Context) DeleteOldCoverage(days int) error {
+	query := `DELETE FROM aircraft_coverage
+			  WHERE day < (SELECT MAX(day) - $1::INT FROM aircraft_coverage)`
+
+	_, err := ctx.Exec(query, days)
+	return err
+}
+
+// SelectCoverage selects the farthest position in every altitude band and bearing sector over the days of filter,
+// ordered by band and sector.
+func (ctx *Context) SelectCoverage(filter models.CoverageFilter) (coverage []models.CoverageModel, err error) {
+	var conditions []string
+	var args []interface{}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From.UTC().Format(time.DateOnly))
+		conditions = append(conditions, fmt.Sprintf("day >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To.UTC().Format(time.DateOnly))
+		conditions = append(conditions, fmt.Sprintf("day < $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`SELECT DISTINCT ON (band, sector) band, sector, range, lat, long
+			  FROM aircraft_coverage
+			  %s
+			  ORDER BY band, sector, range DESC`, where)
+
+	rows, err := ctx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var c models.CoverageModel
+		err = rows.Scan(&c.Band, &c.Sector, &c.Range, &c.Latitude, &c.Longitude)
+		if err != nil {
+			return nil, err
+		}
+		coverage = append(coverage, c)
+	}
+
+	return coverage, rows.Err()
+}
+
+// SelectHeatmap counts the rows of aircraft_history within the time range and bounding box of filter in every cell of a
+// grid of filter.Cell degrees, ordered by row and column. Cells without rows are left out. The time range is matched
+// against the timestamp index.
+func (ctx *Context) SelectHeatmap(filter models.HeatmapFilter) (cells []models.HeatmapCellModel, err error) {
+	conditions := []string{"timestamp >= $1", "timestamp < $2"}
+	args := []interface{}{filter.From.UTC().Format(timestampFormat), filter.To.UTC().Format(timestampFormat), filter.Cell}
+
+	// addCondition adds a condition where '?' is replaced by the placeholder of arg
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.Bbox != nil {
+		addCondition("lat >= ?", filter.Bbox.MinLat)
+		addCondition("lat <= ?", filter.Bbox.MaxLat)
+		if filter.Bbox.MinLon <= filter.Bbox.MaxLon {
+			addCondition("long >= ?", filter.Bbox.MinLon)
+			addCondition("long <= ?", filter.Bbox.MaxLon)
+		} else {
+			// the bounding box crosses the antimeridian
+			args = append(args, filter.Bbox.MinLon, filter.Bbox.MaxLon)
+			conditions = append(conditions, fmt.Sprintf("(long >= $%d OR long <= $%d)", len(args)-1, len(args)))
+		}
+	}
+
+	query := fmt.Sprintf(`SELECT FLOOR(long::DOUBLE PRECISION / $3::DOUBLE PRECISION)::INT AS x,
+				 FLOOR(lat::DOUBLE PRECISION / $3::DOUBLE PRECISION)::INT AS y, COUNT(*)
+			  FROM aircraft_history
+			  WHERE %s
+			  GROUP BY y, x
+			  ORDER BY y, x`, strings.Join(conditions, " AND "))
+
+	rows, err := ctx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var cell models.HeatmapCellModel
+		err = rows.Scan(&cell.X, &cell.Y, &cell.Count)
+		if err != nil {
+			return nil, err
+		}
+		cells = append(cells, cell)
+	}
+
+	return cells, rows.Err()
+}
+
+// StreamHistoryExport calls handle for every row of aircraft_history within the time range and bounding box of filter,
+// ordered by timestamp, or by icao and then timestamp if filter.ByAircraft is set, without holding more than one row in
+// memory. The time range is matched against the timestamp index. Stops at the first error returned by handle.
+func (ctx *Context) StreamHistoryExport(filter models.HistoryExportFilter,
+	handle func(models.AircraftHistoryModel) error) (err error) {
+	conditions := []string{"timestamp >= $1", "timestamp < $2"}
+	args := []interface{}{filter.From.UTC().Format(timestampFormat), filter.To.UTC().Format(timestampFormat)}
+
+	// addCondition adds a condition where '?' is replaced by the placeholder of arg
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.Bbox != nil {
+		addCondition("lat >= ?", filter.Bbox.MinLat)
+		addCondition("lat <= ?", filter.Bbox.MaxLat)
+		if filter.Bbox.MinLon <= filter.Bbox.MaxLon {
+			addCondition("long >= ?", filter.Bbox.MinLon)
+			addCondition("long <= ?", filter.Bbox.MaxLon)
+		} else {
+			// the bounding box crosses the antimeridian
+			args = append(args, filter.Bbox.MinLon, filter.Bbox.MaxLon)
+			conditions = append(conditions, fmt.Sprintf("(long >= $%d OR long <= $%d)", len(args)-1, len(args)))
+		}
+	}
+
+	order := "timestamp, icao"
+	if filter.ByAircraft {
+		order = "icao, timestamp"
+	}
+
+	query := fmt.Sprintf(`SELECT icao, lat, long, timestamp, altitude FROM aircraft_history WHERE %s
+			  ORDER BY %s`, strings.Join(conditions, " AND "), order)
+
+	rows, err := ctx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var ac models.AircraftHistoryModel
+		err = rows.Scan(&ac.Icao, &ac.Latitude, &ac.Longitude, &ac.Timestamp, &ac.Altitude)
+		if err != nil {
+			return err
+		}
+
+		err = handle(ac)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// CreateAircraftRegistryTable creates a table for storing aircraft registry data if it does not already exist
+func (ctx *Context) CreateAircraftRegistryTable() error {
+	query := `CREATE TABLE IF NOT EXISTS aircraft_registry(
+				 icao VARCHAR(6) NOT NULL,
+				 registration TEXT NOT NULL DEFAULT '',
+				 type_code TEXT NOT NULL DEFAULT '',
+				 manufacturer TEXT NOT NULL DEFAULT '',
+				 model TEXT NOT NULL DEFAULT '',
+				 operator TEXT NOT NULL DEFAULT '',
+				 year INT,
+				 PRIMARY KEY (icao))`
+
+	_, err := ctx.Exec(query)
+	return err
+}
+
+// BulkUpsertAircraftRegistry inserts an array of registry rows into aircraft_registry.
+// Rows with an icao that already exists are updated. Every icao can only be in the array once.
+func (ctx *Context) BulkUpsertAircraftRegistry(registry []models.AircraftRegistryModel) error {
+	/*
+		Maximum number of rows per query
+		(65535 is the max number of parameters postgres supports and there are 7 registry parameters)
+	*/
+	const maxRows = 65535 / 7
+
+	for i := 0; i < len(registry); i += maxRows {
+		end := i + maxRows
+		if end > len(registry) {
+			end = len(registry)
+		}
+
+		var (
+			placeholders []string
+			vals         []interface{}
+		)
+
+		for j, r := range registry[i:end] {
+			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, NULLIF($%d, 0))",
+				j*7+1, j*7+2, j*7+3, j*7+4, j*7+5, j*7+6, j*7+7))
+
+			vals = append(vals, r.Icao, r.Registration, r.TypeCode, r.Manufacturer, r.Model, r.Operator, r.Year)
+		}
+
+		query := `INSERT INTO aircraft_registry (icao, registration, type_code, manufacturer, model, operator, year)
+				  VALUES %s
+				  ON CONFLICT (icao) DO UPDATE SET
+				      registration = EXCLUDED.registration, type_code = EXCLUDED.type_code,
+				      manufacturer = EXCLUDED.manufacturer, model = EXCLUDED.model,
+				      operator = EXCLUDED.operator, year = EXCLUDED.year`
+		stmt := fmt.Sprintf(query, strings.Join(placeholders, ","))
+		_, err := ctx.Exec(stmt, vals...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SelectAircraftRegistryByIcao retrieves the aircraft_registry row matching the icao parameter.
+// Returns nil if the aircraft is not registered.
+func (ctx *Context) SelectAircraftRegistryByIcao(search string) (*models.AircraftRegistryModel, error) {
+	query := `SELECT icao, registration, type_code, manufacturer, model, operator, COALESCE(year, 0)
+			  FROM aircraft_registry WHERE icao = UPPER($1)`
+
+	var r models.AircraftRegistryModel
+	err := ctx.QueryRow(query, search).Scan(&r.Icao, &r.Registration, &r.TypeCode, &r.Manufacturer, &r.Model,
+		&r.Operator, &r.Year)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// CreateApiKeyTable creates a table for storing the API keys of the REST API if it does not already exist. Only the
+// SHA-256 hash of each key is stored.
+func (ctx *Context) CreateApiKeyTable() error {
+	query := `CREATE TABLE IF NOT EXISTS api_key(
+				 name TEXT NOT NULL,
+				 key_hash CHAR(64) NOT NULL UNIQUE,
+				 prefix TEXT NOT NULL,
+				 scopes TEXT[] NOT NULL,
+				 rate_limit INT NOT NULL DEFAULT 0,
+				 created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC'),
+				 PRIMARY KEY (name))`
+
+	_, err := ctx.Exec(query)
+	return err
+}
+
+// InsertApiKey inserts a new API key into api_key, with hash as the hash of the key. Returns an error if there already
+// is a key with the same name.
+func (ctx *Context) InsertApiKey(key models.ApiKeyModel, hash string) error {
+	query := `INSERT INTO api_key (name, key_hash, prefix, scopes, rate_limit) VALUES ($1, $2, $3, $4, $5)`
+
+	// a key without scopes is stored with an empty array rather than NULL
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	_, err := ctx.Exec(query, key.Name, hash, key.Prefix, pq.Array(scopes), key.RateLimit)
+	return err
+}
+
+// SelectApiKeyByHash retrieves the API key with the hash parameter.
+// Returns nil if there is no such key. The key is read from the primary, so that a key that has just been created or
+// revoked is not looked up on a replica that is behind.
+func (ctx *Context) SelectApiKeyByHash(hash string) (*models.ApiKeyModel, error) {
+	query := `SELECT name, prefix, scopes, rate_limit, created_at FROM api_key WHERE key_hash = $1`
+
+	var key models.ApiKeyModel
+	err := ctx.db.QueryRow(query, hash).Scan(&key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.RateLimit,
+		&key.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// SelectApiKeys retrieves every API key, ordered by name.
+func (ctx *Context) SelectApiKeys() (keys []models.ApiKeyModel, err error) {
+	query := `SELECT name, prefix, scopes, rate_limit, created_at FROM api_key ORDER BY name`
+
+	rows, err := ctx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}(rows)
+
+	for rows.Next() {
+		var key models.ApiKeyModel
+		err = rows.Scan(&key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.RateLimit, &key.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// DeleteApiKey deletes the API key with the name parameter, which revokes it. Reports whether there was such a key.
+func (ctx *Context) DeleteApiKey(name string) (bool, error) {
+	query := `DELETE FROM api_key WHERE name = $1`
+
+	res, err := ctx.Exec(query, name)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := res.RowsAffected()
+	return deleted > 0, err
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+// replica represents a read-replica connection and whether it is currently in the read rotation.
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaSet load-balances reads across the healthy replicas, falling back to the primary when there are none.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	maxLag   time.Duration
+	stop     chan struct{}
+}
+
+// openReplicas opens a connection to each replica DSN with the given pool profile.
+// A replica that cannot be reached is kept out of the rotation until a health check succeeds.
+func openReplicas(dsns []string, pool PoolConfig) (*replicaSet, error) {
+	set := &replicaSet{
+		maxLag: time.Duration(global.DbReplicaMaxLag) * time.Second,
+		stop:   make(chan struct{}),
+	}
+
+	for _, dsn := range dsns {
+		conn, err := sql.Open("postgres", dsn)
+		if err != nil {
+			set.close()
+			return nil, err
+		}
+		applyPoolConfig(conn, pool)
+		set.replicas = append(set.replicas, &replica{db: conn})
+	}
+
+	set.checkHealth()
+	return set, nil
+}
+
+// reader returns the next healthy replica, or nil when no replica is healthy.
+func (set *replicaSet) reader() *replica {
+	if set == nil {
+		return nil
+	}
+
+	n := uint64(len(set.replicas))
+	start := set.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		r := set.replicas[(start+i)%n]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// fail takes the replica out of the rotation until the next health check, after err from its connection.
+func (r *replica) fail(err error) {
+	r.healthy.Store(false)
+	log.Warn().Msgf(errorMsg.ErrorReplicaUnhealthy+": %q", err)
+}
+
+// isConnectionError reports whether err is from the connection to the database, or the database not accepting
+// connections, rather than from the query itself, e.g. a syntax error or a violated constraint. Only the former means
+// that a replica is unhealthy.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// connection exceptions, and the server shutting down, crashing or starting up
+		switch pqErr.Code {
+		case "57P01", "57P02", "57P03":
+			return true
+		}
+		return pqErr.Code.Class() == "08"
+	}
+	return false
+}
+
+// checkHealth pings every replica and takes it out of the rotation when it is unreachable or its replication lag
+// exceeds DB_REPLICA_MAX_LAG.
+func (set *replicaSet) checkHealth() {
+	for i, r := range set.replicas {
+		lag, err := replicationLag(r.db)
+		healthy := err == nil && (set.maxLag <= 0 || lag <= set.maxLag)
+
+		if healthy != r.healthy.Load() {
+			if healthy {
+				log.Info().Msgf("read replica %d back in rotation", i)
+			} else if err != nil {
+				log.Warn().Msgf(errorMsg.ErrorReplicaUnhealthy+": replica %d: %q", i, err)
+			} else {
+				log.Warn().Msgf(errorMsg.ErrorReplicaUnhealthy+": replica %d: replication lag %s", i, lag)
+			}
+		}
+		r.healthy.Store(healthy)
+	}
+}
+
+// monitor runs checkHealth every interval until the replica set is closed.
+func (set *replicaSet) monitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			set.checkHealth()
+		case <-set.stop:
+			return
+		}
+	}
+}
+
+// close stops the health checks and closes every replica connection.
+func (set *replicaSet) close() error {
+	if set == nil {
+		return nil
+	}
+
+	close(set.stop)
+
+	var err error
+	for _, r := range set.replicas {
+		if closeErr := r.db.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// replicationLag returns how far behind the primary the replica is.
+// A replica that has replayed everything it has received is not lagging, even if the primary has been idle.
+func replicationLag(conn *sql.DB) (time.Duration, error) {
+	query := `SELECT CASE
+				 WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+				 ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+				 END`
+
+	var seconds float64
+	err := conn.QueryRow(query).Scan(&seconds)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
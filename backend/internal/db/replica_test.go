@@ -0,0 +1,103 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestReplicaSet(healthy ...bool) *replicaSet {
+	set := &replicaSet{stop: make(chan struct{})}
+	for _, h := range healthy {
+		r := &replica{db: &sql.DB{}}
+		r.healthy.Store(h)
+		set.replicas = append(set.replicas, r)
+	}
+	return set
+}
+
+func TestReplicaSet_Reader_RoundRobin(t *testing.T) {
+	set := newTestReplicaSet(true, true, true)
+
+	seen := make(map[*replica]int)
+	for i := 0; i < 6; i++ {
+		seen[set.reader()]++
+	}
+
+	assert.Equal(t, 3, len(seen))
+	for _, r := range set.replicas {
+		assert.Equal(t, 2, seen[r])
+	}
+}
+
+func TestReplicaSet_Reader_SkipsUnhealthy(t *testing.T) {
+	set := newTestReplicaSet(false, true, false)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, set.replicas[1], set.reader())
+	}
+}
+
+func TestReplicaSet_Reader_NoHealthyReplica(t *testing.T) {
+	set := newTestReplicaSet(false, false)
+	assert.Nil(t, set.reader())
+
+	var noReplicas *replicaSet
+	assert.Nil(t, noReplicas.reader())
+}
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		connection bool
+	}{
+		{name: "No error", err: nil, connection: false},
+		{name: "No rows", err: sql.ErrNoRows, connection: false},
+		{name: "Bad connection", err: fmt.Errorf("query: %w", driver.ErrBadConn), connection: true},
+		{name: "Unexpected EOF", err: io.ErrUnexpectedEOF, connection: true},
+		{name: "Network error", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			connection: true},
+		{name: "Connection failure", err: &pq.Error{Code: "08006"}, connection: true},
+		{name: "Server shutting down", err: &pq.Error{Code: "57P01"}, connection: true},
+		{name: "Syntax error", err: &pq.Error{Code: "42601"}, connection: false},
+		{name: "Unique violation", err: &pq.Error{Code: "23505"}, connection: false},
+		{name: "Statement timeout", err: &pq.Error{Code: "57014"}, connection: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.connection, isConnectionError(tt.err))
+		})
+	}
+}
+
+func TestRow_Scan_RetriesOnPrimary(t *testing.T) {
+	// nothing listens on the port, so the queries fail on their connection
+	unreachable, err := sql.Open("postgres", "host=127.0.0.1 port=1 sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatalf("error opening database: %q", err)
+	}
+	defer unreachable.Close()
+
+	set := newTestReplicaSet(true)
+	retried := false
+	row := &Row{row: unreachable.QueryRow("SELECT 1"), replica: set.replicas[0], retry: func() *sql.Row {
+		retried = true
+		return unreachable.QueryRow("SELECT 1")
+	}}
+
+	var n int
+	err = row.Scan(&n)
+
+	assert.True(t, isConnectionError(err))
+	assert.True(t, retried)
+	assert.False(t, set.replicas[0].healthy.Load())
+}
@@ -1,5 +1,7 @@
 package global
 
+import "time"
+
 // Default constant values
 
 // Database variables
@@ -9,15 +11,57 @@ var (
 	DbName     = "adsb_db"
 	DbHost     = "localhost"
 	DbPort     = 5432
+
+	DbSslMode          = "disable"
+	DbSslRootCert      string
+	DbSslCert          string
+	DbSslKey           string
+	DbDsn              string // full DSN, overrides all the connection variables above
+	DbStatementTimeout = 0    // milliseconds, 0 disables the timeout
+)
+
+// Database read replica variables
+var (
+	DbReplicaDsns          []string // read replicas used by the REST API, empty sends every read to the primary
+	DbReplicaMaxLag        = 10     // seconds, 0 disables the replication lag check
+	DbReplicaCheckInterval = 5      // seconds
+)
+
+// Database connection pool variables, one profile for each service
+var (
+	RestDbMaxOpenConns    = 20
+	RestDbMaxIdleConns    = 10
+	RestDbConnMaxLifetime = 300 // seconds, 0 reuses connections forever
+
+	ReceptionDbMaxOpenConns    = 4
+	ReceptionDbMaxIdleConns    = 2
+	ReceptionDbConnMaxLifetime = 300 // seconds, 0 reuses connections forever
 )
 
 // API constants
 const (
-	DefaultPort         = "8080"
-	VERSION             = "1.0.3"
-	DefaultPath         = "/"
-	AircraftCurrentPath = "/aircraft/current/"
-	AircraftHistoryPath = "/aircraft/history/"
+	DefaultPort          = "8080"
+	VERSION              = "1.0.3"
+	DefaultPath          = "/"
+	AircraftCurrentPath  = "/aircraft/current/"
+	AircraftHistoryPath  = "/aircraft/history/"
+	AircraftRegistryPath = "/aircraft/registry/"
+	AircraftStreamPath   = "/aircraft/stream"
+	AircraftLivePath     = "/aircraft/live"
+	AircraftSearchPath   = "/aircraft/search"
+	StatsPath            = "/stats/"
+	CoveragePath         = "/coverage"
+	HeatmapPath          = "/heatmap"
+	ExportHistoryPath    = "/export/history"
+	OpenApiPath          = "/openapi.json"
+	ApiVersionPrefix     = "/v1"     // prefix of every path of the current version of the API
+	ApiKeyParam          = "api_key" // query parameter of the API key, for the browsers that can not send headers
+)
+
+// API versioning variables, of the unversioned paths kept for clients from before ApiVersionPrefix
+var (
+	UnversionedDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC) // sent in the Deprecation header
+	UnversionedSunset     time.Time                                                 // sent in the Sunset header, unless zero
 )
 
 // SBS processing constants
@@ -28,3 +72,84 @@ var (
 	MaxDaysHistory  = 1
 	CleanupSchedule = "0 0 * * *" // once a day
 )
+
+// History rollup variables
+var (
+	RollupResolution int  // seconds between each kept point, 0 disables the rollup
+	MaxDaysRollup    = 30 // 0 keeps the rollup forever
+)
+
+// Current aircraft delta variables
+var (
+	CurrentDeltaRetention = 3600 // seconds removed aircraft are kept for ?since= tokens, older tokens get every aircraft
+)
+
+// Current aircraft detail variables
+var (
+	DefaultTrailMinutes = 10 // minutes of trail of /aircraft/current/{icao} without the trail query parameter
+	MaxTrailMinutes     = 60 // largest trail query parameter of /aircraft/current/{icao}
+)
+
+// Aircraft search variables
+var (
+	SearchDefaultLimit = 20  // results of /aircraft/search without the limit query parameter
+	SearchMaxLimit     = 100 // largest limit query parameter of /aircraft/search
+)
+
+// Traffic statistics variables
+var (
+	StatsDefaultDays  = 7   // days before now of /stats/ without the from query parameter
+	StatsMaxDays      = 31  // longest time range of /stats/
+	StatsDefaultLimit = 10  // results of /stats/busiest and /stats/airlines without the limit query parameter
+	StatsMaxLimit     = 100 // largest limit query parameter of /stats/busiest and /stats/airlines
+)
+
+// Receiver coverage variables
+var (
+	ReceiverLatitude     float64
+	ReceiverLongitude    float64
+	ReceiverPositionSet  bool          // the coverage is only computed when the receiver position is set
+	CoverageSchedule     = "5 * * * *" // once an hour
+	CoverageSectors      = 36          // bearing sectors around the receiver, 36 sectors are 10 degrees each
+	CoverageAltitudeBand = 10000       // feet
+	MaxDaysCoverage      = 90          // 0 keeps the coverage forever
+)
+
+// Traffic heatmap variables
+var (
+	HeatmapDefaultHours = 24     // hours before now of /heatmap without the from query parameter
+	HeatmapMaxDays      = 7      // longest time range of /heatmap
+	HeatmapMaxCells     = 100000 // most cells of the grid of /heatmap, over the whole bounding box
+)
+
+// Data export variables
+var (
+	ExportMaxDays = 7 // longest time range of /export/history
+)
+
+// Live stream variables
+var (
+	StreamPollInterval = 2   // seconds between each poll of the current aircraft
+	StreamHeartbeat    = 15  // seconds between each heartbeat sent to idle clients
+	StreamHistorySize  = 100 // events kept for clients resuming with Last-Event-ID
+)
+
+// HTTP caching variables
+var (
+	HistoryCacheMaxAge = 86400 // seconds a history range that has ended is cached for, a day
+)
+
+// API key and rate limit variables
+var (
+	ApiKeyScopes       = []string{"live", "history", "export"} // every scope an API key can have
+	AnonymousAccess    = true                                  // requests without an API key are allowed
+	AnonymousScopes    = []string{"live", "history"}           // scopes of the requests without an API key
+	AnonymousRateLimit = 60                                    // requests per minute of each address without an API key
+	ApiKeyRateLimit    = 600                                   // requests per minute of an API key without its own limit
+	TrustProxy         bool                                    // the address of a client is the last X-Forwarded-For
+)
+
+// History archive variables
+var (
+	ArchiveDir string // directory for archived history, empty disables archiving
+)
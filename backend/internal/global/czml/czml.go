@@ -0,0 +1,62 @@
+package czml
+
+// Packet is a CZML packet. A CZML document is an array of packets, where the first is the document packet with the
+// Version and Clock, and every other packet is an entity, e.g. an aircraft.
+type Packet struct {
+	Id           string    `json:"id"`
+	Name         string    `json:"name,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	Version      string    `json:"version,omitempty"`
+	Clock        *Clock    `json:"clock,omitempty"`
+	Availability string    `json:"availability,omitempty"`
+	Position     *Position `json:"position,omitempty"`
+	Point        *Point    `json:"point,omitempty"`
+	Path         *Path     `json:"path,omitempty"`
+}
+
+// Clock is the clock of the document, where Interval is an ISO 8601 interval of start/end.
+type Clock struct {
+	Interval    string `json:"interval"`
+	CurrentTime string `json:"currentTime"`
+	Multiplier  int    `json:"multiplier"`
+	Range       string `json:"range"`
+	Step        string `json:"step"`
+}
+
+// Position is a sampled position, where CartographicDegrees is seconds since Epoch, longitude, latitude and height in
+// meters for every sample. The interpolation and extrapolation members tell the client how to fill in between the
+// samples.
+type Position struct {
+	Epoch                     string    `json:"epoch"`
+	CartographicDegrees       []float64 `json:"cartographicDegrees"`
+	InterpolationAlgorithm    string    `json:"interpolationAlgorithm"`
+	InterpolationDegree       int       `json:"interpolationDegree"`
+	ForwardExtrapolationType  string    `json:"forwardExtrapolationType"`
+	BackwardExtrapolationType string    `json:"backwardExtrapolationType"`
+}
+
+type Point struct {
+	PixelSize int   `json:"pixelSize"`
+	Color     Color `json:"color"`
+}
+
+// Path is the line drawn behind the entity, for TrailTime seconds.
+type Path struct {
+	Width      int      `json:"width"`
+	LeadTime   int      `json:"leadTime"`
+	TrailTime  int      `json:"trailTime"`
+	Resolution int      `json:"resolution"`
+	Material   Material `json:"material"`
+}
+
+type Material struct {
+	SolidColor SolidColor `json:"solidColor"`
+}
+
+type SolidColor struct {
+	Color Color `json:"color"`
+}
+
+type Color struct {
+	Rgba []int `json:"rgba"`
+}
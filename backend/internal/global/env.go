@@ -2,8 +2,12 @@ package global
 
 import (
 	"adsb-api/internal/utility/logger"
+	"math"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
@@ -21,11 +25,24 @@ func InitEnvironment() {
 
 	InitDatabaseEnvVariables()
 	InitSbsEnvVariables()
+	InitRollupEnvVariables()
+	InitArchiveEnvVariables()
+	InitStreamEnvVariables()
+	InitCurrentDeltaEnvVariables()
+	InitCurrentDetailEnvVariables()
+	InitSearchEnvVariables()
+	InitStatsEnvVariables()
+	InitCoverageEnvVariables()
+	InitHeatmapEnvVariables()
+	InitExportEnvVariables()
+	InitVersioningEnvVariables()
+	InitCacheEnvVariables()
+	InitApiKeyEnvVariables()
 }
 
 // InitDatabaseEnvVariables initializes the environment variables related to the database.
-// It retrieves the values of the DB_USER, DB_PASSWORD, DB_HOST and DB_PORT environment variables and assigns
-// them to the respective variables.
+// It retrieves the values of the DB_USER, DB_PASSWORD, DB_HOST, DB_PORT, DB_SSL_MODE, DB_SSL_ROOT_CERT, DB_SSL_CERT,
+// DB_SSL_KEY, DB_DSN and DB_STATEMENT_TIMEOUT environment variables and assigns them to the respective variables.
 func InitDatabaseEnvVariables() {
 	DbUser = os.Getenv("DB_USER")
 	DbPassword = os.Getenv("DB_PASSWORD")
@@ -48,6 +65,84 @@ func InitDatabaseEnvVariables() {
 			log.Warn().Msgf("error setting environment variable 'DB_PORT': can only be an integer: Error %q", err)
 		}
 	}
+
+	dbSslMode, exist := os.LookupEnv("DB_SSL_MODE")
+	if exist {
+		DbSslMode = dbSslMode
+	}
+
+	DbSslRootCert = os.Getenv("DB_SSL_ROOT_CERT")
+	DbSslCert = os.Getenv("DB_SSL_CERT")
+	DbSslKey = os.Getenv("DB_SSL_KEY")
+	DbDsn = os.Getenv("DB_DSN")
+
+	dbStatementTimeout, exist := os.LookupEnv("DB_STATEMENT_TIMEOUT")
+	if exist {
+		DbStatementTimeout, err = strconv.Atoi(dbStatementTimeout)
+		if err != nil {
+			log.Warn().Msgf("error setting environment variable 'DB_STATEMENT_TIMEOUT': can only be an integer: Error %q", err)
+		}
+	}
+
+	InitDatabasePoolEnvVariables()
+	InitDatabaseReplicaEnvVariables()
+}
+
+// InitDatabaseReplicaEnvVariables initializes the environment variables related to the read replicas.
+// It retrieves the values of the DB_REPLICA_DSNS, a comma separated list of DSNs, DB_REPLICA_MAX_LAG and
+// DB_REPLICA_CHECK_INTERVAL environment variables and assigns them to the respective variables.
+func InitDatabaseReplicaEnvVariables() {
+	DbReplicaDsns = nil
+	for _, dsn := range strings.Split(os.Getenv("DB_REPLICA_DSNS"), ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			DbReplicaDsns = append(DbReplicaDsns, dsn)
+		}
+	}
+
+	var err error
+	maxLag, exist := os.LookupEnv("DB_REPLICA_MAX_LAG")
+	if exist {
+		DbReplicaMaxLag, err = strconv.Atoi(maxLag)
+		if err != nil {
+			log.Warn().Msgf("error setting environment variable 'DB_REPLICA_MAX_LAG': can only be an integer: Error %q", err)
+		}
+	}
+
+	checkInterval, exist := os.LookupEnv("DB_REPLICA_CHECK_INTERVAL")
+	if exist {
+		DbReplicaCheckInterval, err = strconv.Atoi(checkInterval)
+		if err != nil || DbReplicaCheckInterval <= 0 {
+			log.Warn().Msgf("error setting environment variable 'DB_REPLICA_CHECK_INTERVAL': can only be a positive integer: Error %q", err)
+			DbReplicaCheckInterval = 5
+		}
+	}
+}
+
+// InitDatabasePoolEnvVariables initializes the environment variables related to the database connection pools.
+// Each variable is prefixed with the service it applies to, REST_ or RECEPTION_, e.g., REST_DB_MAX_OPEN_CONNS.
+func InitDatabasePoolEnvVariables() {
+	pools := map[string]*int{
+		"REST_DB_MAX_OPEN_CONNS":         &RestDbMaxOpenConns,
+		"REST_DB_MAX_IDLE_CONNS":         &RestDbMaxIdleConns,
+		"REST_DB_CONN_MAX_LIFETIME":      &RestDbConnMaxLifetime,
+		"RECEPTION_DB_MAX_OPEN_CONNS":    &ReceptionDbMaxOpenConns,
+		"RECEPTION_DB_MAX_IDLE_CONNS":    &ReceptionDbMaxIdleConns,
+		"RECEPTION_DB_CONN_MAX_LIFETIME": &ReceptionDbConnMaxLifetime,
+	}
+
+	for name, variable := range pools {
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			log.Warn().Msgf("error setting environment variable '%s': can only be an integer: Error %q", name, err)
+			continue
+		}
+		*variable = parsed
+	}
 }
 
 // InitSbsEnvVariables initializes the environment variables related to the SBS.
@@ -87,6 +182,328 @@ func InitSbsEnvVariables() {
 	}
 }
 
+// InitRollupEnvVariables initializes the environment variables related to the history rollup.
+// It retrieves the values of the ROLLUP_RESOLUTION and MAX_DAYS_ROLLUP environment variables and assigns them to the
+// respective variables.
+func InitRollupEnvVariables() {
+	// MAX_DAYS_ROLLUP can be 0, which keeps the rollup forever
+	rollupVariables := map[string]struct {
+		variable *int
+		minimum  int
+	}{
+		"ROLLUP_RESOLUTION": {&RollupResolution, 1},
+		"MAX_DAYS_ROLLUP":   {&MaxDaysRollup, 0},
+	}
+
+	for name, rollup := range rollupVariables {
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < rollup.minimum {
+			log.Warn().Msgf("error setting environment variable '%s': can only be an integer of at least %d", name,
+				rollup.minimum)
+			continue
+		}
+		*rollup.variable = parsed
+	}
+}
+
+// InitArchiveEnvVariables initializes the environment variables related to the history archive.
+// It retrieves the value of the ARCHIVE_DIR environment variable and assigns it to ArchiveDir.
+func InitArchiveEnvVariables() {
+	ArchiveDir = os.Getenv("ARCHIVE_DIR")
+}
+
+// InitStreamEnvVariables initializes the environment variables related to the live stream.
+// It retrieves the values of the STREAM_POLL_INTERVAL, STREAM_HEARTBEAT and STREAM_HISTORY_SIZE environment variables
+// and assigns them to the respective variables.
+func InitStreamEnvVariables() {
+	streamVariables := map[string]*int{
+		"STREAM_POLL_INTERVAL": &StreamPollInterval,
+		"STREAM_HEARTBEAT":     &StreamHeartbeat,
+		"STREAM_HISTORY_SIZE":  &StreamHistorySize,
+	}
+
+	for name, variable := range streamVariables {
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			log.Warn().Msgf("error setting environment variable '%s': can only be a positive integer", name)
+			continue
+		}
+		*variable = parsed
+	}
+}
+
+// InitCurrentDeltaEnvVariables initializes the environment variables related to the current aircraft deltas.
+// It retrieves the value of the CURRENT_DELTA_RETENTION environment variable and assigns it to CurrentDeltaRetention.
+func InitCurrentDeltaEnvVariables() {
+	retention, exist := os.LookupEnv("CURRENT_DELTA_RETENTION")
+	if exist {
+		parsed, err := strconv.Atoi(retention)
+		if err != nil || parsed <= 0 {
+			log.Warn().Msgf("error setting environment variable 'CURRENT_DELTA_RETENTION': can only be a positive integer")
+			return
+		}
+		CurrentDeltaRetention = parsed
+	}
+}
+
+// InitCurrentDetailEnvVariables initializes the environment variables related to the current aircraft detail.
+// It retrieves the values of the DEFAULT_TRAIL_MINUTES and MAX_TRAIL_MINUTES environment variables and assigns them to
+// the respective variables.
+func InitCurrentDetailEnvVariables() {
+	trailVariables := map[string]*int{
+		"DEFAULT_TRAIL_MINUTES": &DefaultTrailMinutes,
+		"MAX_TRAIL_MINUTES":     &MaxTrailMinutes,
+	}
+
+	for name, variable := range trailVariables {
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			log.Warn().Msgf("error setting environment variable '%s': can only be a non-negative integer", name)
+			continue
+		}
+		*variable = parsed
+	}
+}
+
+// InitSearchEnvVariables initializes the environment variables related to the aircraft search.
+// It retrieves the values of the SEARCH_DEFAULT_LIMIT and SEARCH_MAX_LIMIT environment variables and assigns them to the
+// respective variables.
+func InitSearchEnvVariables() {
+	searchVariables := map[string]*int{
+		"SEARCH_DEFAULT_LIMIT": &SearchDefaultLimit,
+		"SEARCH_MAX_LIMIT":     &SearchMaxLimit,
+	}
+
+	for name, variable := range searchVariables {
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			log.Warn().Msgf("error setting environment variable '%s': can only be a positive integer", name)
+			continue
+		}
+		*variable = parsed
+	}
+}
+
+// InitStatsEnvVariables initializes the environment variables related to the traffic statistics.
+// It retrieves the values of the STATS_DEFAULT_DAYS, STATS_MAX_DAYS, STATS_DEFAULT_LIMIT and STATS_MAX_LIMIT
+// environment variables and assigns them to the respective variables.
+func InitStatsEnvVariables() {
+	statsVariables := map[string]*int{
+		"STATS_DEFAULT_DAYS":  &StatsDefaultDays,
+		"STATS_MAX_DAYS":      &StatsMaxDays,
+		"STATS_DEFAULT_LIMIT": &StatsDefaultLimit,
+		"STATS_MAX_LIMIT":     &StatsMaxLimit,
+	}
+
+	for name, variable := range statsVariables {
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			log.Warn().Msgf("error setting environment variable '%s': can only be a positive integer", name)
+			continue
+		}
+		*variable = parsed
+	}
+}
+
+// InitCoverageEnvVariables initializes the environment variables related to the receiver coverage.
+// It retrieves the values of the RECEIVER_LATITUDE, RECEIVER_LONGITUDE, COVERAGE_SCHEDULE, COVERAGE_SECTORS,
+// COVERAGE_ALTITUDE_BAND and MAX_DAYS_COVERAGE environment variables and assigns them to the respective variables.
+// ReceiverPositionSet is only set if both RECEIVER_LATITUDE and RECEIVER_LONGITUDE are valid.
+func InitCoverageEnvVariables() {
+	latitude, latExist := os.LookupEnv("RECEIVER_LATITUDE")
+	longitude, lonExist := os.LookupEnv("RECEIVER_LONGITUDE")
+	if latExist && lonExist {
+		lat, latErr := strconv.ParseFloat(latitude, 64)
+		lon, lonErr := strconv.ParseFloat(longitude, 64)
+		if latErr != nil || lonErr != nil || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+			log.Warn().Msgf("error setting environment variables 'RECEIVER_LATITUDE' and 'RECEIVER_LONGITUDE': " +
+				"can only be a latitude between -90 and 90 and a longitude between -180 and 180")
+		} else {
+			ReceiverLatitude, ReceiverLongitude, ReceiverPositionSet = lat, lon, true
+		}
+	}
+
+	coverageSchedule, exist := os.LookupEnv("COVERAGE_SCHEDULE")
+	if exist {
+		CoverageSchedule = coverageSchedule
+	}
+
+	coverageVariables := map[string]*int{
+		"COVERAGE_SECTORS":       &CoverageSectors,
+		"COVERAGE_ALTITUDE_BAND": &CoverageAltitudeBand,
+	}
+
+	for name, variable := range coverageVariables {
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			log.Warn().Msgf("error setting environment variable '%s': can only be a positive integer", name)
+			continue
+		}
+		*variable = parsed
+	}
+
+	maxDaysCoverage, exist := os.LookupEnv("MAX_DAYS_COVERAGE")
+	if exist {
+		parsed, err := strconv.Atoi(maxDaysCoverage)
+		if err != nil || parsed < 0 {
+			log.Warn().Msgf("error setting environment variable 'MAX_DAYS_COVERAGE': can only be a non-negative integer")
+		} else {
+			MaxDaysCoverage = parsed
+		}
+	}
+}
+
+// InitHeatmapEnvVariables initializes the environment variables related to the traffic heatmap.
+// It retrieves the values of the HEATMAP_DEFAULT_HOURS, HEATMAP_MAX_DAYS and HEATMAP_MAX_CELLS environment variables
+// and assigns them to the respective variables.
+func InitHeatmapEnvVariables() {
+	heatmapVariables := map[string]*int{
+		"HEATMAP_DEFAULT_HOURS": &HeatmapDefaultHours,
+		"HEATMAP_MAX_DAYS":      &HeatmapMaxDays,
+		"HEATMAP_MAX_CELLS":     &HeatmapMaxCells,
+	}
+
+	for name, variable := range heatmapVariables {
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			log.Warn().Msgf("error setting environment variable '%s': can only be a positive integer", name)
+			continue
+		}
+		*variable = parsed
+	}
+}
+
+// InitExportEnvVariables initializes the environment variables related to the data export.
+// It retrieves the value of the EXPORT_MAX_DAYS environment variable and assigns it to ExportMaxDays.
+func InitExportEnvVariables() {
+	exportMaxDays, exist := os.LookupEnv("EXPORT_MAX_DAYS")
+	if !exist {
+		return
+	}
+	parsed, err := strconv.Atoi(exportMaxDays)
+	if err != nil || parsed <= 0 {
+		log.Warn().Msgf("error setting environment variable 'EXPORT_MAX_DAYS': can only be a positive integer")
+		return
+	}
+	ExportMaxDays = parsed
+}
+
+// InitVersioningEnvVariables initializes the environment variables related to the API versions.
+// It retrieves the value of the UNVERSIONED_SUNSET environment variable, a date as YYYY-MM-DD, and assigns it to
+// UnversionedSunset.
+func InitVersioningEnvVariables() {
+	unversionedSunset, exist := os.LookupEnv("UNVERSIONED_SUNSET")
+	if !exist {
+		return
+	}
+	parsed, err := time.Parse(time.DateOnly, unversionedSunset)
+	if err != nil {
+		log.Warn().Msgf("error setting environment variable 'UNVERSIONED_SUNSET': can only be a date as YYYY-MM-DD")
+		return
+	}
+	UnversionedSunset = parsed
+}
+
+// InitCacheEnvVariables initializes the environment variables related to HTTP caching.
+// It retrieves the value of the HISTORY_CACHE_MAX_AGE environment variable and assigns it to HistoryCacheMaxAge.
+func InitCacheEnvVariables() {
+	historyCacheMaxAge, exist := os.LookupEnv("HISTORY_CACHE_MAX_AGE")
+	if !exist {
+		return
+	}
+	parsed, err := strconv.Atoi(historyCacheMaxAge)
+	if err != nil || parsed < 0 {
+		log.Warn().Msgf("error setting environment variable 'HISTORY_CACHE_MAX_AGE': can only be a non-negative integer")
+		return
+	}
+	HistoryCacheMaxAge = parsed
+}
+
+// InitApiKeyEnvVariables initializes the environment variables related to the API keys and rate limits.
+// It retrieves the values of the ANONYMOUS_ACCESS, ANONYMOUS_SCOPES, a comma separated list of scopes,
+// ANONYMOUS_RATE_LIMIT, API_KEY_RATE_LIMIT and TRUST_PROXY environment variables and assigns them to the respective
+// variables.
+func InitApiKeyEnvVariables() {
+	booleanVariables := map[string]*bool{
+		"ANONYMOUS_ACCESS": &AnonymousAccess,
+		"TRUST_PROXY":      &TrustProxy,
+	}
+	for name, variable := range booleanVariables {
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Warn().Msgf("error setting environment variable '%s': can only be true or false", name)
+			continue
+		}
+		*variable = parsed
+	}
+
+	rateLimitVariables := map[string]*int{
+		"ANONYMOUS_RATE_LIMIT": &AnonymousRateLimit,
+		"API_KEY_RATE_LIMIT":   &ApiKeyRateLimit,
+	}
+	for name, variable := range rateLimitVariables {
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			log.Warn().Msgf("error setting environment variable '%s': can only be a positive integer", name)
+			continue
+		}
+		*variable = parsed
+	}
+
+	anonymousScopes, exist := os.LookupEnv("ANONYMOUS_SCOPES")
+	if !exist {
+		return
+	}
+	var scopes []string
+	for _, scope := range strings.Split(anonymousScopes, ",") {
+		if scope = strings.ToLower(strings.TrimSpace(scope)); scope == "" {
+			continue
+		} else if !slices.Contains(ApiKeyScopes, scope) {
+			log.Warn().Msgf("error setting environment variable 'ANONYMOUS_SCOPES': can only be a comma separated "+
+				"list of %s", strings.Join(ApiKeyScopes, ", "))
+			return
+		}
+		scopes = append(scopes, scope)
+	}
+	AnonymousScopes = scopes
+}
+
 // InitTestEnvironment initializes the test environment by initializing the logger and setting up the test database
 // and SBS environment variables.
 func InitTestEnvironment() {
@@ -96,10 +513,65 @@ func InitTestEnvironment() {
 	DbName = "adsb_test_db"
 	DbHost = "localhost"
 	DbPort = 5432
+	DbSslMode = "disable"
+	DbSslRootCert = ""
+	DbSslCert = ""
+	DbSslKey = ""
+	DbDsn = ""
+	DbStatementTimeout = 0
+	DbReplicaDsns = nil
+	DbReplicaMaxLag = 10
+	DbReplicaCheckInterval = 5
 
 	SbsSource = "localhost:9999"
 	WaitingTime = 4
 	CleanupSchedule = "0 0 * * *"
 	UpdatingPeriod = 10
 	MaxDaysHistory = 1
+
+	RollupResolution = 0
+	MaxDaysRollup = 30
+
+	ArchiveDir = ""
+
+	StreamPollInterval = 2
+	StreamHeartbeat = 15
+	StreamHistorySize = 100
+
+	CurrentDeltaRetention = 3600
+
+	DefaultTrailMinutes = 10
+	MaxTrailMinutes = 60
+
+	SearchDefaultLimit = 20
+	SearchMaxLimit = 100
+
+	StatsDefaultDays = 7
+	StatsMaxDays = 31
+	StatsDefaultLimit = 10
+	StatsMaxLimit = 100
+
+	ReceiverLatitude = 60.39
+	ReceiverLongitude = 5.32
+	ReceiverPositionSet = true
+	CoverageSchedule = "5 * * * *"
+	CoverageSectors = 36
+	CoverageAltitudeBand = 10000
+	MaxDaysCoverage = 90
+
+	HeatmapDefaultHours = 24
+	HeatmapMaxDays = 7
+	HeatmapMaxCells = 100000
+
+	ExportMaxDays = 7
+
+	UnversionedSunset = time.Time{}
+
+	HistoryCacheMaxAge = 86400
+
+	AnonymousAccess = true
+	AnonymousScopes = []string{"live", "history"}
+	AnonymousRateLimit = 60
+	ApiKeyRateLimit = 600
+	TrustProxy = false
 }
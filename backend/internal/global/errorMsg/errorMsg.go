@@ -1,24 +1,95 @@
 package errorMsg
 
 const (
-	MethodNotSupported              = "method %s is not supported"
 	ErrorRetrievingCurrentAircraft  = "error retrieving current aircraft from database"
-	ErrorTongURL                    = "requested URL is too long"
 	ErrorInvalidQueryParams         = "invalid query parameter: Endpoint only supports the given parameters"
 	ErrorRetrievingAircraftWithIcao = "error retrieving aircraft history with icao"
 	ErrorConvertingDataToGeoJson    = "error converting aircraft data to Geo Json"
 	ErrorGeoJsonTooFewCoordinates   = "coordinates array must have at least 2 items"
 	ErrorEncodingJsonData           = "error encoding json data"
+	ErrorEncodingXmlData            = "error encoding xml data"
 	ErrorClosingDatabase            = "error closing database"
+	ErrorEncodingCursor             = "error encoding next page cursor"
+	ErrorStreamingHistory           = "error streaming aircraft history"
+	ErrorRetrievingExport           = "error retrieving history export"
+	ErrorStreamingExport            = "error streaming history export"
+	ErrorPollingCurrentAircraft     = "error polling current aircraft for the live stream"
+	ErrorStreamingNotSupported      = "streaming is not supported"
+	ErrorWritingStreamEvent         = "error writing live stream event"
+	ErrorUpgradingWebSocket         = "error upgrading connection to WebSocket"
+	ErrorClientTooSlow              = "client did not keep up with the live feed"
+	ErrorInvalidSubscription        = "invalid message: must be a JSON object with type 'subscribe'"
+	ErrorReplicaUnhealthy           = "read replica taken out of rotation"
 	ErrorCreatingDatabaseTables     = "error creating database tables"
 	ErrorInsertingNewSbsData        = "could not insert new SBS data"
 	ErrorCouldNotConnectToTcpStream = "could not connect to TCP stream"
 	EmptyIcao                       = "ICAO code cannot be empty"
 	InvalidQueryParameterHour       = "query parameter 'hour', can only be an integer"
+	InvalidQueryParameterTolerance  = "query parameter 'tolerance', can only be a non-negative number"
+	InvalidQueryParameterFrom       = "query parameter 'from', must be an RFC 3339 timestamp"
+	InvalidQueryParameterTo         = "query parameter 'to', must be an RFC 3339 timestamp after 'from'"
+	InvalidQueryParameterLimit      = "query parameter 'limit', can only be a positive integer"
+	InvalidQueryParameterCursor     = "query parameter 'cursor', must be the 'next' cursor of a previous page"
+	InvalidQueryParameterOrder      = "query parameter 'order', can only be asc or desc"
+	InvalidQueryParameterHourRange  = "query parameter 'hour', can not be combined with 'from', 'to', 'limit' or 'order'"
+	InvalidQueryParameterBbox       = "query parameter 'bbox', must be minLon,minLat,maxLon,maxLat with longitudes between -180 and 180, and latitudes between -90 and 90 where minLat is not above maxLat"
+	InvalidQueryParameterAltitude   = "query parameters 'minAltitude' and 'maxAltitude', can only be integers"
+	InvalidQueryParameterSpeed      = "query parameters 'minSpeed' and 'maxSpeed', can only be integers"
+	InvalidQueryParameterOnGround   = "query parameter 'onGround', can only be true or false"
+	InvalidQueryParameterSince      = "query parameter 'since', must be the 'token' of a previous response"
+	InvalidQueryParameterTrail      = "query parameter 'trail', can only be an integer of minutes between 0 and %d"
+	InvalidQueryParameterSearch     = "query parameter 'q', must be 1 to 10 characters"
+	InvalidQueryParameterMaxRange   = "query parameters 'from' and 'to', can be at most %d days apart"
+	InvalidQueryParameterInterval   = "query parameter 'interval', can only be hour or day"
+	InvalidQueryParameterBand       = "query parameter 'band', can only be a positive integer of feet"
+	InvalidQueryParameterDay        = "query parameters 'from', 'to', 'compareFrom' and 'compareTo', must be dates as YYYY-MM-DD where 'to' is after 'from'"
+	InvalidQueryParameterCell       = "query parameter 'cell', can only be a positive number of degrees"
+	InvalidQueryParameterCellCount  = "query parameters 'cell' and 'bbox', can make a grid of at most %d cells"
+	InvalidQueryParameterFormat     = "query parameter 'format', can only be geojson or array"
+	InvalidQueryParameterHistFormat = "query parameter 'format', can only be geojson, kml, gpx or czml"
+	InvalidQueryParameterHistLimit  = "query parameter 'limit', can only be used with format geojson"
+	InvalidQueryParameterExport     = "query parameter 'format', can only be csv, ndjson or czml"
+	InvalidQueryParameterFields     = "query parameter 'fields', can only be callsign, altitude, latitude, longitude, speed, track, vspeed, timestamp, onGround or registry"
 	TransactionInProgress           = "transaction already in progress"
 	NoTransactionInProgress         = "no transaction in progress"
 	TooLongIcao                     = "ICAO code cannot be longer than 6 characters"
 	ErrorDeletingOldHistory         = "error deleting old history"
+	ErrorSelectingHistoryCutoff     = "error selecting history cutoff"
+	ErrorRollingUpOldHistory        = "error rolling up old history"
+	ErrorDeletingOldRollup          = "error deleting old history rollup"
+	ErrorDeletingOldSightings       = "error deleting old aircraft sightings"
+	ErrorUpdatingCoverage           = "error updating receiver coverage"
+	ErrorDeletingOldCoverage        = "error deleting old receiver coverage"
+	ErrorRetrievingCoverage         = "error retrieving receiver coverage"
+	ErrorRetrievingHeatmap          = "error retrieving traffic heatmap"
+	ErrorArchivingOldHistory        = "error archiving old history"
+	ErrorArchiveAlreadyExists       = "archive already exists"
+	ErrorArchiveChecksumMismatch    = "archive file checksum does not match manifest"
+	ErrorArchiveRowCountMismatch    = "archive file row count does not match manifest"
+	ErrorArchiveRowsNotOrdered      = "archived rows are not ordered by timestamp"
+	ErrorRestoringArchive           = "error restoring archive"
+	ErrorRegistryMissingIcaoColumn  = "registry file has no icao column"
+	ErrorImportingRegistry          = "error importing aircraft registry"
+	ErrorRetrievingRegistry         = "error retrieving aircraft registry with icao"
+	ErrorSearchingAircraft          = "error searching aircraft"
+	ErrorRetrievingStats            = "error retrieving traffic statistics"
+	StatsNotFound                   = "statistics not found, available statistics: traffic, busiest, altitudes, airlines"
+	RegistryNotFound                = "aircraft not found in registry"
+	AircraftNotTracked              = "aircraft is not currently tracked"
+	ErrorBuildingOpenApi            = "error building openapi document"
+	ErrorRetrievingApiKey           = "error retrieving API key"
+	ErrorCreatingApiKey             = "error creating API key"
+	ErrorListingApiKeys             = "error listing API keys"
+	ErrorRevokingApiKey             = "error revoking API key"
+	ApiKeyRequired                  = "an API key is required, as a Bearer token in the Authorization header or in the X-API-Key header"
+	InvalidApiKey                   = "invalid API key"
+	MissingScope                    = "the '%s' scope is required for this endpoint"
+	RateLimitExceeded               = "rate limit exceeded, retry after %d seconds"
+	InvalidApiKeyScopes             = "scopes can only be a comma separated list of %s"
 
 	InfoOldHistoryDataDeleted = "old history data deleted"
+	InfoOldHistoryRolledUp    = "old history data rolled up"
+	InfoOldHistoryArchived    = "old history data archived"
+	InfoOldHistoryWasArchived = "old history data was already archived"
+	InfoCoverageUpdated       = "receiver coverage updated"
 )
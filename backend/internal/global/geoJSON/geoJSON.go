@@ -5,6 +5,10 @@ package geoJSON
 type FeatureCollectionPoint struct {
 	Type     string         `json:"type"`
 	Features []FeaturePoint `json:"features"`
+	// Removed, Full and Token are only set on /aircraft/current/ responses, see AircraftCurrentDelta
+	Removed []string `json:"removed,omitempty"`
+	Full    bool     `json:"full,omitempty"`
+	Token   string   `json:"token,omitempty"`
 }
 
 type FeaturePoint struct {
@@ -21,6 +25,43 @@ type AircraftCurrentProperties struct {
 	Track        int    `json:"track"`
 	VerticalRate int    `json:"vspeed"`
 	Timestamp    string `json:"timestamp"`
+	OnGround     bool   `json:"onGround"`
+	RegistryProperties
+}
+
+// RegistryProperties are the aircraft_registry properties of an aircraft. They are left out if the aircraft is not
+// registered.
+type RegistryProperties struct {
+	Registration string `json:"registration,omitempty"`
+	TypeCode     string `json:"typecode,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"`
+	Operator     string `json:"operator,omitempty"`
+	Year         int    `json:"year,omitempty"`
+}
+
+// GeoJson Feature for a single current aircraft, with its recent trail
+
+type FeatureAircraftDetail struct {
+	Type       string                   `json:"type"`
+	Geometry   geometryPoint            `json:"geometry"`
+	Properties AircraftDetailProperties `json:"properties"`
+}
+
+// AircraftDetailProperties are the properties of a current aircraft, along with when it was first and last seen since
+// it was last acquired, the number of SBS messages received in that time and its trail, oldest point first.
+type AircraftDetailProperties struct {
+	AircraftCurrentProperties
+	FirstSeen string       `json:"firstSeen"`
+	LastSeen  string       `json:"lastSeen"`
+	Messages  int          `json:"messages"`
+	Trail     []TrailPoint `json:"trail"`
+}
+
+type TrailPoint struct {
+	Latitude  float32 `json:"latitude"`
+	Longitude float32 `json:"longitude"`
+	Timestamp string  `json:"timestamp"`
 }
 
 type geometryPoint struct {
@@ -33,6 +74,8 @@ type geometryPoint struct {
 type FeatureCollectionLineString struct {
 	Type     string              `json:"type"`
 	Features []FeatureLineString `json:"features"`
+	// Next is the cursor of the next page, it is left out on the last page
+	Next string `json:"next,omitempty"`
 }
 
 type FeatureLineString struct {
@@ -42,10 +85,67 @@ type FeatureLineString struct {
 }
 
 type aircraftHistProperties struct {
-	Icao string `json:"icao"`
+	Icao           string `json:"icao"`
+	OriginalPoints int    `json:"originalPoints"`
+	Points         int    `json:"points"`
+	RegistryProperties
 }
 
 type geometryLineString struct {
 	Type        string      `json:"type"`
 	Coordinates [][]float32 `json:"coordinates"`
 }
+
+// GeoJson FeatureCollection for a Polygon type
+
+type FeatureCollectionPolygon struct {
+	Type     string           `json:"type"`
+	Features []FeaturePolygon `json:"features"`
+}
+
+type FeaturePolygon struct {
+	Type       string             `json:"type"`
+	Properties CoverageProperties `json:"properties"`
+	Geometry   geometryPolygon    `json:"geometry"`
+}
+
+// CoverageProperties are the properties of the receiver coverage in an altitude band, from MinAltitude up to
+// MaxAltitude feet. MaxRange is the farthest range in kilometers, and Sectors the number of bearing sectors with
+// coverage.
+type CoverageProperties struct {
+	CoverageWindow
+	MinAltitude int     `json:"minAltitude"`
+	MaxAltitude int     `json:"maxAltitude"`
+	MaxRange    float64 `json:"maxRange"`
+	Sectors     int     `json:"sectors"`
+}
+
+// CoverageWindow is the window of days of the coverage, a From or To that is left out leaves that end open.
+type CoverageWindow struct {
+	Window string `json:"window"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+type geometryPolygon struct {
+	Type        string        `json:"type"`
+	Coordinates [][][]float32 `json:"coordinates"`
+}
+
+// GeoJson FeatureCollection for the Polygon cells of a heatmap
+
+type FeatureCollectionHeatmap struct {
+	Type     string           `json:"type"`
+	Features []FeatureHeatmap `json:"features"`
+}
+
+type FeatureHeatmap struct {
+	Type       string            `json:"type"`
+	Properties HeatmapProperties `json:"properties"`
+	Geometry   geometryPolygon   `json:"geometry"`
+}
+
+// HeatmapProperties are the properties of a heatmap cell, where Count is the number of positions within it.
+type HeatmapProperties struct {
+	Count int `json:"count"`
+}
@@ -0,0 +1,35 @@
+package gpx
+
+import "encoding/xml"
+
+// Gpx is a GPX 1.1 document with a trk for every aircraft track.
+type Gpx struct {
+	XMLName  xml.Name `xml:"gpx"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Version  string   `xml:"version,attr"`
+	Creator  string   `xml:"creator,attr"`
+	Metadata Metadata `xml:"metadata"`
+	Tracks   []Track  `xml:"trk"`
+}
+
+type Metadata struct {
+	Name string `xml:"name"`
+}
+
+type Track struct {
+	Name     string         `xml:"name"`
+	Desc     string         `xml:"desc,omitempty"`
+	Segments []TrackSegment `xml:"trkseg"`
+}
+
+type TrackSegment struct {
+	Points []TrackPoint `xml:"trkpt"`
+}
+
+// TrackPoint is a trkpt, where Ele is the altitude in meters, left out for positions without an altitude.
+type TrackPoint struct {
+	Latitude  float32  `xml:"lat,attr"`
+	Longitude float32  `xml:"lon,attr"`
+	Ele       *float64 `xml:"ele,omitempty"`
+	Time      string   `xml:"time"`
+}
@@ -0,0 +1,31 @@
+package kml
+
+import "encoding/xml"
+
+// Kml is a KML document with a gx:Track placemark for every aircraft track.
+type Kml struct {
+	XMLName  xml.Name `xml:"kml"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	XmlnsGx  string   `xml:"xmlns:gx,attr"`
+	Document Document `xml:"Document"`
+}
+
+type Document struct {
+	Name       string      `xml:"name"`
+	Placemarks []Placemark `xml:"Placemark"`
+}
+
+type Placemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description,omitempty"`
+	Track       Track  `xml:"gx:Track"`
+}
+
+// Track is a gx:Track, where every When is the time of the Coord at the same index. Coords are "lon lat alt", with
+// the altitude in meters. Extrude connects the track to the ground, and is only used with an absolute AltitudeMode.
+type Track struct {
+	Extrude      int      `xml:"extrude"`
+	AltitudeMode string   `xml:"altitudeMode"`
+	When         []string `xml:"when"`
+	Coords       []string `xml:"gx:coord"`
+}
@@ -1,11 +1,21 @@
 package models
 
+import (
+	"strings"
+	"time"
+)
+
 // AircraftHistoryModel represent a row in aircraft_history
 type AircraftHistoryModel struct {
 	Icao      string  `json:"icao"`
 	Latitude  float32 `json:"latitude"`
 	Longitude float32 `json:"longitude"`
 	Timestamp string  `json:"timestamp"`
+	// Altitude is nil for positions recorded without an altitude
+	Altitude *int `json:"altitude,omitempty"`
+	// Callsign and Messages are only read for archiving, and are nil for positions recorded without them
+	Callsign *string `json:"callsign,omitempty"`
+	Messages *int    `json:"messages,omitempty"`
 }
 
 // AircraftCurrentModel represents a row in aircraft_current
@@ -19,4 +29,212 @@ type AircraftCurrentModel struct {
 	Track        int     `json:"track"`
 	VerticalRate int     `json:"vspeed"`
 	Timestamp    string  `json:"timestamp"`
+	OnGround     bool    `json:"onGround"`
+	// Messages is the number of SBS messages received for the aircraft since FirstSeen. FirstSeen is only set when
+	// selecting, as the timestamp of the aircraft when it was inserted into aircraft_current
+	Messages  int    `json:"messages"`
+	FirstSeen string `json:"firstSeen"`
+	// Registry is only set when selecting, it is empty if the aircraft is not in aircraft_registry
+	Registry AircraftRegistryModel `json:"registry"`
+}
+
+// AircraftCurrentDetail represents an aircraft from aircraft_current along with its trail, oldest point first.
+type AircraftCurrentDetail struct {
+	Aircraft AircraftCurrentModel
+	Trail    []AircraftHistoryModel
+}
+
+// AircraftSightingModel represents a row in aircraft_sighting. Current is only set when searching, if the aircraft is
+// in aircraft_current with the same callsign.
+type AircraftSightingModel struct {
+	Icao      string `json:"icao"`
+	Callsign  string `json:"callsign"`
+	FirstSeen string `json:"firstSeen"`
+	LastSeen  string `json:"lastSeen"`
+	Current   bool   `json:"current"`
+}
+
+// AircraftRegistryModel represents a row in aircraft_registry
+type AircraftRegistryModel struct {
+	Icao         string `json:"icao"`
+	Registration string `json:"registration"`
+	TypeCode     string `json:"typecode"`
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	Operator     string `json:"operator"`
+	Year         int    `json:"year"`
+}
+
+// AircraftCurrentVersion represents a version of aircraft_current, as the number of its last change and the time of
+// that change. Every response of the same version is the same, which makes the version the ETag of the current
+// aircraft.
+type AircraftCurrentVersion struct {
+	Seq  int64
+	Time time.Time
+}
+
+// AircraftCurrentDelta represents the changes to aircraft_current since a version. If Full is set, the changes since
+// that version are no longer known, and Updated holds every current aircraft instead.
+type AircraftCurrentDelta struct {
+	Updated []AircraftCurrentModel
+	Removed []string
+	Full    bool
+	Version AircraftCurrentVersion
+}
+
+// AircraftCurrentFilter represents the filters when selecting from aircraft_current. A nil field, or an empty
+// CallsignPrefix, is not filtered on.
+type AircraftCurrentFilter struct {
+	Bbox           *BoundingBox
+	MinAltitude    *int
+	MaxAltitude    *int
+	MinSpeed       *int
+	MaxSpeed       *int
+	CallsignPrefix string
+	OnGround       *bool
+}
+
+// Matches reports whether ac matches every filter that is set, the same way as selecting from aircraft_current.
+func (filter AircraftCurrentFilter) Matches(ac AircraftCurrentModel) bool {
+	if filter.Bbox != nil && !filter.Bbox.Contains(float64(ac.Longitude), float64(ac.Latitude)) {
+		return false
+	}
+	if filter.MinAltitude != nil && ac.Altitude < *filter.MinAltitude {
+		return false
+	}
+	if filter.MaxAltitude != nil && ac.Altitude > *filter.MaxAltitude {
+		return false
+	}
+	if filter.MinSpeed != nil && ac.Speed < *filter.MinSpeed {
+		return false
+	}
+	if filter.MaxSpeed != nil && ac.Speed > *filter.MaxSpeed {
+		return false
+	}
+	if filter.CallsignPrefix != "" && !strings.HasPrefix(strings.ToUpper(ac.Callsign), strings.ToUpper(filter.CallsignPrefix)) {
+		return false
+	}
+	if filter.OnGround != nil && ac.OnGround != *filter.OnGround {
+		return false
+	}
+	return true
+}
+
+// BoundingBox represents an area given by its south-west and north-east corners. MinLon is greater than MaxLon if the
+// area crosses the antimeridian.
+type BoundingBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+// Contains reports whether the position is inside the bounding box, edges included.
+func (bbox BoundingBox) Contains(lon float64, lat float64) bool {
+	if lat < bbox.MinLat || lat > bbox.MaxLat {
+		return false
+	}
+	if bbox.MinLon <= bbox.MaxLon {
+		return lon >= bbox.MinLon && lon <= bbox.MaxLon
+	}
+	// the bounding box crosses the antimeridian
+	return lon >= bbox.MinLon || lon <= bbox.MaxLon
+}
+
+// AircraftHistoryFilter represents the time range, limit and ordering when selecting the history of an aircraft.
+// From is inclusive and To is exclusive, a zero From or To leaves that end of the range open. A zero Limit selects
+// every row. The history is ordered newest first unless Ascending is set. Cursor is the timestamp of the last row of
+// the previous page, only rows after it in the ordering are selected.
+type AircraftHistoryFilter struct {
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Ascending bool
+	Cursor    time.Time
+}
+
+// StatsFilter represents the time range of the traffic statistics. From is inclusive and To is exclusive.
+type StatsFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+// TrafficStatsModel represents the traffic in aircraft_history during the hour or day starting at Time. Aircraft is the
+// number of unique aircraft, Positions the number of rows and Messages the number of SBS messages received.
+type TrafficStatsModel struct {
+	Time      string `json:"time"`
+	Aircraft  int    `json:"aircraft"`
+	Positions int    `json:"positions"`
+	Messages  int    `json:"messages"`
+}
+
+// AltitudeStatsModel represents the rows in aircraft_history with an altitude from Min up to, but not including, Max.
+type AltitudeStatsModel struct {
+	Min       int `json:"min"`
+	Max       int `json:"max"`
+	Aircraft  int `json:"aircraft"`
+	Positions int `json:"positions"`
+}
+
+// AirlineStatsModel represents the rows in aircraft_history with a callsign starting with Prefix, the ICAO designator
+// of an airline. Callsigns is the number of unique callsigns, e.g. flight numbers, of the airline.
+type AirlineStatsModel struct {
+	Prefix    string `json:"prefix"`
+	Aircraft  int    `json:"aircraft"`
+	Callsigns int    `json:"callsigns"`
+	Positions int    `json:"positions"`
+}
+
+// CoverageModel represents the farthest position observed from the receiver in a bearing sector and altitude band, with
+// its range in kilometers. Band is the lowest altitude of the band in feet. Sectors are numbered clockwise from north.
+type CoverageModel struct {
+	Band      int
+	Sector    int
+	Range     float64
+	Latitude  float32
+	Longitude float32
+}
+
+// CoverageFilter represents the days of the coverage to select. From is inclusive and To is exclusive, a zero From or
+// To leaves that end open.
+type CoverageFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+// HeatmapFilter represents the time range, area and grid of the traffic heatmap. From is inclusive and To is exclusive.
+// A nil Bbox covers the whole world. Cell is the size of each grid cell in degrees.
+type HeatmapFilter struct {
+	From time.Time
+	To   time.Time
+	Bbox *BoundingBox
+	Cell float64
+}
+
+// HeatmapCellModel represents the number of rows in aircraft_history in a grid cell. The cell spans from column X times
+// the cell size in longitude and row Y times the cell size in latitude, up to the next column and row.
+type HeatmapCellModel struct {
+	X     int
+	Y     int
+	Count int
+}
+
+// HistoryExportFilter represents the time range and area of an export of aircraft_history. From is inclusive and To is
+// exclusive. A nil Bbox covers the whole world. The rows are ordered by timestamp, or by aircraft and then timestamp if
+// ByAircraft is set.
+type HistoryExportFilter struct {
+	From       time.Time
+	To         time.Time
+	Bbox       *BoundingBox
+	ByAircraft bool
+}
+
+// ApiKeyModel represents a row in api_key. The key itself is only stored as its hash, Prefix is the start of the key
+// for telling the keys apart. A RateLimit of 0 uses the default rate limit of API keys.
+type ApiKeyModel struct {
+	Name      string
+	Prefix    string
+	Scopes    []string
+	RateLimit int
+	CreatedAt time.Time
 }
@@ -3,42 +3,92 @@ package aircraftCurrentHandler
 import (
 	"adsb-api/internal/global"
 	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/models"
 	"adsb-api/internal/service/restService"
 	"adsb-api/internal/utility/apiUtility"
 	"adsb-api/internal/utility/convert"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
-// CurrentAircraftHandler handles HTTP requests for /aircraft/current/ endpoint.
+var optionalParams = append(append([]string{}, apiUtility.CurrentFilterParams...), "since")
+
+// detailParams are the query parameters of /aircraft/current/{icao}
+var detailParams = []string{"trail"}
+
+// CurrentAircraftHandler handles HTTP requests for
+// /aircraft/current/?bbox=&minAltitude=&maxAltitude=&minSpeed=&maxSpeed=&callsign=&onGround=&since= and
+// /aircraft/current/{icao}?trail= endpoints.
 func CurrentAircraftHandler(svc restService.RestService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := apiUtility.ValidateURL(w, r, len(strings.Split(global.AircraftCurrentPath, "/"))-1, []string{})
+		icao := r.PathValue("icao")
+		params := optionalParams
+		if icao != "" {
+			params = detailParams
+		}
+
+		err := apiUtility.ValidateURL(w, r, params)
 		if err != nil {
 			return
 		}
-		switch r.Method {
-		case http.MethodGet:
+		if icao == "" {
 			handleCurrentAircraftGetRequest(w, r, svc)
-		default:
-			http.Error(w, fmt.Sprintf(errorMsg.MethodNotSupported, r.Method), http.StatusMethodNotAllowed)
+		} else {
+			handleCurrentAircraftDetailGetRequest(w, r, svc, icao)
 		}
 	}
 }
 
 // handleCurrentAircraftGetRequest handles GET requests for the /aircraft/current/ endpoint.
-// Sends all current aircraft in the database to the client.
+// Sends all current aircraft in the database matching the query parameters to the client, with a token for the
+// changes since to be requested with the since query parameter. The response is cached for global.UpdatingPeriod
+// seconds, and revalidated by the ETag of the version of the aircraft or its Last-Modified time.
 func handleCurrentAircraftGetRequest(w http.ResponseWriter, r *http.Request, svc restService.RestService) {
-	res, err := svc.GetCurrentAircraft()
+	if r.URL.Query().Has("since") {
+		handleCurrentAircraftSinceRequest(w, r, svc)
+		return
+	}
+
+	filter, err := apiUtility.ParseCurrentFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the version is retrieved first, so that a client with the aircraft of this version is answered without them
+	version, err := svc.GetCurrentAircraftVersion()
 	if err != nil {
 		http.Error(w, errorMsg.ErrorRetrievingCurrentAircraft, http.StatusInternalServerError)
 		log.Error().Msgf(errorMsg.ErrorRetrievingCurrentAircraft+": %q Path: %q", err, r.URL)
 		return
 	}
+
+	cache := apiUtility.Cache{ETag: currentETag(r, version), LastModified: version.Time, MaxAge: global.UpdatingPeriod}
+	if cache.NotModified(w, r) {
+		return
+	}
+
+	// the aircraft are sent with the version of the snapshot they are read from, which may be a later version, or from
+	// another replica, than the one the client was compared with
+	res, version, err := svc.GetCurrentAircraftWithVersion(filter)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorRetrievingCurrentAircraft, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorRetrievingCurrentAircraft+": %q Path: %q", err, r.URL)
+		return
+	}
+	cache.ETag, cache.LastModified = currentETag(r, version), version.Time
 	if len(res) == 0 {
+		cache.SetHeaders(w)
 		apiUtility.NoContent(w)
 		return
 	}
@@ -49,10 +99,135 @@ func handleCurrentAircraftGetRequest(w http.ResponseWriter, r *http.Request, svc
 		log.Error().Msgf(errorMsg.ErrorConvertingDataToGeoJson+": %q", err)
 		return
 	}
+	aircraft.Token = encodeToken(version)
+
+	cache.SetHeaders(w)
+	err = apiUtility.EncodeData(w, r, aircraft)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
+	}
+}
+
+// handleCurrentAircraftSinceRequest handles GET requests for the /aircraft/current/ endpoint with the since query
+// parameter. Sends the current aircraft matching the query parameters that were added or changed since the token, the
+// ICAO of the aircraft that were removed or no longer match, and a new token. If the changes since the token are no
+// longer known, every matching aircraft is sent with full set.
+func handleCurrentAircraftSinceRequest(w http.ResponseWriter, r *http.Request, svc restService.RestService) {
+	since, err := decodeToken(r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, errorMsg.InvalidQueryParameterSince, http.StatusBadRequest)
+		return
+	}
+
+	filter, err := apiUtility.ParseCurrentFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	delta, err := svc.GetCurrentAircraftSince(since, filter)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorRetrievingCurrentAircraft, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorRetrievingCurrentAircraft+": %q Path: %q", err, r.URL)
+		return
+	}
+
+	aircraft, err := convert.CurrentModelToGeoJson(delta.Updated)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorConvertingDataToGeoJson, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorConvertingDataToGeoJson+": %q", err)
+		return
+	}
+	if aircraft.Features == nil {
+		// an empty delta is still sent, for the client to get the new token
+		aircraft.Features = []geoJSON.FeaturePoint{}
+	}
+	aircraft.Removed = delta.Removed
+	aircraft.Full = delta.Full
+	aircraft.Token = encodeToken(delta.Version)
 
-	err = apiUtility.EncodeJsonData(w, aircraft)
+	err = apiUtility.EncodeData(w, r, aircraft)
 	if err != nil {
 		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
 		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
 	}
 }
+
+// handleCurrentAircraftDetailGetRequest handles GET requests for the /aircraft/current/{icao}?trail= endpoint.
+// Sends the current aircraft given by the icao path parameter as a GeoJSON Feature, with the trail of its last minutes
+// given by the trail parameter.
+func handleCurrentAircraftDetailGetRequest(w http.ResponseWriter, r *http.Request, svc restService.RestService, icao string) {
+	if len(icao) > 6 {
+		http.Error(w, errorMsg.TooLongIcao, http.StatusBadRequest)
+		return
+	}
+
+	trail := global.DefaultTrailMinutes
+	if r.URL.Query().Has("trail") {
+		var err error
+		trail, err = strconv.Atoi(r.URL.Query().Get("trail"))
+		if err != nil || trail < 0 || trail > global.MaxTrailMinutes {
+			http.Error(w, fmt.Sprintf(errorMsg.InvalidQueryParameterTrail, global.MaxTrailMinutes), http.StatusBadRequest)
+			return
+		}
+	}
+
+	res, err := svc.GetCurrentAircraftByIcao(icao, trail)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorRetrievingCurrentAircraft, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorRetrievingCurrentAircraft+": %q Path: %q", err, r.URL)
+		return
+	}
+	if res == nil {
+		http.Error(w, errorMsg.AircraftNotTracked, http.StatusNotFound)
+		return
+	}
+
+	aircraft, err := convert.CurrentDetailToGeoJson(*res)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorConvertingDataToGeoJson, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorConvertingDataToGeoJson+": %q", err)
+		return
+	}
+
+	err = apiUtility.EncodeData(w, r, aircraft)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
+	}
+}
+
+// currentETag returns the strong ETag of the current aircraft of version, for the query parameters and the Accept
+// header of r, which both change the response.
+func currentETag(r *http.Request, version models.AircraftCurrentVersion) string {
+	hash := fnv.New64a()
+	_, _ = io.WriteString(hash, r.URL.RawQuery+"\n"+r.Header.Get("Accept"))
+	return fmt.Sprintf(`"%d-%x"`, version.Seq, hash.Sum64())
+}
+
+// encodeToken encodes version as an opaque token for the since query parameter.
+func encodeToken(version models.AircraftCurrentVersion) string {
+	token := strconv.FormatInt(version.Seq, 10) + ":" + version.Time.UTC().Format(time.RFC3339Nano)
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+// decodeToken decodes a token made by encodeToken.
+func decodeToken(token string) (models.AircraftCurrentVersion, error) {
+	var version models.AircraftCurrentVersion
+
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return version, err
+	}
+
+	seq, timestamp, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return version, errors.New(errorMsg.InvalidQueryParameterSince)
+	}
+	if version.Seq, err = strconv.ParseInt(seq, 10, 64); err != nil {
+		return version, err
+	}
+	version.Time, err = time.Parse(time.RFC3339Nano, timestamp)
+	return version, err
+}
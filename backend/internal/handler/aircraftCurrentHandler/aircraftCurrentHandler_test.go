@@ -5,9 +5,13 @@ import (
 	"adsb-api/internal/global/errorMsg"
 	"adsb-api/internal/global/geoJSON"
 	"adsb-api/internal/global/models"
+	"adsb-api/internal/handler/router"
 	"adsb-api/internal/utility/convert"
 	"adsb-api/internal/utility/mock"
+	"adsb-api/internal/utility/protobuf"
 	"adsb-api/internal/utility/testUtility"
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,9 +19,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 func TestMain(m *testing.M) {
@@ -25,12 +31,19 @@ func TestMain(m *testing.M) {
 	m.Run()
 }
 
+// testVersion is the version of aircraft_current returned by the mocked service
+var testVersion = models.AircraftCurrentVersion{Seq: 42, Time: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+
 func TestInvalidRequests(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockSvc := mock.NewMockRestService(ctrl)
-	currentEndpoint := httptest.NewServer(CurrentAircraftHandler(mockSvc)) // Use mockSvc here
+	handler := CurrentAircraftHandler(mockSvc)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftCurrentPath+"{$}", handler),
+		router.Get(global.AircraftCurrentPath+"{icao}", handler),
+	}))
 	defer currentEndpoint.Close()
 
 	var endpoint = currentEndpoint.URL + global.AircraftCurrentPath
@@ -45,14 +58,14 @@ func TestInvalidRequests(t *testing.T) {
 			url:        endpoint,
 			httpMethod: http.MethodPost,
 			statusCode: http.StatusMethodNotAllowed,
-			errorMsg:   fmt.Sprintf(errorMsg.MethodNotSupported, http.MethodPost),
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
 		},
 		{
 			name:       "Delete request",
 			url:        endpoint,
 			httpMethod: http.MethodDelete,
 			statusCode: http.StatusMethodNotAllowed,
-			errorMsg:   fmt.Sprintf(errorMsg.MethodNotSupported, http.MethodDelete),
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
 		},
 		{
 			name:       "Database returns nil",
@@ -60,23 +73,157 @@ func TestInvalidRequests(t *testing.T) {
 			httpMethod: http.MethodGet,
 			statusCode: http.StatusInternalServerError,
 			setup: func(mockSvc *mock.MockRestService) {
-				mockSvc.EXPECT().GetCurrentAircraft().Return([]models.AircraftCurrentModel{}, errors.New("no new aircraft"))
+				mockSvc.EXPECT().GetCurrentAircraftVersion().Return(testVersion, nil)
+				mockSvc.EXPECT().GetCurrentAircraftWithVersion(models.AircraftCurrentFilter{}).Return([]models.AircraftCurrentModel{}, testVersion, errors.New("no new aircraft"))
+			},
+			errorMsg: errorMsg.ErrorRetrievingCurrentAircraft,
+		},
+		{
+			name:       "Database returns error for version",
+			url:        endpoint,
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusInternalServerError,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetCurrentAircraftVersion().Return(models.AircraftCurrentVersion{}, errors.New("connection refused"))
+			},
+			errorMsg: errorMsg.ErrorRetrievingCurrentAircraft,
+		},
+		{
+			name:       "Database returns error for since",
+			url:        endpoint + "?since=" + encodeToken(testVersion),
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusInternalServerError,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetCurrentAircraftSince(testVersion, models.AircraftCurrentFilter{}).
+					Return(models.AircraftCurrentDelta{}, errors.New("connection refused"))
+			},
+			errorMsg: errorMsg.ErrorRetrievingCurrentAircraft,
+		},
+		{
+			name:       "Get request with invalid since",
+			url:        endpoint + "?since=abc",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterSince,
+		},
+		{
+			name:       "Get request with since without sequence number",
+			url:        endpoint + "?since=" + base64.RawURLEncoding.EncodeToString([]byte("2024-01-01T10:00:00Z")),
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterSince,
+		},
+		{
+			name:       "Get request with since and invalid filter",
+			url:        endpoint + "?since=" + encodeToken(testVersion) + "&minSpeed=fast",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterSpeed,
+		},
+		{
+			name:       "Get request with unknown path",
+			url:        endpoint + "ABC123/endpoint/",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
+		},
+		{
+			name:       "Get request with too long ICAO",
+			url:        endpoint + "ABC1234",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.TooLongIcao,
+		},
+		{
+			name:       "Get request for aircraft not tracked",
+			url:        endpoint + "ABC123",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetCurrentAircraftByIcao("ABC123", global.DefaultTrailMinutes).Return(nil, nil)
+			},
+			errorMsg: errorMsg.AircraftNotTracked,
+		},
+		{
+			name:       "Database returns error for aircraft",
+			url:        endpoint + "ABC123",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusInternalServerError,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetCurrentAircraftByIcao("ABC123", global.DefaultTrailMinutes).
+					Return(nil, errors.New("connection refused"))
 			},
 			errorMsg: errorMsg.ErrorRetrievingCurrentAircraft,
 		},
 		{
-			name:       "Get request with too long URL",
-			url:        endpoint + "endpoint/",
+			name:       "Get request for aircraft with filter parameter",
+			url:        endpoint + "ABC123?callsign=sas",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": trail",
+		},
+		{
+			name:       "Get request for aircraft with invalid trail",
+			url:        endpoint + "ABC123?trail=-1",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   fmt.Sprintf(errorMsg.InvalidQueryParameterTrail, global.MaxTrailMinutes),
+		},
+		{
+			name:       "Get request for aircraft with too long trail",
+			url:        endpoint + "ABC123?trail=61",
 			httpMethod: http.MethodGet,
-			statusCode: http.StatusRequestURITooLong,
-			errorMsg:   errorMsg.ErrorTongURL,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   fmt.Sprintf(errorMsg.InvalidQueryParameterTrail, global.MaxTrailMinutes),
 		},
 		{
 			name:       "Get request with invalid parameter",
 			url:        endpoint + "?param=123",
 			httpMethod: http.MethodGet,
 			statusCode: http.StatusBadRequest,
-			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": ",
+			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": bbox, minAltitude, maxAltitude, minSpeed, maxSpeed, callsign, onGround, since",
+		},
+		{
+			name:       "Get request with too few bbox coordinates",
+			url:        endpoint + "?bbox=1,2,3",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterBbox,
+		},
+		{
+			name:       "Get request with bbox latitude out of range",
+			url:        endpoint + "?bbox=1,-91,3,4",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterBbox,
+		},
+		{
+			name:       "Get request with minLat above maxLat",
+			url:        endpoint + "?bbox=1,5,3,4",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterBbox,
+		},
+		{
+			name:       "Get request with invalid altitude",
+			url:        endpoint + "?minAltitude=high",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterAltitude,
+		},
+		{
+			name:       "Get request with invalid speed",
+			url:        endpoint + "?maxSpeed=1.5",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterSpeed,
+		},
+		{
+			name:       "Get request with invalid onGround",
+			url:        endpoint + "?onGround=maybe",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterOnGround,
 		},
 	}
 
@@ -113,7 +260,11 @@ func TestValidRequests(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSvc := mock.NewMockRestService(ctrl)
-	currentEndpoint := httptest.NewServer(CurrentAircraftHandler(mockSvc)) // Use mockSvc here
+	handler := CurrentAircraftHandler(mockSvc)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftCurrentPath+"{$}", handler),
+		router.Get(global.AircraftCurrentPath+"{icao}", handler),
+	}))
 	defer currentEndpoint.Close()
 
 	var endpoint = currentEndpoint.URL + global.AircraftCurrentPath
@@ -131,7 +282,40 @@ func TestValidRequests(t *testing.T) {
 			statusCode: http.StatusOK,
 			mockData:   testUtility.CreateMockAircraft(10),
 			setup: func(mockSvc *mock.MockRestService, mockData []models.AircraftCurrentModel) {
-				mockSvc.EXPECT().GetCurrentAircraft().Return(mockData, nil)
+				mockSvc.EXPECT().GetCurrentAircraftVersion().Return(testVersion, nil)
+				mockSvc.EXPECT().GetCurrentAircraftWithVersion(models.AircraftCurrentFilter{}).Return(mockData, testVersion, nil)
+			},
+		},
+		{
+			name:       "Get request with all filters",
+			url:        endpoint + "?bbox=170,-10,-170,10.5&minAltitude=1000&maxAltitude=5000&minSpeed=100&maxSpeed=300&callsign=sas&onGround=false",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusOK,
+			mockData:   testUtility.CreateMockAircraft(2),
+			setup: func(mockSvc *mock.MockRestService, mockData []models.AircraftCurrentModel) {
+				minAltitude, maxAltitude, minSpeed, maxSpeed, onGround := 1000, 5000, 100, 300, false
+				mockSvc.EXPECT().GetCurrentAircraftVersion().Return(testVersion, nil)
+				mockSvc.EXPECT().GetCurrentAircraftWithVersion(models.AircraftCurrentFilter{
+					Bbox:           &models.BoundingBox{MinLon: 170, MinLat: -10, MaxLon: -170, MaxLat: 10.5},
+					MinAltitude:    &minAltitude,
+					MaxAltitude:    &maxAltitude,
+					MinSpeed:       &minSpeed,
+					MaxSpeed:       &maxSpeed,
+					CallsignPrefix: "sas",
+					OnGround:       &onGround,
+				}).Return(mockData, testVersion, nil)
+			},
+		},
+		{
+			name:       "Get request with filters matching no aircraft",
+			url:        endpoint + "?onGround=true",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNoContent,
+			setup: func(mockSvc *mock.MockRestService, mockData []models.AircraftCurrentModel) {
+				onGround := true
+				mockSvc.EXPECT().GetCurrentAircraftVersion().Return(testVersion, nil)
+				mockSvc.EXPECT().GetCurrentAircraftWithVersion(models.AircraftCurrentFilter{OnGround: &onGround}).
+					Return([]models.AircraftCurrentModel{}, testVersion, nil)
 			},
 		},
 		{
@@ -140,7 +324,8 @@ func TestValidRequests(t *testing.T) {
 			httpMethod: http.MethodGet,
 			statusCode: http.StatusNoContent,
 			setup: func(mockSvc *mock.MockRestService, mockData []models.AircraftCurrentModel) {
-				mockSvc.EXPECT().GetCurrentAircraft().Return([]models.AircraftCurrentModel{}, nil)
+				mockSvc.EXPECT().GetCurrentAircraftVersion().Return(testVersion, nil)
+				mockSvc.EXPECT().GetCurrentAircraftWithVersion(models.AircraftCurrentFilter{}).Return([]models.AircraftCurrentModel{}, testVersion, nil)
 			},
 		},
 	}
@@ -172,8 +357,374 @@ func TestValidRequests(t *testing.T) {
 			}
 
 			mockFeatureCollection, err := convert.CurrentModelToGeoJson(tt.mockData)
+			mockFeatureCollection.Token = encodeToken(testVersion)
 
 			assert.Equal(t, mockFeatureCollection, actual)
 		})
 	}
 }
+
+func TestSinceRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	handler := CurrentAircraftHandler(mockSvc)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftCurrentPath+"{$}", handler),
+		router.Get(global.AircraftCurrentPath+"{icao}", handler),
+	}))
+	defer currentEndpoint.Close()
+
+	var endpoint = currentEndpoint.URL + global.AircraftCurrentPath + "?since=" + encodeToken(testVersion)
+	newVersion := models.AircraftCurrentVersion{Seq: 50, Time: testVersion.Time.Add(10 * time.Second)}
+
+	tests := []struct {
+		name, url string
+		filter    models.AircraftCurrentFilter
+		delta     models.AircraftCurrentDelta
+	}{
+		{
+			name:  "Changed and removed aircraft",
+			url:   endpoint,
+			delta: models.AircraftCurrentDelta{Updated: testUtility.CreateMockAircraft(2), Removed: []string{"ABC123"}, Version: newVersion},
+		},
+		{
+			name:   "Changes matching filter",
+			url:    endpoint + "&callsign=sas",
+			filter: models.AircraftCurrentFilter{CallsignPrefix: "sas"},
+			delta:  models.AircraftCurrentDelta{Updated: testUtility.CreateMockAircraft(1), Version: newVersion},
+		},
+		{
+			name:  "Token too old",
+			url:   endpoint,
+			delta: models.AircraftCurrentDelta{Updated: testUtility.CreateMockAircraft(3), Full: true, Version: newVersion},
+		},
+		{
+			name:  "Nothing changed",
+			url:   endpoint,
+			delta: models.AircraftCurrentDelta{Version: testVersion},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc.EXPECT().GetCurrentAircraftSince(testVersion, tt.filter).Return(tt.delta, nil)
+
+			res, err := http.Get(tt.url)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			// an empty delta is not 204 No Content, the client needs the new token
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+
+			var actual geoJSON.FeatureCollectionPoint
+			err = json.NewDecoder(res.Body).Decode(&actual)
+			if err != nil {
+				t.Errorf("Test: %s. Error decoding response body: %s", tt.name, err.Error())
+			}
+
+			expected, _ := convert.CurrentModelToGeoJson(tt.delta.Updated)
+			if expected.Features == nil {
+				expected.Features = []geoJSON.FeaturePoint{}
+			}
+			expected.Removed = tt.delta.Removed
+			expected.Full = tt.delta.Full
+			expected.Token = encodeToken(tt.delta.Version)
+
+			assert.Equal(t, expected, actual)
+
+			since, err := decodeToken(actual.Token)
+			assert.NoError(t, err)
+			assert.True(t, tt.delta.Version.Time.Equal(since.Time))
+			assert.Equal(t, tt.delta.Version.Seq, since.Seq)
+		})
+	}
+}
+
+func TestDetailRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	handler := CurrentAircraftHandler(mockSvc)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftCurrentPath+"{$}", handler),
+		router.Get(global.AircraftCurrentPath+"{icao}", handler),
+	}))
+	defer currentEndpoint.Close()
+
+	var endpoint = currentEndpoint.URL + global.AircraftCurrentPath
+
+	aircraft := testUtility.CreateMockAircraftWithTimestamp("ABC123", "2024-01-01T10:05:00Z")
+	aircraft.FirstSeen = "2024-01-01T09:30:00Z"
+	aircraft.Messages = 120
+	aircraft.Registry = models.AircraftRegistryModel{Icao: "ABC123", Registration: "LN-ABC", TypeCode: "B738"}
+	trail := []models.AircraftHistoryModel{
+		{Icao: "ABC123", Latitude: 51, Longitude: 0.1, Timestamp: "2024-01-01T10:04:00Z"},
+		{Icao: "ABC123", Latitude: aircraft.Latitude, Longitude: aircraft.Longitude, Timestamp: aircraft.Timestamp},
+	}
+
+	tests := []struct {
+		name, url string
+		trail     int
+		detail    models.AircraftCurrentDetail
+	}{
+		{
+			name:   "Default trail",
+			url:    endpoint + "ABC123",
+			trail:  global.DefaultTrailMinutes,
+			detail: models.AircraftCurrentDetail{Aircraft: aircraft, Trail: trail},
+		},
+		{
+			name:   "Trail of 30 minutes",
+			url:    endpoint + "ABC123?trail=30",
+			trail:  30,
+			detail: models.AircraftCurrentDetail{Aircraft: aircraft, Trail: trail},
+		},
+		{
+			name:   "Without trail",
+			url:    endpoint + "ABC123?trail=0",
+			trail:  0,
+			detail: models.AircraftCurrentDetail{Aircraft: aircraft},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detail := tt.detail
+			mockSvc.EXPECT().GetCurrentAircraftByIcao("ABC123", tt.trail).Return(&detail, nil)
+
+			res, err := http.Get(tt.url)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+
+			var actual geoJSON.FeatureAircraftDetail
+			err = json.NewDecoder(res.Body).Decode(&actual)
+			if err != nil {
+				t.Errorf("Test: %s. Error decoding response body: %s", tt.name, err.Error())
+			}
+
+			expected, err := convert.CurrentDetailToGeoJson(tt.detail)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+			assert.Equal(t, "Feature", actual.Type)
+			assert.Equal(t, "2024-01-01T09:30:00Z", actual.Properties.FirstSeen)
+			assert.Equal(t, aircraft.Timestamp, actual.Properties.LastSeen)
+			assert.Equal(t, 120, actual.Properties.Messages)
+			assert.Equal(t, "LN-ABC", actual.Properties.Registration)
+			assert.Len(t, actual.Properties.Trail, len(tt.detail.Trail))
+		})
+	}
+}
+
+func TestEncodedRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	handler := CurrentAircraftHandler(mockSvc)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftCurrentPath+"{$}", handler),
+		router.Get(global.AircraftCurrentPath+"{icao}", handler),
+	}))
+	defer currentEndpoint.Close()
+
+	mockData := testUtility.CreateMockAircraft(2)
+	expected, err := convert.CurrentModelToGeoJson(mockData)
+	if err != nil {
+		t.Fatalf("error converting mock data: %q", err)
+	}
+	expected.Token = encodeToken(testVersion)
+
+	tests := []struct {
+		name, accept, contentType string
+		decode                    func(body []byte) (geoJSON.FeatureCollectionPoint, error)
+	}{
+		{
+			name:        "Compact JSON",
+			accept:      "application/json;compact=true",
+			contentType: "application/json",
+			decode: func(body []byte) (geoJSON.FeatureCollectionPoint, error) {
+				assert.NotContains(t, string(body), "\t")
+				var actual geoJSON.FeatureCollectionPoint
+				return actual, json.Unmarshal(body, &actual)
+			},
+		},
+		{
+			name:        "MessagePack preferred over JSON",
+			accept:      "application/json;q=0.5, application/msgpack",
+			contentType: "application/msgpack",
+			decode: func(body []byte) (geoJSON.FeatureCollectionPoint, error) {
+				var actual geoJSON.FeatureCollectionPoint
+				decoder := msgpack.NewDecoder(bytes.NewReader(body))
+				decoder.SetCustomStructTag("json")
+				return actual, decoder.Decode(&actual)
+			},
+		},
+		{
+			name:        "Protocol Buffers",
+			accept:      "application/x-protobuf",
+			contentType: "application/x-protobuf",
+			decode: func(body []byte) (geoJSON.FeatureCollectionPoint, error) {
+				assert.Equal(t, protobuf.Marshal(expected), body)
+				return expected, nil
+			},
+		},
+		{
+			name:        "Unsupported media type falls back to GeoJSON",
+			accept:      "text/html",
+			contentType: "application/json",
+			decode: func(body []byte) (geoJSON.FeatureCollectionPoint, error) {
+				var actual geoJSON.FeatureCollectionPoint
+				return actual, json.Unmarshal(body, &actual)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc.EXPECT().GetCurrentAircraftVersion().Return(testVersion, nil)
+			mockSvc.EXPECT().GetCurrentAircraftWithVersion(models.AircraftCurrentFilter{}).Return(mockData, testVersion, nil)
+
+			req, err := http.NewRequest(http.MethodGet, currentEndpoint.URL+global.AircraftCurrentPath, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			req.Header.Set("Accept", tt.accept)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, tt.contentType, res.Header.Get("Content-Type"))
+			assert.Equal(t, "Accept", res.Header.Get("Vary"))
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+			actual, err := tt.decode(body)
+			if err != nil {
+				t.Fatalf("Test: %s. Error decoding response body: %s", tt.name, err.Error())
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	handler := CurrentAircraftHandler(mockSvc)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftCurrentPath+"{$}", handler),
+	}))
+	defer currentEndpoint.Close()
+
+	var endpoint = currentEndpoint.URL + global.AircraftCurrentPath
+
+	mockSvc.EXPECT().GetCurrentAircraftVersion().Return(testVersion, nil).Times(5)
+	mockSvc.EXPECT().GetCurrentAircraftWithVersion(models.AircraftCurrentFilter{}).Return(testUtility.CreateMockAircraft(3), testVersion, nil).Times(3)
+
+	res, err := http.Get(endpoint)
+	if err != nil {
+		t.Fatalf("Error executing request: %s", err.Error())
+	}
+	res.Body.Close()
+
+	etag := res.Header.Get("ETag")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Regexp(t, `^"42-[0-9a-f]+"$`, etag)
+	assert.Equal(t, "Mon, 01 Jan 2024 10:00:00 GMT", res.Header.Get("Last-Modified"))
+	assert.Equal(t, fmt.Sprintf("public, max-age=%d", global.UpdatingPeriod), res.Header.Get("Cache-Control"))
+
+	tests := []struct {
+		name, header, value string
+		accept              string
+		statusCode          int
+	}{
+		{name: "Same ETag", header: "If-None-Match", value: etag, statusCode: http.StatusNotModified},
+		{name: "Not modified since", header: "If-Modified-Since", value: "Mon, 01 Jan 2024 10:00:00 GMT", statusCode: http.StatusNotModified},
+		{name: "Older ETag", header: "If-None-Match", value: `"41-0"`, statusCode: http.StatusOK},
+		{
+			// another encoding is another response with another ETag
+			name:       "Same ETag with another Accept header",
+			header:     "If-None-Match",
+			value:      etag,
+			accept:     "application/msgpack",
+			statusCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			req.Header.Set(tt.header, tt.value)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			assert.NotEmpty(t, res.Header.Get("ETag"))
+			if tt.statusCode == http.StatusNotModified {
+				body, _ := io.ReadAll(res.Body)
+				assert.Empty(t, body)
+				assert.Equal(t, etag, res.Header.Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestConditionalRequests_SnapshotVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftCurrentPath+"{$}", CurrentAircraftHandler(mockSvc)),
+	}))
+	defer currentEndpoint.Close()
+
+	// the aircraft are read from a later version than the one the request was compared with
+	snapshotVersion := models.AircraftCurrentVersion{Seq: 43, Time: testVersion.Time.Add(time.Second)}
+	mockSvc.EXPECT().GetCurrentAircraftVersion().Return(testVersion, nil)
+	mockSvc.EXPECT().GetCurrentAircraftWithVersion(models.AircraftCurrentFilter{}).
+		Return(testUtility.CreateMockAircraft(3), snapshotVersion, nil)
+
+	res, err := http.Get(currentEndpoint.URL + global.AircraftCurrentPath)
+	if err != nil {
+		t.Fatalf("Error executing request: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	var actual geoJSON.FeatureCollectionPoint
+	err = json.NewDecoder(res.Body).Decode(&actual)
+	if err != nil {
+		t.Fatalf("Error decoding response body: %s", err.Error())
+	}
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Regexp(t, `^"43-[0-9a-f]+"$`, res.Header.Get("ETag"))
+	assert.Equal(t, "Mon, 01 Jan 2024 10:00:01 GMT", res.Header.Get("Last-Modified"))
+	assert.Equal(t, encodeToken(snapshotVersion), actual.Token)
+}
@@ -3,45 +3,54 @@ package aircraftHistoryHandler
 import (
 	"adsb-api/internal/global"
 	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/geoJSON"
 	"adsb-api/internal/global/models"
 	"adsb-api/internal/service/restService"
 	"adsb-api/internal/utility/apiUtility"
 	"adsb-api/internal/utility/convert"
-	"fmt"
+	"encoding/base64"
+	"errors"
+	"math"
 	"net/http"
-	"path"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
-var optionalParams = []string{"hour"}
+var optionalParams = []string{"hour", "tolerance", "from", "to", "limit", "cursor", "order", "format"}
 
-// HistoryAircraftHandler handles HTTP requests for /aircraft/history/{icao}?hour= endpoint.
+// rangeParams are the query parameters that select the history with an AircraftHistoryFilter
+var rangeParams = []string{"from", "to", "limit", "cursor", "order"}
+
+// HistoryAircraftHandler handles HTTP requests for
+// /aircraft/history/{icao}?hour=&tolerance=&from=&to=&limit=&cursor=&order=&format= endpoint.
 func HistoryAircraftHandler(svc restService.RestService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := apiUtility.ValidateURL(w, r, len(strings.Split(global.AircraftHistoryPath, "/")), optionalParams)
+		err := apiUtility.ValidateURL(w, r, optionalParams)
 		if err != nil {
 			return
 		}
-		switch r.Method {
-		case http.MethodGet:
-			handleHistoryAircraftGetRequest(w, r, svc)
-		default:
-			http.Error(w, fmt.Sprintf(errorMsg.MethodNotSupported, r.Method), http.StatusMethodNotAllowed)
-		}
+		handleHistoryAircraftGetRequest(w, r, svc)
 	}
 }
 
-// handleHistoryAircraftGetRequest handles GET requests for the aircraft/history/{icao}?hour= endpoint.
+// handleHistoryAircraftGetRequest handles GET requests for the
+// aircraft/history/{icao}?hour=&tolerance=&from=&to=&limit=&cursor=&order=&format= endpoint.
 // Sends history data for aircraft given by the icao query parameter.
+// The history is limited to the last hours of the aircraft by the hour parameter, or to an absolute time range by the
+// from and to parameters. The track is simplified if the tolerance parameter, in meters, is given.
+// With the limit parameter the history is paginated, and the next page is requested with the returned cursor. Without
+// limit, hour and tolerance, the history is streamed to the client as it is read from the database, unless the
+// Accept header asks for MessagePack or Protocol Buffers.
+// The track is sent as GeoJSON by default, or as a KML or GPX file download with format=kml or format=gpx. With
+// format=czml the positions, including altitude, are sent as a CZML document for playback in Cesium.
+// The history of a time range that has ended no longer changes, and is cached for global.HistoryCacheMaxAge seconds.
 func handleHistoryAircraftGetRequest(w http.ResponseWriter, r *http.Request, svc restService.RestService) {
-	search := path.Base(r.URL.Path)
-	if search == "history" {
-		http.Error(w, errorMsg.EmptyIcao, http.StatusBadRequest)
-		return
-	} else if len(search) > 6 {
+	search := r.PathValue("icao")
+	if len(search) > 6 {
 		http.Error(w, errorMsg.TooLongIcao, http.StatusBadRequest)
 		return
 	}
@@ -49,16 +58,75 @@ func handleHistoryAircraftGetRequest(w http.ResponseWriter, r *http.Request, svc
 	var err error
 	var res []models.AircraftHistoryModel
 
-	if r.URL.Query().Has("hour") {
-		hour, err := strconv.Atoi(r.URL.Query().Get("hour"))
-		if err != nil {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "geojson"
+	} else if format != "geojson" && format != "kml" && format != "gpx" && format != "czml" {
+		http.Error(w, errorMsg.InvalidQueryParameterHistFormat, http.StatusBadRequest)
+		return
+	}
+	if format != "geojson" && r.URL.Query().Has("limit") {
+		http.Error(w, errorMsg.InvalidQueryParameterHistLimit, http.StatusBadRequest)
+		return
+	}
+
+	var tolerance float64
+	if r.URL.Query().Has("tolerance") {
+		tolerance, err = strconv.ParseFloat(r.URL.Query().Get("tolerance"), 64)
+		if err != nil || tolerance < 0 || math.IsNaN(tolerance) || math.IsInf(tolerance, 0) {
+			http.Error(w, errorMsg.InvalidQueryParameterTolerance, http.StatusBadRequest)
+			return
+		}
+	}
+
+	hasRangeParams := false
+	for _, param := range rangeParams {
+		hasRangeParams = hasRangeParams || r.URL.Query().Has(param)
+	}
+
+	var filter models.AircraftHistoryFilter
+	var cache apiUtility.Cache
+	var cached bool
+	if r.URL.Query().Has("hour") && hasRangeParams {
+		http.Error(w, errorMsg.InvalidQueryParameterHourRange, http.StatusBadRequest)
+		return
+	} else if r.URL.Query().Has("hour") {
+		hour, hourErr := strconv.Atoi(r.URL.Query().Get("hour"))
+		if hourErr != nil {
 			http.Error(w, errorMsg.InvalidQueryParameterHour, http.StatusBadRequest)
-			log.Error().Msgf(errorMsg.InvalidQueryParameterHour+" Error : %q", err)
+			log.Error().Msgf(errorMsg.InvalidQueryParameterHour+" Error : %q", hourErr)
 			return
 		}
 		res, err = svc.GetAircraftHistoryByIcaoFilterByTimestamp(search, hour)
 	} else {
-		res, err = svc.GetAircraftHistoryByIcao(search)
+		var filterErr error
+		filter, filterErr = parseFilter(r.URL.Query())
+		if filterErr != nil {
+			http.Error(w, filterErr.Error(), http.StatusBadRequest)
+			return
+		}
+		cache, cached = historyCache(filter)
+
+		// only GeoJSON can be streamed, other encodings of the Accept header are sent once the history is read
+		streamable := apiUtility.Negotiate(r, geoJSON.FeatureCollectionLineString{}).ContentType() == "application/json"
+		if filter.Limit == 0 && tolerance == 0 && format == "geojson" && streamable {
+			if cached {
+				cache.SetHeaders(w)
+			}
+			streamHistory(w, r, svc, search, filter)
+			return
+		}
+
+		if filter.Limit > 0 {
+			// one extra row tells if there is a next page
+			pageFilter := filter
+			pageFilter.Limit++
+			res, err = svc.GetAircraftHistoryByIcaoTimeRange(search, pageFilter)
+		} else if hasRangeParams {
+			res, err = svc.GetAircraftHistoryByIcaoTimeRange(search, filter)
+		} else {
+			res, err = svc.GetAircraftHistoryByIcao(search)
+		}
 	}
 
 	if err != nil {
@@ -67,21 +135,248 @@ func handleHistoryAircraftGetRequest(w http.ResponseWriter, r *http.Request, svc
 		return
 	}
 
-	if len(res) == 0 || len(res) < 2 {
+	var next string
+	if filter.Limit > 0 && len(res) > filter.Limit {
+		res = res[:filter.Limit]
+		next, err = encodeCursor(res[len(res)-1].Timestamp)
+		if err != nil {
+			http.Error(w, errorMsg.ErrorRetrievingAircraftWithIcao+search, http.StatusInternalServerError)
+			log.Error().Msgf(errorMsg.ErrorEncodingCursor+": %q", err)
+			return
+		}
+	}
+
+	// a page is only a part of the track, so the last page may hold a single point
+	if len(res) == 0 || (filter.Limit == 0 && len(res) < 2) {
 		apiUtility.NoContent(w)
 		return
 	}
 
-	aircraft, err := convert.HistoryModelToGeoJson(res)
+	if cached {
+		cache.SetHeaders(w)
+	}
+	if format != "geojson" {
+		sendTrackFile(w, svc, search, res, tolerance, format)
+		return
+	}
+
+	var aircraft geoJSON.FeatureCollectionLineString
+	if filter.Limit > 0 {
+		aircraft, err = convert.HistoryPageToGeoJson(res, tolerance)
+	} else {
+		aircraft, err = convert.HistoryModelToGeoJson(res, tolerance)
+	}
 	if err != nil {
 		http.Error(w, errorMsg.ErrorConvertingDataToGeoJson, http.StatusInternalServerError)
 		log.Error().Msgf(errorMsg.ErrorConvertingDataToGeoJson+" Error: %q", err)
 		return
 	}
+	aircraft.Next = next
+	aircraft.Features[0].Properties.RegistryProperties = registryProperties(svc, search)
 
-	err = apiUtility.EncodeJsonData(w, aircraft)
+	err = apiUtility.EncodeData(w, r, aircraft)
 	if err != nil {
 		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
 		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
 	}
 }
+
+// streamHistory streams the history of the aircraft within the time range of filter straight from the database to the
+// client, without holding the history in memory. Errors after the first points are written can only be logged.
+func streamHistory(w http.ResponseWriter, r *http.Request, svc restService.RestService, search string,
+	filter models.AircraftHistoryFilter) {
+	stream := convert.NewHistoryGeoJsonStream(apiUtility.NewJsonStreamWriter(w))
+
+	err := svc.StreamAircraftHistoryByIcaoTimeRange(search, filter, stream.Write)
+	if err != nil && !stream.Started() {
+		// the error must not be cached as the history of a completed range
+		w.Header().Del("Cache-Control")
+		http.Error(w, errorMsg.ErrorRetrievingAircraftWithIcao+search, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorRetrievingAircraftWithIcao+": %s Error : %q URL: %q", search, err, r.URL)
+		return
+	} else if err != nil {
+		log.Error().Msgf(errorMsg.ErrorStreamingHistory+": %s Error : %q URL: %q", search, err, r.URL)
+		return
+	}
+
+	if !stream.Started() {
+		apiUtility.NoContent(w)
+		return
+	}
+
+	err = stream.Close(registryProperties(svc, search))
+	if err != nil {
+		log.Error().Msgf(errorMsg.ErrorStreamingHistory+": %s Error : %q URL: %q", search, err, r.URL)
+	}
+}
+
+// sendTrackFile sends the history of the aircraft as a KML, GPX or CZML file, given by format, named after the icao.
+// The registration, model and operator of the aircraft are added as the description of the track.
+func sendTrackFile(w http.ResponseWriter, svc restService.RestService, search string,
+	res []models.AircraftHistoryModel, tolerance float64, format string) {
+	description := trackDescription(registryProperties(svc, search))
+
+	var data interface{}
+	var contentType string
+	var err error
+	switch format {
+	case "kml":
+		data, err = convert.HistoryModelToKml(res, tolerance, description)
+		contentType = "application/vnd.google-earth.kml+xml"
+	case "gpx":
+		data, err = convert.HistoryModelToGpx(res, tolerance, description)
+		contentType = "application/gpx+xml"
+	case "czml":
+		data, err = convert.HistoryModelToCzml(res, tolerance, description)
+	}
+	if err != nil {
+		http.Error(w, errorMsg.ErrorConvertingDataToGeoJson, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorConvertingDataToGeoJson+" Error: %q", err)
+		return
+	}
+
+	filename := strings.ToUpper(search) + "." + format
+	if format == "czml" {
+		err = apiUtility.EncodeJsonFile(w, data, filename)
+		if err != nil {
+			http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
+			log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
+		}
+		return
+	}
+
+	err = apiUtility.EncodeXmlData(w, data, contentType, filename)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorEncodingXmlData, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorEncodingXmlData+": %q", err)
+	}
+}
+
+// trackDescription describes the aircraft of a track by its registration, manufacturer and model, and operator, leaving
+// out what is not registered.
+func trackDescription(registry geoJSON.RegistryProperties) string {
+	var parts []string
+	for _, part := range []string{registry.Registration,
+		strings.TrimSpace(registry.Manufacturer + " " + registry.Model), registry.Operator} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// registryProperties returns the registry properties of the aircraft. The history is still sent if the registry data
+// can not be retrieved, so an error is only logged.
+func registryProperties(svc restService.RestService, search string) geoJSON.RegistryProperties {
+	registry, err := svc.GetAircraftRegistryByIcao(search)
+	if err != nil {
+		log.Warn().Msgf(errorMsg.ErrorRetrievingRegistry+": %s Error : %q", search, err)
+		return geoJSON.RegistryProperties{}
+	} else if registry == nil {
+		return geoJSON.RegistryProperties{}
+	}
+	return convert.RegistryModelToProperties(*registry)
+}
+
+// encodeCursor encodes the timestamp of the last row of a page as an opaque cursor for the next page.
+func encodeCursor(timestamp string) (string, error) {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(t.UTC().Format(time.RFC3339Nano))), nil
+}
+
+// decodeCursor decodes a cursor made by encodeCursor.
+func decodeCursor(cursor string) (time.Time, error) {
+	timestamp, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, string(timestamp))
+}
+
+// rangeCompleted reports whether the time range of filter ended before the aircraft of the last ingestion cycle were
+// added to the history, so that no more history is added to it.
+func rangeCompleted(filter models.AircraftHistoryFilter) bool {
+	ingested := time.Now().Add(-time.Duration(global.WaitingTime+global.UpdatingPeriod) * time.Second)
+	return !filter.To.IsZero() && filter.To.Before(ingested)
+}
+
+// historyCache returns the caching of the history within the time range of filter, and whether it can be cached. Only
+// a completed range with a start can be cached, and only until the cleanup job may roll up or delete its oldest
+// positions, which changes its history.
+func historyCache(filter models.AircraftHistoryFilter) (apiUtility.Cache, bool) {
+	if !rangeCompleted(filter) || filter.From.IsZero() {
+		return apiUtility.Cache{}, false
+	}
+
+	maxAge := global.HistoryCacheMaxAge
+	if changed := historyChanged(filter.From); !changed.IsZero() {
+		maxAge = min(maxAge, int(time.Until(changed).Seconds()))
+	}
+	if maxAge <= 0 {
+		return apiUtility.Cache{}, false
+	}
+	return apiUtility.Cache{MaxAge: maxAge}, true
+}
+
+// historyChanged returns when the cleanup job may next change the history at timestamp: when it is older than
+// MAX_DAYS_HISTORY days and is rolled up or deleted, or when its rollup is older than MAX_DAYS_ROLLUP days and is
+// deleted. Returns the zero time if it is kept forever in the rollup.
+func historyChanged(timestamp time.Time) time.Time {
+	day := 24 * time.Hour
+	changed := timestamp.Add(time.Duration(global.MaxDaysHistory) * day)
+	if changed.After(time.Now()) || global.RollupResolution <= 0 {
+		return changed
+	}
+	if global.MaxDaysRollup == 0 {
+		return time.Time{}
+	}
+	return timestamp.Add(time.Duration(global.MaxDaysRollup) * day)
+}
+
+// parseFilter parses the from, to, limit, cursor and order query parameters to an AircraftHistoryFilter.
+// Returns an error with the message for the first invalid parameter.
+func parseFilter(query url.Values) (models.AircraftHistoryFilter, error) {
+	var filter models.AircraftHistoryFilter
+	var err error
+
+	if query.Has("from") {
+		filter.From, err = time.Parse(time.RFC3339, query.Get("from"))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterFrom)
+		}
+	}
+
+	if query.Has("to") {
+		filter.To, err = time.Parse(time.RFC3339, query.Get("to"))
+		if err != nil || !filter.To.After(filter.From) {
+			return filter, errors.New(errorMsg.InvalidQueryParameterTo)
+		}
+	}
+
+	if query.Has("limit") {
+		filter.Limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || filter.Limit < 1 {
+			return filter, errors.New(errorMsg.InvalidQueryParameterLimit)
+		}
+	}
+
+	if query.Has("cursor") {
+		filter.Cursor, err = decodeCursor(query.Get("cursor"))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterCursor)
+		}
+	}
+
+	switch strings.ToLower(query.Get("order")) {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		return filter, errors.New(errorMsg.InvalidQueryParameterOrder)
+	}
+
+	return filter, nil
+}
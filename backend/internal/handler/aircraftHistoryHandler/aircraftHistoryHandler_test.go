@@ -2,13 +2,17 @@ package aircraftHistoryHandler
 
 import (
 	"adsb-api/internal/global"
+	"adsb-api/internal/global/czml"
 	"adsb-api/internal/global/errorMsg"
 	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/gpx"
 	"adsb-api/internal/global/models"
+	"adsb-api/internal/handler/router"
 	"adsb-api/internal/utility/convert"
 	"adsb-api/internal/utility/mock"
 	"adsb-api/internal/utility/testUtility"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -16,6 +20,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -26,12 +31,28 @@ func TestMain(m *testing.M) {
 	m.Run()
 }
 
+// streamMockData returns a function streaming mockData to the handle of StreamAircraftHistoryByIcaoTimeRange, and then
+// returning err.
+func streamMockData(mockData []models.AircraftHistoryModel, err error) func(string, models.AircraftHistoryFilter,
+	func(models.AircraftHistoryModel) error) error {
+	return func(search string, filter models.AircraftHistoryFilter, handle func(models.AircraftHistoryModel) error) error {
+		for _, ac := range mockData {
+			if handleErr := handle(ac); handleErr != nil {
+				return handleErr
+			}
+		}
+		return err
+	}
+}
+
 func TestInvalidRequests(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockSvc := mock.NewMockRestService(ctrl)
-	currentEndpoint := httptest.NewServer(HistoryAircraftHandler(mockSvc))
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftHistoryPath+"{icao}", HistoryAircraftHandler(mockSvc)),
+	}))
 	defer currentEndpoint.Close()
 
 	var endpoint = currentEndpoint.URL + global.AircraftHistoryPath
@@ -46,14 +67,14 @@ func TestInvalidRequests(t *testing.T) {
 			httpMethod: http.MethodPost,
 			url:        endpoint + "ABC123",
 			statusCode: http.StatusMethodNotAllowed,
-			errorMsg:   fmt.Sprintf(errorMsg.MethodNotSupported, http.MethodPost),
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
 		},
 		{
 			name:       "Delete request",
 			url:        endpoint + "ABC123",
 			httpMethod: http.MethodDelete,
 			statusCode: http.StatusMethodNotAllowed,
-			errorMsg:   fmt.Sprintf(errorMsg.MethodNotSupported, http.MethodDelete),
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
 		},
 		{
 			name:       "Database returns nil",
@@ -61,23 +82,24 @@ func TestInvalidRequests(t *testing.T) {
 			httpMethod: http.MethodGet,
 			statusCode: http.StatusInternalServerError,
 			setup: func(mockSvc *mock.MockRestService) {
-				mockSvc.EXPECT().GetAircraftHistoryByIcao("ABC123").Return([]models.AircraftHistoryModel{}, errors.New("expected error"))
+				mockSvc.EXPECT().StreamAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{}, gomock.Any()).
+					DoAndReturn(streamMockData([]models.AircraftHistoryModel{}, errors.New("expected error")))
 			},
 			errorMsg: errorMsg.ErrorRetrievingAircraftWithIcao + "ABC123",
 		},
 		{
-			name:       "Get request with too long URL",
+			name:       "Get request with unknown path",
 			url:        endpoint + "endpoint/endpoint/",
 			httpMethod: http.MethodGet,
-			statusCode: http.StatusRequestURITooLong,
-			errorMsg:   errorMsg.ErrorTongURL,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
 		},
 		{
 			name:       "Get request with empty icao",
 			url:        endpoint + "/",
 			httpMethod: http.MethodGet,
-			statusCode: http.StatusBadRequest,
-			errorMsg:   errorMsg.EmptyIcao,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
 		},
 		{
 			name:       "Get request with invalid parameter",
@@ -97,8 +119,8 @@ func TestInvalidRequests(t *testing.T) {
 			name:       "Get request without icao or parameter",
 			url:        endpoint,
 			httpMethod: http.MethodGet,
-			statusCode: http.StatusBadRequest,
-			errorMsg:   errorMsg.EmptyIcao,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
 		},
 		{
 			name:       "Invalid query parameter 'hour'",
@@ -106,6 +128,60 @@ func TestInvalidRequests(t *testing.T) {
 			statusCode: http.StatusBadRequest,
 			errorMsg:   errorMsg.InvalidQueryParameterHour,
 		},
+		{
+			name:       "Invalid query parameter 'tolerance'",
+			url:        endpoint + "ABC123?tolerance=ABC",
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterTolerance,
+		},
+		{
+			name:       "Negative query parameter 'tolerance'",
+			url:        endpoint + "ABC123?tolerance=-1",
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterTolerance,
+		},
+		{
+			name:       "Invalid query parameter 'from'",
+			url:        endpoint + "ABC123?from=2024-01-01",
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterFrom,
+		},
+		{
+			name:       "Query parameter 'to' before 'from'",
+			url:        endpoint + "ABC123?from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z",
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterTo,
+		},
+		{
+			name:       "Invalid query parameter 'limit'",
+			url:        endpoint + "ABC123?limit=0",
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterLimit,
+		},
+		{
+			name:       "Invalid query parameter 'order'",
+			url:        endpoint + "ABC123?order=random",
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterOrder,
+		},
+		{
+			name:       "Query parameter 'hour' combined with 'from'",
+			url:        endpoint + "ABC123?hour=1&from=2024-01-01T00:00:00Z",
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterHourRange,
+		},
+		{
+			name:       "Invalid query parameter 'format'",
+			url:        endpoint + "ABC123?format=csv",
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterHistFormat,
+		},
+		{
+			name:       "Query parameter 'limit' combined with format kml",
+			url:        endpoint + "ABC123?format=kml&limit=10",
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterHistLimit,
+		},
 		{
 			name:       "Too long ICAO",
 			url:        endpoint + "ABC1234",
@@ -147,7 +223,9 @@ func TestValidRequests(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSvc := mock.NewMockRestService(ctrl)
-	currentEndpoint := httptest.NewServer(HistoryAircraftHandler(mockSvc))
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftHistoryPath+"{icao}", HistoryAircraftHandler(mockSvc)),
+	}))
 	defer currentEndpoint.Close()
 
 	var endpoint = currentEndpoint.URL + global.AircraftHistoryPath
@@ -165,17 +243,21 @@ func TestValidRequests(t *testing.T) {
 			statusCode: http.StatusOK,
 			mockData:   testUtility.CreateMockHistAircraft(10),
 			setup: func(mockSvc *mock.MockRestService, mockData []models.AircraftHistoryModel) {
-				mockSvc.EXPECT().GetAircraftHistoryByIcao("ABC123").Return(mockData, nil)
+				mockSvc.EXPECT().StreamAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{}, gomock.Any()).
+					DoAndReturn(streamMockData(mockData, nil))
+				mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil)
 			},
 		},
 		{
 			name:       "Get request with valid URl but unnecessary slashes",
-			url:        currentEndpoint.URL + "/../" + global.AircraftHistoryPath + "//../././/ABC123",
+			url:        currentEndpoint.URL + "/../" + global.AircraftHistoryPath + "//././/ABC123",
 			httpMethod: http.MethodGet,
 			statusCode: http.StatusOK,
 			mockData:   testUtility.CreateMockHistAircraft(10),
 			setup: func(mockSvc *mock.MockRestService, mockData []models.AircraftHistoryModel) {
-				mockSvc.EXPECT().GetAircraftHistoryByIcao("ABC123").Return(mockData, nil)
+				mockSvc.EXPECT().StreamAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{}, gomock.Any()).
+					DoAndReturn(streamMockData(mockData, nil))
+				mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil)
 			},
 		},
 		{
@@ -184,7 +266,8 @@ func TestValidRequests(t *testing.T) {
 			httpMethod: http.MethodGet,
 			statusCode: http.StatusNoContent,
 			setup: func(mockSvc *mock.MockRestService, mockData []models.AircraftHistoryModel) {
-				mockSvc.EXPECT().GetAircraftHistoryByIcao("ABC123").Return([]models.AircraftHistoryModel{}, nil)
+				mockSvc.EXPECT().StreamAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{}, gomock.Any()).
+					DoAndReturn(streamMockData([]models.AircraftHistoryModel{}, nil))
 			},
 		},
 		{
@@ -194,7 +277,8 @@ func TestValidRequests(t *testing.T) {
 			statusCode: http.StatusNoContent,
 			mockData:   testUtility.CreateMockHistAircraft(1),
 			setup: func(mockDB *mock.MockRestService, mockData []models.AircraftHistoryModel) {
-				mockSvc.EXPECT().GetAircraftHistoryByIcao("ABC123").Return(mockData, nil)
+				mockSvc.EXPECT().StreamAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{}, gomock.Any()).
+					DoAndReturn(streamMockData(mockData, nil))
 			},
 		},
 		{
@@ -204,6 +288,52 @@ func TestValidRequests(t *testing.T) {
 			mockData:   testUtility.CreateMockHistAircraft(10),
 			setup: func(mockDB *mock.MockRestService, mockData []models.AircraftHistoryModel) {
 				mockSvc.EXPECT().GetAircraftHistoryByIcaoFilterByTimestamp("ABC123", 2).Return(mockData, nil)
+				mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil)
+			},
+		},
+		{
+			name:       "Get request with valid query parameters 'hour' and 'tolerance'",
+			url:        endpoint + "ABC123?hour=2&tolerance=100",
+			statusCode: http.StatusOK,
+			setup: func(mockDB *mock.MockRestService, mockData []models.AircraftHistoryModel) {
+				mockSvc.EXPECT().GetAircraftHistoryByIcaoFilterByTimestamp("ABC123", 2).
+					Return(testUtility.CreateMockHistAircraft(10), nil)
+				mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil)
+			},
+		},
+		{
+			name:       "Get request with valid query parameters 'from', 'to', 'limit' and 'order'",
+			url:        endpoint + "ABC123?from=2024-01-01T10:00:00%2B02:00&to=2024-01-01T12:00:00Z&limit=100&order=asc",
+			statusCode: http.StatusOK,
+			mockData:   testUtility.CreateMockHistAircraft(10),
+			setup: func(mockDB *mock.MockRestService, mockData []models.AircraftHistoryModel) {
+				filter := models.AircraftHistoryFilter{
+					From:      time.Date(2024, 1, 1, 10, 0, 0, 0, time.FixedZone("", 2*60*60)),
+					To:        time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+					Limit:     101, // one extra row tells if there is a next page
+					Ascending: true,
+				}
+				mockSvc.EXPECT().GetAircraftHistoryByIcaoTimeRange("ABC123", gomock.Any()).
+					DoAndReturn(func(search string, actual models.AircraftHistoryFilter) ([]models.AircraftHistoryModel, error) {
+						assert.True(t, filter.From.Equal(actual.From))
+						assert.True(t, filter.To.Equal(actual.To))
+						assert.Equal(t, filter.Limit, actual.Limit)
+						assert.Equal(t, filter.Ascending, actual.Ascending)
+						return mockData, nil
+					})
+				mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil)
+			},
+		},
+		{
+			name:       "Get request with only query parameter 'from'",
+			url:        endpoint + "ABC123?from=2024-01-01T10:00:00Z",
+			statusCode: http.StatusOK,
+			mockData:   testUtility.CreateMockHistAircraft(10),
+			setup: func(mockDB *mock.MockRestService, mockData []models.AircraftHistoryModel) {
+				filter := models.AircraftHistoryFilter{From: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+				mockSvc.EXPECT().StreamAircraftHistoryByIcaoTimeRange("ABC123", filter, gomock.Any()).
+					DoAndReturn(streamMockData(mockData, nil))
+				mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil)
 			},
 		},
 		{
@@ -238,7 +368,7 @@ func TestValidRequests(t *testing.T) {
 				var actual geoJSON.FeatureCollectionLineString
 				_ = json.NewDecoder(res.Body).Decode(&actual)
 
-				mockFeatureCollection, err := convert.HistoryModelToGeoJson(tt.mockData)
+				mockFeatureCollection, err := convert.HistoryModelToGeoJson(tt.mockData, 0)
 				if err != nil {
 					t.Fatalf("error converting from history model to geo json")
 				}
@@ -248,3 +378,433 @@ func TestValidRequests(t *testing.T) {
 		})
 	}
 }
+
+func TestValidRequests_WithRegistry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftHistoryPath+"{icao}", HistoryAircraftHandler(mockSvc)),
+	}))
+	defer currentEndpoint.Close()
+
+	mockData := testUtility.CreateMockHistAircraft(10)
+	registry := models.AircraftRegistryModel{Icao: "ABC123", Registration: "LN-ABC", TypeCode: "B738", Year: 2010}
+
+	mockSvc.EXPECT().StreamAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{}, gomock.Any()).
+		DoAndReturn(streamMockData(mockData, nil))
+	mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(&registry, nil)
+
+	res, err := http.Get(currentEndpoint.URL + global.AircraftHistoryPath + "ABC123")
+	if err != nil {
+		t.Fatalf("error executing request: %q", err)
+	}
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var actual geoJSON.FeatureCollectionLineString
+	err = json.NewDecoder(res.Body).Decode(&actual)
+	if err != nil {
+		t.Fatalf("error decoding response body: %q", err)
+	}
+
+	assert.Equal(t, convert.RegistryModelToProperties(registry), actual.Features[0].Properties.RegistryProperties)
+}
+
+func TestValidRequests_ErrorRetrievingRegistry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftHistoryPath+"{icao}", HistoryAircraftHandler(mockSvc)),
+	}))
+	defer currentEndpoint.Close()
+
+	mockSvc.EXPECT().StreamAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{}, gomock.Any()).
+		DoAndReturn(streamMockData(testUtility.CreateMockHistAircraft(10), nil))
+	mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, errors.New("expected error"))
+
+	res, err := http.Get(currentEndpoint.URL + global.AircraftHistoryPath + "ABC123")
+	if err != nil {
+		t.Fatalf("error executing request: %q", err)
+	}
+
+	// the history is sent without registry data
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestValidRequests_TrackFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftHistoryPath+"{icao}", HistoryAircraftHandler(mockSvc)),
+	}))
+	defer currentEndpoint.Close()
+
+	altitude := 35000
+	mockData := testUtility.CreateMockHistAircraftWithIcao(3, "ABC123")
+	for i := range mockData {
+		mockData[i].Altitude = &altitude
+	}
+	registry := models.AircraftRegistryModel{Icao: "ABC123", Registration: "LN-ABC", Manufacturer: "Boeing",
+		Model: "737-800", Operator: "Norwegian"}
+
+	t.Run("KML", func(t *testing.T) {
+		mockSvc.EXPECT().GetAircraftHistoryByIcao("abc123").Return(mockData, nil)
+		mockSvc.EXPECT().GetAircraftRegistryByIcao("abc123").Return(&registry, nil)
+
+		res, err := http.Get(currentEndpoint.URL + global.AircraftHistoryPath + "abc123?format=kml")
+		if err != nil {
+			t.Fatalf("error executing request: %q", err)
+		}
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/vnd.google-earth.kml+xml", res.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=ABC123.kml`, res.Header.Get("Content-Disposition"))
+
+		// the gx prefix is written as is, so the document is checked as text
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("error reading response body: %q", err)
+		}
+		assert.True(t, strings.HasPrefix(string(body), xml.Header))
+		assert.Contains(t, string(body), "<description>LN-ABC, Boeing 737-800, Norwegian</description>")
+		assert.Contains(t, string(body), "<altitudeMode>absolute</altitudeMode>")
+		assert.Contains(t, string(body), "<gx:coord>2 2 10668.0</gx:coord>")
+		assert.Equal(t, 3, strings.Count(string(body), "<gx:coord>"))
+	})
+
+	t.Run("GPX with tolerance", func(t *testing.T) {
+		mockSvc.EXPECT().GetAircraftHistoryByIcao("ABC123").Return(mockData, nil)
+		mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil)
+
+		res, err := http.Get(currentEndpoint.URL + global.AircraftHistoryPath + "ABC123?format=GPX&tolerance=1000000")
+		if err != nil {
+			t.Fatalf("error executing request: %q", err)
+		}
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/gpx+xml", res.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=ABC123.gpx`, res.Header.Get("Content-Disposition"))
+
+		var actual gpx.Gpx
+		err = xml.NewDecoder(res.Body).Decode(&actual)
+		if err != nil {
+			t.Fatalf("error decoding response body: %q", err)
+		}
+		assert.Equal(t, "", actual.Tracks[0].Desc)
+		assert.Len(t, actual.Tracks[0].Segments[0].Points, 2)
+	})
+
+	t.Run("CZML", func(t *testing.T) {
+		mockSvc.EXPECT().GetAircraftHistoryByIcao("ABC123").Return(mockData, nil)
+		mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(&registry, nil)
+
+		res, err := http.Get(currentEndpoint.URL + global.AircraftHistoryPath + "ABC123?format=czml")
+		if err != nil {
+			t.Fatalf("error executing request: %q", err)
+		}
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=ABC123.czml`, res.Header.Get("Content-Disposition"))
+
+		var actual []czml.Packet
+		err = json.NewDecoder(res.Body).Decode(&actual)
+		if err != nil {
+			t.Fatalf("error decoding response body: %q", err)
+		}
+		assert.Len(t, actual, 2)
+		assert.Equal(t, "document", actual[0].Id)
+		assert.Equal(t, "LN-ABC, Boeing 737-800, Norwegian", actual[1].Description)
+		assert.Equal(t, []float64{0, 0, 0, 10668, 1, 1, 1, 10668, 2, 2, 2, 10668}, actual[1].Position.CartographicDegrees)
+	})
+}
+
+func TestValidRequests_Pagination(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftHistoryPath+"{icao}", HistoryAircraftHandler(mockSvc)),
+	}))
+	defer currentEndpoint.Close()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var mockData []models.AircraftHistoryModel
+	for i := 0; i < 4; i++ {
+		mockData = append(mockData, models.AircraftHistoryModel{
+			Icao:      "ABC123",
+			Latitude:  float32(i),
+			Longitude: float32(i),
+			Timestamp: start.Add(-time.Duration(i) * time.Minute).Format(time.RFC3339),
+		})
+	}
+
+	mockSvc.EXPECT().GetAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{Limit: 4}).
+		Return(mockData, nil)
+	mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil).Times(3)
+
+	res, err := http.Get(currentEndpoint.URL + global.AircraftHistoryPath + "ABC123?limit=3")
+	if err != nil {
+		t.Fatalf("error executing request: %q", err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var page geoJSON.FeatureCollectionLineString
+	err = json.NewDecoder(res.Body).Decode(&page)
+	if err != nil {
+		t.Fatalf("error decoding response body: %q", err)
+	}
+	assert.Equal(t, 3, len(page.Features[0].Geometry.Coordinates))
+	assert.NotEmpty(t, page.Next)
+
+	// the next page starts after the last row of the first page
+	mockSvc.EXPECT().GetAircraftHistoryByIcaoTimeRange("ABC123",
+		models.AircraftHistoryFilter{Limit: 4, Cursor: start.Add(-2 * time.Minute)}).Return(mockData[3:], nil)
+
+	res, err = http.Get(currentEndpoint.URL + global.AircraftHistoryPath + "ABC123?limit=3&cursor=" + page.Next)
+	if err != nil {
+		t.Fatalf("error executing request: %q", err)
+	}
+
+	// the last page is sent even if only one point is left, without a next cursor
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	page = geoJSON.FeatureCollectionLineString{}
+	err = json.NewDecoder(res.Body).Decode(&page)
+	if err != nil {
+		t.Fatalf("error decoding response body: %q", err)
+	}
+	assert.Equal(t, [][]float32{{3, 3}}, page.Features[0].Geometry.Coordinates)
+	assert.Empty(t, page.Next)
+
+	mockSvc.EXPECT().GetAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{Limit: 4}).
+		Return(mockData[:3], nil)
+
+	res, err = http.Get(currentEndpoint.URL + global.AircraftHistoryPath + "ABC123?limit=3")
+	if err != nil {
+		t.Fatalf("error executing request: %q", err)
+	}
+
+	page = geoJSON.FeatureCollectionLineString{}
+	err = json.NewDecoder(res.Body).Decode(&page)
+	if err != nil {
+		t.Fatalf("error decoding response body: %q", err)
+	}
+
+	// the last page has no next cursor
+	assert.Equal(t, 3, len(page.Features[0].Geometry.Coordinates))
+	assert.Empty(t, page.Next)
+}
+
+func TestValidRequests_PaginationSinglePoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftHistoryPath+"{icao}", HistoryAircraftHandler(mockSvc)),
+	}))
+	defer currentEndpoint.Close()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	mockData := []models.AircraftHistoryModel{
+		{Icao: "ABC123", Latitude: 1, Longitude: 2, Timestamp: start.Format(time.RFC3339)},
+		{Icao: "ABC123", Latitude: 3, Longitude: 4, Timestamp: start.Add(-time.Minute).Format(time.RFC3339)},
+	}
+
+	mockSvc.EXPECT().GetAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{Limit: 2}).
+		Return(mockData, nil)
+	mockSvc.EXPECT().GetAircraftHistoryByIcaoTimeRange("ABC123", models.AircraftHistoryFilter{Limit: 2, Cursor: start}).
+		Return(mockData[1:], nil)
+	mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil).Times(2)
+
+	// every page of limit=1 holds one point, until the last one
+	var coordinates [][]float32
+	query := "ABC123?limit=1"
+	for i := 0; i < len(mockData); i++ {
+		res, err := http.Get(currentEndpoint.URL + global.AircraftHistoryPath + query)
+		if err != nil {
+			t.Fatalf("error executing request: %q", err)
+		}
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var page geoJSON.FeatureCollectionLineString
+		err = json.NewDecoder(res.Body).Decode(&page)
+		if err != nil {
+			t.Fatalf("error decoding response body: %q", err)
+		}
+		assert.Equal(t, 1, len(page.Features[0].Geometry.Coordinates))
+		coordinates = append(coordinates, page.Features[0].Geometry.Coordinates...)
+		query = "ABC123?limit=1&cursor=" + page.Next
+		if i == len(mockData)-1 {
+			assert.Empty(t, page.Next)
+		} else {
+			assert.NotEmpty(t, page.Next)
+		}
+	}
+	assert.Equal(t, [][]float32{{2, 1}, {4, 3}}, coordinates)
+}
+
+func TestValidRequests_Caching(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftHistoryPath+"{icao}", HistoryAircraftHandler(mockSvc)),
+	}))
+	defer currentEndpoint.Close()
+
+	// the history is kept long enough for the ranges of yesterday to be cached for a whole day
+	global.MaxDaysHistory = 30
+	defer func() { global.MaxDaysHistory = 1 }()
+
+	from := time.Now().UTC().Truncate(time.Hour).Add(-24 * time.Hour)
+	old := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	future := time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockData := testUtility.CreateMockHistAircraft(10)
+
+	// rangeQuery returns the from and to query parameters of a range, to is left out if it is zero
+	rangeQuery := func(from, to time.Time) string {
+		query := "?from=" + from.Format(time.RFC3339)
+		if !to.IsZero() {
+			query += "&to=" + to.Format(time.RFC3339)
+		}
+		return query
+	}
+
+	tests := []struct {
+		name, query, cacheControl string
+		filter                    models.AircraftHistoryFilter
+		stream                    bool
+	}{
+		{
+			name:         "Completed range",
+			query:        rangeQuery(from, from.Add(time.Hour)),
+			cacheControl: fmt.Sprintf("public, max-age=%d", global.HistoryCacheMaxAge),
+			filter:       models.AircraftHistoryFilter{From: from, To: from.Add(time.Hour)},
+			stream:       true,
+		},
+		{
+			name:         "Completed range page",
+			query:        rangeQuery(from, from.Add(time.Hour)) + "&limit=20",
+			cacheControl: fmt.Sprintf("public, max-age=%d", global.HistoryCacheMaxAge),
+			filter:       models.AircraftHistoryFilter{From: from, To: from.Add(time.Hour), Limit: 21},
+		},
+		{
+			name:   "Completed range older than the history",
+			query:  rangeQuery(old, old.Add(time.Hour)),
+			filter: models.AircraftHistoryFilter{From: old, To: old.Add(time.Hour)},
+			stream: true,
+		},
+		{
+			name:   "Range without start",
+			query:  "?to=" + from.Format(time.RFC3339),
+			filter: models.AircraftHistoryFilter{To: from},
+			stream: true,
+		},
+		{
+			name:   "Range without end",
+			query:  rangeQuery(from, time.Time{}),
+			filter: models.AircraftHistoryFilter{From: from},
+			stream: true,
+		},
+		{
+			name:   "Range ending in the future",
+			query:  rangeQuery(from, future),
+			filter: models.AircraftHistoryFilter{From: from, To: future},
+			stream: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.stream {
+				mockSvc.EXPECT().StreamAircraftHistoryByIcaoTimeRange("ABC123", tt.filter, gomock.Any()).
+					DoAndReturn(streamMockData(mockData, nil))
+			} else {
+				mockSvc.EXPECT().GetAircraftHistoryByIcaoTimeRange("ABC123", tt.filter).Return(mockData, nil)
+			}
+			mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil)
+
+			res, err := http.Get(currentEndpoint.URL + global.AircraftHistoryPath + "ABC123" + tt.query)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, tt.cacheControl, res.Header.Get("Cache-Control"))
+		})
+	}
+}
+
+func TestHistoryCache(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name                                            string
+		from                                            time.Time
+		maxDaysHistory, rollupResolution, maxDaysRollup int
+		maxAge                                          time.Duration
+		cached                                          bool
+	}{
+		{name: "Kept for more than the max age", from: now.Add(-day), maxDaysHistory: 30,
+			maxAge: time.Duration(global.HistoryCacheMaxAge) * time.Second, cached: true},
+		{name: "Deleted before the max age", from: now.Add(-day), maxDaysHistory: 1, maxDaysRollup: 30},
+		{name: "Rolled up before the max age", from: now.Add(-20 * time.Hour), maxDaysHistory: 1, rollupResolution: 60,
+			maxDaysRollup: 30, maxAge: 4 * time.Hour, cached: true},
+		{name: "Rollup deleted before the max age", from: now.Add(-29*day - 22*time.Hour), maxDaysHistory: 1,
+			rollupResolution: 60, maxDaysRollup: 30, maxAge: 2 * time.Hour, cached: true},
+		{name: "Rollup kept forever", from: now.Add(-60 * day), maxDaysHistory: 1, rollupResolution: 60,
+			maxAge: time.Duration(global.HistoryCacheMaxAge) * time.Second, cached: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			global.MaxDaysHistory, global.RollupResolution, global.MaxDaysRollup =
+				tt.maxDaysHistory, tt.rollupResolution, tt.maxDaysRollup
+			defer func() { global.MaxDaysHistory, global.RollupResolution, global.MaxDaysRollup = 1, 0, 30 }()
+
+			cache, cached := historyCache(models.AircraftHistoryFilter{From: tt.from, To: tt.from.Add(time.Hour)})
+
+			assert.Equal(t, tt.cached, cached)
+			// a second may have passed since now
+			assert.InDelta(t, tt.maxAge.Seconds(), cache.MaxAge, 1)
+		})
+	}
+}
+
+func TestInvalidRequests_Cursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	currentEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftHistoryPath+"{icao}", HistoryAircraftHandler(mockSvc)),
+	}))
+	defer currentEndpoint.Close()
+
+	res, err := http.Get(currentEndpoint.URL + global.AircraftHistoryPath + "ABC123?limit=3&cursor=not-a-cursor")
+	if err != nil {
+		t.Fatalf("error executing request: %q", err)
+	}
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %q", err)
+	}
+	assert.Equal(t, errorMsg.InvalidQueryParameterCursor+"\n", string(body))
+}
@@ -0,0 +1,339 @@
+package aircraftLiveHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/service/streamService"
+	"adsb-api/internal/utility/apiUtility"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// writeWait is the time a client has to receive a message before it is disconnected
+	writeWait = 10 * time.Second
+	// maxMessageSize is the largest message accepted from a client
+	maxMessageSize = 4096
+	// msgpackProtocol is the subprotocol of the clients sent MessagePack binary messages instead of JSON text messages
+	msgpackProtocol = "msgpack"
+)
+
+// optionalParams are the query parameters of the initial subscription, and the API key of the browsers
+var optionalParams = append(append([]string{}, apiUtility.CurrentFilterParams...), "icao", "fields", global.ApiKeyParam)
+
+var upgrader = websocket.Upgrader{
+	EnableCompression: true,
+	// without a subprotocol, or with the json subprotocol, the messages are JSON
+	Subprotocols: []string{msgpackProtocol, "json"},
+	// the API can be used from any origin, as with the Access-Control-Allow-Origin header of the other endpoints
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// LiveAircraftHandler handles HTTP requests for
+// /aircraft/live?bbox=&minAltitude=&maxAltitude=&minSpeed=&maxSpeed=&callsign=&onGround=&icao=&fields=&api_key= endpoint.
+func LiveAircraftHandler(svc streamService.StreamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := apiUtility.ValidateURL(w, r, optionalParams)
+		if err != nil {
+			return
+		}
+		handleLiveAircraftGetRequest(w, r, svc)
+	}
+}
+
+// handleLiveAircraftGetRequest handles GET requests for the /aircraft/live endpoint.
+// Upgrades the connection to a WebSocket, and sends a snapshot of the aircraft matching the subscription given by the
+// query parameters, followed by deltas after every ingestion cycle. A client changes its subscription by sending a
+// subscribeMessage, and is then sent a new snapshot. Clients of the msgpack subprotocol are sent MessagePack binary
+// messages, with the same keys as the JSON messages.
+func handleLiveAircraftGetRequest(w http.ResponseWriter, r *http.Request, svc streamService.StreamService) {
+	query := r.URL.Query()
+	icao := splitList(query.Get("icao"))
+	fields := splitList(query.Get("fields"))
+	query.Del("icao")
+	query.Del("fields")
+	query.Del(global.ApiKeyParam)
+
+	sub, err := parseSubscription(query, icao, fields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already responded with an error
+		log.Debug().Msgf(errorMsg.ErrorUpgradingWebSocket+": %q", err)
+		return
+	}
+	defer conn.Close()
+
+	feed := svc.Subscribe("")
+	defer feed.Close()
+
+	c := newClient(conn, sub, feed.State)
+	if err = c.writeSnapshot(); err != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	messages := make(chan subscribeMessage)
+	done := make(chan struct{})
+	go c.readMessages(messages, done, stop)
+
+	ping := time.NewTicker(time.Duration(global.StreamHeartbeat) * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case msg := <-messages:
+			err = c.subscribe(msg)
+		case event, open := <-feed.C:
+			if !open {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, errorMsg.ErrorClientTooSlow),
+					time.Now().Add(writeWait))
+				return
+			}
+			err = c.apply(event)
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+		}
+
+		if err != nil {
+			log.Debug().Msgf(errorMsg.ErrorWritingStreamEvent+": %q", err)
+			return
+		}
+	}
+}
+
+// snapshotMessage is sent with every aircraft of the subscription when a client connects or changes its subscription.
+type snapshotMessage struct {
+	Type     string                   `json:"type"`
+	Aircraft []map[string]interface{} `json:"aircraft"`
+}
+
+// deltaMessage is sent after an ingestion cycle. Updated has the aircraft that are new to the client with all their
+// subscribed fields, and the aircraft that have changed with only the fields that have changed.
+type deltaMessage struct {
+	Type    string                   `json:"type"`
+	Updated []map[string]interface{} `json:"updated,omitempty"`
+	Removed []string                 `json:"removed,omitempty"`
+}
+
+// errorMessage is sent when a message from a client is not valid. The previous subscription is kept.
+type errorMessage struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// client represents a WebSocket client of the live feed.
+type client struct {
+	conn         *websocket.Conn
+	subscription subscription
+	// state is every current aircraft, not just the ones of the subscription
+	state map[string]models.AircraftCurrentModel
+	// sent is the fields last sent for each aircraft the client has
+	sent map[string]map[string]interface{}
+}
+
+// newClient returns a client with the subscription sub, where state is the current aircraft.
+func newClient(conn *websocket.Conn, sub subscription, state []models.AircraftCurrentModel) *client {
+	c := &client{conn: conn, subscription: sub, state: make(map[string]models.AircraftCurrentModel)}
+	for _, ac := range state {
+		c.state[ac.Icao] = ac
+	}
+	return c
+}
+
+// readMessages reads the messages from the client and sends the valid subscribe messages to messages, until the
+// connection fails or stop is closed. Invalid messages are answered with an errorMessage by the caller.
+func (c *client) readMessages(messages chan<- subscribeMessage, done chan<- struct{}, stop <-chan struct{}) {
+	defer close(done)
+
+	pongWait := 2 * time.Duration(global.StreamHeartbeat) * time.Second
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var msg subscribeMessage
+		messageType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if messageType == websocket.BinaryMessage {
+			err = decodeMsgpack(data, &msg)
+		} else {
+			err = json.Unmarshal(data, &msg)
+		}
+		if err != nil || msg.Type != "subscribe" {
+			msg = subscribeMessage{}
+		}
+
+		select {
+		case messages <- msg:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// subscribe replaces the subscription of the client with msg and writes a new snapshot. An invalid msg is answered with
+// an errorMessage.
+func (c *client) subscribe(msg subscribeMessage) error {
+	if msg.Type != "subscribe" {
+		return c.write(errorMessage{Type: "error", Error: errorMsg.ErrorInvalidSubscription})
+	}
+
+	filter := url.Values{}
+	for param, value := range msg.Filter {
+		filter.Set(param, value)
+	}
+
+	sub, err := parseSubscription(filter, msg.Icao, msg.Fields)
+	if err != nil {
+		return c.write(errorMessage{Type: "error", Error: err.Error()})
+	}
+
+	c.subscription = sub
+	return c.writeSnapshot()
+}
+
+// writeSnapshot writes every aircraft of the subscription.
+func (c *client) writeSnapshot() error {
+	c.sent = make(map[string]map[string]interface{})
+
+	msg := snapshotMessage{Type: "snapshot", Aircraft: []map[string]interface{}{}}
+	for _, icao := range sortedIcaos(c.state) {
+		ac := c.state[icao]
+		if c.subscription.matches(ac) {
+			projected := c.subscription.project(ac)
+			c.sent[icao] = projected
+			msg.Aircraft = append(msg.Aircraft, projected)
+		}
+	}
+
+	return c.write(msg)
+}
+
+// apply updates the state with event, and writes the changes to the aircraft of the subscription. Nothing is written
+// if none of them have changed.
+func (c *client) apply(event streamService.Event) error {
+	affected := make(map[string]bool)
+	if event.Snapshot {
+		for icao := range c.sent {
+			affected[icao] = true
+		}
+		c.state = make(map[string]models.AircraftCurrentModel)
+	}
+	for _, ac := range event.Updated {
+		c.state[ac.Icao] = ac
+		affected[ac.Icao] = true
+	}
+	for _, icao := range event.Removed {
+		delete(c.state, icao)
+		affected[icao] = true
+	}
+
+	msg := deltaMessage{Type: "delta"}
+	for _, icao := range sortedKeys(affected) {
+		ac, exist := c.state[icao]
+		previous, sent := c.sent[icao]
+
+		if !exist || !c.subscription.matches(ac) {
+			if sent {
+				msg.Removed = append(msg.Removed, icao)
+				delete(c.sent, icao)
+			}
+			continue
+		}
+
+		projected := c.subscription.project(ac)
+		c.sent[icao] = projected
+		if !sent {
+			msg.Updated = append(msg.Updated, projected)
+			continue
+		}
+
+		changed := map[string]interface{}{"icao": icao}
+		for field, value := range projected {
+			if previous[field] != value {
+				changed[field] = value
+			}
+		}
+		if len(changed) > 1 {
+			msg.Updated = append(msg.Updated, changed)
+		}
+	}
+
+	if len(msg.Updated) == 0 && len(msg.Removed) == 0 {
+		return nil
+	}
+	return c.write(msg)
+}
+
+// write writes msg as a MessagePack binary message to the clients of the msgpack subprotocol, and as a JSON text
+// message to the others.
+func (c *client) write(msg interface{}) error {
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	if c.conn.Subprotocol() != msgpackProtocol {
+		return c.conn.WriteJSON(msg)
+	}
+
+	w, err := c.conn.NextWriter(websocket.BinaryMessage)
+	if err != nil {
+		return err
+	}
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	if err = encoder.Encode(msg); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// decodeMsgpack decodes a MessagePack message from a client to msg, with the same keys as the JSON messages.
+func decodeMsgpack(data []byte, msg interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(msg)
+}
+
+// sortedIcaos returns the ICAO codes of state in order, so the aircraft are always sent in the same order.
+func sortedIcaos(state map[string]models.AircraftCurrentModel) []string {
+	icaos := make([]string, 0, len(state))
+	for icao := range state {
+		icaos = append(icaos, icao)
+	}
+	sort.Strings(icaos)
+	return icaos
+}
+
+// sortedKeys returns the keys of set in order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,307 @@
+package aircraftLiveHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/handler/router"
+	"adsb-api/internal/service/streamService"
+	"adsb-api/internal/utility/apiUtility"
+	"adsb-api/internal/utility/mock"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+var (
+	aircraftA = models.AircraftCurrentModel{Icao: "AAA111", Callsign: "TEST1", Altitude: 1000, Latitude: 60, Longitude: 5}
+	aircraftB = models.AircraftCurrentModel{Icao: "BBB222", Callsign: "TEST2", Altitude: 9000, Latitude: 61, Longitude: 6}
+)
+
+// setupLive returns a StreamImpl polling each of polls in order, and a server for the /aircraft/live endpoint.
+func setupLive(t *testing.T, polls ...[]models.AircraftCurrentModel) (*streamService.StreamImpl, *httptest.Server) {
+	ctrl := gomock.NewController(t)
+	mockSvc := mock.NewMockRestService(ctrl)
+	for _, poll := range polls {
+		mockSvc.EXPECT().GetCurrentAircraft().Return(poll, nil)
+	}
+
+	svc := streamService.InitStreamService(mockSvc, 10)
+	server := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftLivePath, LiveAircraftHandler(svc)),
+	}))
+	t.Cleanup(server.Close)
+	return svc, server
+}
+
+// dial connects to the /aircraft/live endpoint of server with query.
+func dial(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + global.AircraftLivePath + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readMessage reads the next message from conn.
+func readMessage(t *testing.T, conn *websocket.Conn) map[string]interface{} {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	var msg map[string]interface{}
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestInvalidRequests(t *testing.T) {
+	_, server := setupLive(t)
+	var endpoint = server.URL + global.AircraftLivePath
+
+	tests := []struct {
+		name, url, httpMethod, errorMsg string
+		statusCode                      int
+	}{
+		{
+			name:       "Post request",
+			url:        endpoint,
+			httpMethod: http.MethodPost,
+			statusCode: http.StatusMethodNotAllowed,
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
+		},
+		{
+			name:       "Unknown query parameter",
+			url:        endpoint + "?hour=1",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": " + strings.Join(optionalParams, ", "),
+		},
+		{
+			name:       "Unknown field",
+			url:        endpoint + "?fields=altitude,colour",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterFields,
+		},
+		{
+			name:       "Too long ICAO",
+			url:        endpoint + "?icao=ABC1234",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.TooLongIcao,
+		},
+		{
+			name:       "Not a WebSocket request",
+			url:        endpoint,
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   "Bad Request",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.httpMethod, test.url, nil)
+			require.NoError(t, err)
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.statusCode, res.StatusCode)
+			assert.Equal(t, test.errorMsg+"\n", string(body))
+		})
+	}
+}
+
+func TestLiveAircraft(t *testing.T) {
+	climbedA := aircraftA
+	climbedA.Altitude = 8000
+	movedB := aircraftB
+	movedB.Latitude = 62
+
+	svc, server := setupLive(t,
+		[]models.AircraftCurrentModel{aircraftA, aircraftB},
+		[]models.AircraftCurrentModel{climbedA, movedB},
+		[]models.AircraftCurrentModel{climbedA},
+	)
+	require.NoError(t, svc.Poll())
+
+	// the API key of the browsers is not part of the subscription
+	conn := dial(t, server, "?minAltitude=5000&fields=altitude,latitude&"+global.ApiKeyParam+"=adsb_live")
+
+	msg := readMessage(t, conn)
+	assert.Equal(t, "snapshot", msg["type"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"icao": "BBB222", "altitude": float64(9000), "latitude": float64(61)},
+	}, msg["aircraft"])
+
+	// aircraft A climbs into the subscription with all its fields, only the latitude of aircraft B is sent
+	require.NoError(t, svc.Poll())
+	msg = readMessage(t, conn)
+	assert.Equal(t, "delta", msg["type"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"icao": "AAA111", "altitude": float64(8000), "latitude": float64(60)},
+		map[string]interface{}{"icao": "BBB222", "latitude": float64(62)},
+	}, msg["updated"])
+	assert.Nil(t, msg["removed"])
+
+	require.NoError(t, svc.Poll())
+	msg = readMessage(t, conn)
+	assert.Equal(t, "delta", msg["type"])
+	assert.Equal(t, []interface{}{"BBB222"}, msg["removed"])
+}
+
+func TestLiveAircraftMsgpack(t *testing.T) {
+	svc, server := setupLive(t, []models.AircraftCurrentModel{aircraftA, aircraftB})
+	require.NoError(t, svc.Poll())
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + global.AircraftLivePath + "?fields=altitude"
+	dialer := websocket.Dialer{Subprotocols: []string{"msgpack"}}
+	conn, _, err := dialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "msgpack", conn.Subprotocol())
+
+	// readMsgpack reads the next message from conn, which must be a MessagePack binary message
+	readMsgpack := func() map[string]interface{} {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		messageType, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.BinaryMessage, messageType)
+
+		var msg map[string]interface{}
+		require.NoError(t, msgpack.Unmarshal(data, &msg))
+		return msg
+	}
+
+	// the integers are compact, in the smallest type that holds them
+	msg := readMsgpack()
+	assert.Equal(t, "snapshot", msg["type"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"icao": "AAA111", "altitude": uint16(1000)},
+		map[string]interface{}{"icao": "BBB222", "altitude": uint16(9000)},
+	}, msg["aircraft"])
+
+	// the subscription is changed with a MessagePack message
+	subscribe, err := msgpack.Marshal(map[string]interface{}{"type": "subscribe", "icao": []string{"BBB222"}})
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, subscribe))
+
+	msg = readMsgpack()
+	assert.Equal(t, "snapshot", msg["type"])
+	assert.Len(t, msg["aircraft"], 1)
+}
+
+func TestLiveAircraftSubscribe(t *testing.T) {
+	svc, server := setupLive(t, []models.AircraftCurrentModel{aircraftA, aircraftB})
+	require.NoError(t, svc.Poll())
+
+	conn := dial(t, server, "")
+	msg := readMessage(t, conn)
+	assert.Len(t, msg["aircraft"], 2)
+
+	tests := []struct {
+		name     string
+		message  string
+		expected map[string]interface{}
+	}{
+		{
+			name:    "Watchlist",
+			message: `{"type":"subscribe","icao":["aaa111"],"fields":["callsign"]}`,
+			expected: map[string]interface{}{"type": "snapshot", "aircraft": []interface{}{
+				map[string]interface{}{"icao": "AAA111", "callsign": "TEST1"},
+			}},
+		},
+		{
+			name:    "Viewport",
+			message: `{"type":"subscribe","filter":{"bbox":"5.5,60.5,7,62"},"fields":["altitude"]}`,
+			expected: map[string]interface{}{"type": "snapshot", "aircraft": []interface{}{
+				map[string]interface{}{"icao": "BBB222", "altitude": float64(9000)},
+			}},
+		},
+		{
+			name:     "Nothing matches",
+			message:  `{"type":"subscribe","filter":{"callsign":"SAS"}}`,
+			expected: map[string]interface{}{"type": "snapshot", "aircraft": []interface{}{}},
+		},
+		{
+			name:     "Invalid filter",
+			message:  `{"type":"subscribe","filter":{"minSpeed":"fast"}}`,
+			expected: map[string]interface{}{"type": "error", "error": errorMsg.InvalidQueryParameterSpeed},
+		},
+		{
+			name:     "Unknown filter",
+			message:  `{"type":"subscribe","filter":{"hour":"1"}}`,
+			expected: map[string]interface{}{"type": "error", "error": errorMsg.ErrorInvalidQueryParams + ": " + strings.Join(apiUtility.CurrentFilterParams, ", ")},
+		},
+		{
+			name:     "Unknown message type",
+			message:  `{"type":"unsubscribe"}`,
+			expected: map[string]interface{}{"type": "error", "error": errorMsg.ErrorInvalidSubscription},
+		},
+		{
+			name:     "Not JSON",
+			message:  `subscribe`,
+			expected: map[string]interface{}{"type": "error", "error": errorMsg.ErrorInvalidSubscription},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(test.message)))
+
+			msg := readMessage(t, conn)
+			expected, err := json.Marshal(test.expected)
+			require.NoError(t, err)
+			actual, err := json.Marshal(msg)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestLiveAircraftSlowClient(t *testing.T) {
+	var polls [][]models.AircraftCurrentModel
+	for i := 0; i < 200; i++ {
+		ac := aircraftA
+		ac.Altitude = i
+		polls = append(polls, []models.AircraftCurrentModel{ac})
+	}
+
+	svc, server := setupLive(t, polls...)
+	svc.BufferSize = 1
+
+	conn := dial(t, server, "")
+	readMessage(t, conn)
+
+	// the feed is polled faster than the handler can take the events
+	for range polls {
+		require.NoError(t, svc.Poll())
+	}
+
+	for {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		_, _, err := conn.ReadMessage()
+		if err != nil {
+			assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater), "unexpected error: %v", err)
+			break
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package aircraftLiveHandler
+
+import (
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/utility/apiUtility"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// aircraftFields are the fields a client can subscribe to, by their name in AircraftCurrentModel
+var aircraftFields = map[string]func(ac models.AircraftCurrentModel) interface{}{
+	"callsign":  func(ac models.AircraftCurrentModel) interface{} { return ac.Callsign },
+	"altitude":  func(ac models.AircraftCurrentModel) interface{} { return ac.Altitude },
+	"latitude":  func(ac models.AircraftCurrentModel) interface{} { return ac.Latitude },
+	"longitude": func(ac models.AircraftCurrentModel) interface{} { return ac.Longitude },
+	"speed":     func(ac models.AircraftCurrentModel) interface{} { return ac.Speed },
+	"track":     func(ac models.AircraftCurrentModel) interface{} { return ac.Track },
+	"vspeed":    func(ac models.AircraftCurrentModel) interface{} { return ac.VerticalRate },
+	"timestamp": func(ac models.AircraftCurrentModel) interface{} { return ac.Timestamp },
+	"onGround":  func(ac models.AircraftCurrentModel) interface{} { return ac.OnGround },
+	"registry":  func(ac models.AircraftCurrentModel) interface{} { return ac.Registry },
+}
+
+// defaultFields are the fields sent when a client does not choose any
+var defaultFields = []string{"callsign", "altitude", "latitude", "longitude", "speed", "track", "vspeed", "timestamp",
+	"onGround", "registry"}
+
+// subscription represents which aircraft, and which of their fields, a client is sent.
+type subscription struct {
+	filter models.AircraftCurrentFilter
+	// watchlist is the ICAO codes the client is sent, all aircraft matching filter if empty
+	watchlist map[string]bool
+	fields    []string
+}
+
+// subscribeMessage represents a message from a client changing its subscription. Filter takes the query parameters of
+// /aircraft/current/, and replaces the whole subscription along with Icao and Fields.
+type subscribeMessage struct {
+	Type   string            `json:"type"`
+	Filter map[string]string `json:"filter"`
+	Icao   []string          `json:"icao"`
+	Fields []string          `json:"fields"`
+}
+
+// parseSubscription parses the filter query parameters, the ICAO watchlist and the fields to a subscription.
+// Returns an error with the message for the first invalid value.
+func parseSubscription(filter url.Values, icao []string, fields []string) (subscription, error) {
+	var sub subscription
+
+	for param := range filter {
+		if !slices.Contains(apiUtility.CurrentFilterParams, param) {
+			return sub, fmt.Errorf(errorMsg.ErrorInvalidQueryParams+": %s", strings.Join(apiUtility.CurrentFilterParams, ", "))
+		}
+	}
+
+	var err error
+	sub.filter, err = apiUtility.ParseCurrentFilter(filter)
+	if err != nil {
+		return sub, err
+	}
+
+	sub.watchlist = make(map[string]bool)
+	for _, code := range icao {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" {
+			return sub, errors.New(errorMsg.EmptyIcao)
+		}
+		if len(code) > 6 {
+			return sub, errors.New(errorMsg.TooLongIcao)
+		}
+		sub.watchlist[code] = true
+	}
+
+	sub.fields = defaultFields
+	if len(fields) > 0 {
+		sub.fields = nil
+		for _, field := range fields {
+			if _, exist := aircraftFields[field]; !exist {
+				return sub, errors.New(errorMsg.InvalidQueryParameterFields)
+			}
+			sub.fields = append(sub.fields, field)
+		}
+	}
+
+	return sub, nil
+}
+
+// matches reports whether ac is sent to the client.
+func (sub subscription) matches(ac models.AircraftCurrentModel) bool {
+	if len(sub.watchlist) > 0 && !sub.watchlist[ac.Icao] {
+		return false
+	}
+	return sub.filter.Matches(ac)
+}
+
+// project returns the subscribed fields of ac, along with its ICAO.
+func (sub subscription) project(ac models.AircraftCurrentModel) map[string]interface{} {
+	projected := map[string]interface{}{"icao": ac.Icao}
+	for _, field := range sub.fields {
+		projected[field] = aircraftFields[field](ac)
+	}
+	return projected
+}
+
+// splitList splits a comma separated query parameter. Returns nil if value is empty.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
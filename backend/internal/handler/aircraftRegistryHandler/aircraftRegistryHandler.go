@@ -0,0 +1,49 @@
+package aircraftRegistryHandler
+
+import (
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/service/restService"
+	"adsb-api/internal/utility/apiUtility"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RegistryAircraftHandler handles HTTP requests for /aircraft/registry/{icao} endpoint.
+func RegistryAircraftHandler(svc restService.RestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := apiUtility.ValidateURL(w, r, []string{})
+		if err != nil {
+			return
+		}
+		handleRegistryAircraftGetRequest(w, r, svc)
+	}
+}
+
+// handleRegistryAircraftGetRequest handles GET requests for the /aircraft/registry/{icao} endpoint.
+// Sends the registry data for the aircraft given by the icao path parameter.
+func handleRegistryAircraftGetRequest(w http.ResponseWriter, r *http.Request, svc restService.RestService) {
+	search := r.PathValue("icao")
+	if len(search) > 6 {
+		http.Error(w, errorMsg.TooLongIcao, http.StatusBadRequest)
+		return
+	}
+
+	res, err := svc.GetAircraftRegistryByIcao(search)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorRetrievingRegistry+search, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorRetrievingRegistry+": %s Error : %q URL: %q", search, err, r.URL)
+		return
+	}
+
+	if res == nil {
+		http.Error(w, errorMsg.RegistryNotFound, http.StatusNotFound)
+		return
+	}
+
+	err = apiUtility.EncodeData(w, r, res)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
+	}
+}
@@ -0,0 +1,154 @@
+package aircraftRegistryHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/handler/router"
+	"adsb-api/internal/utility/mock"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+func TestInvalidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	registryEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftRegistryPath+"{icao}", RegistryAircraftHandler(mockSvc)),
+	}))
+	defer registryEndpoint.Close()
+
+	var endpoint = registryEndpoint.URL + global.AircraftRegistryPath
+
+	tests := []struct {
+		name, url, httpMethod, errorMsg string
+		statusCode                      int
+		setup                           func(mockSvc *mock.MockRestService)
+	}{
+		{
+			name:       "Post request",
+			url:        endpoint + "ABC123",
+			httpMethod: http.MethodPost,
+			statusCode: http.StatusMethodNotAllowed,
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
+		},
+		{
+			name:       "Get request with unknown path",
+			url:        endpoint + "endpoint/endpoint/",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
+		},
+		{
+			name:       "Get request without icao",
+			url:        endpoint,
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
+		},
+		{
+			name:       "Too long ICAO",
+			url:        endpoint + "ABC1234",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.TooLongIcao,
+		},
+		{
+			name:       "Get request with invalid parameter",
+			url:        endpoint + "ABC123?param=123",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": ",
+		},
+		{
+			name:       "Database returns error",
+			url:        endpoint + "ABC123",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusInternalServerError,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, errors.New("expected error"))
+			},
+			errorMsg: errorMsg.ErrorRetrievingRegistry + "ABC123",
+		},
+		{
+			name:       "Aircraft not registered",
+			url:        endpoint + "ABC123",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetAircraftRegistryByIcao("ABC123").Return(nil, nil)
+			},
+			errorMsg: errorMsg.RegistryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(mockSvc)
+			}
+
+			req, err := http.NewRequest(tt.httpMethod, tt.url, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing %s request: %s", tt.name, tt.httpMethod, err.Error())
+			}
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Errorf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+			assert.Equal(t, tt.errorMsg+"\n", string(body))
+		})
+	}
+}
+
+func TestValidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	registryEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftRegistryPath+"{icao}", RegistryAircraftHandler(mockSvc)),
+	}))
+	defer registryEndpoint.Close()
+
+	registry := models.AircraftRegistryModel{Icao: "ABC123", Registration: "LN-ABC", TypeCode: "B738",
+		Manufacturer: "Boeing", Model: "737-8JP", Operator: "Norwegian", Year: 2010}
+
+	mockSvc.EXPECT().GetAircraftRegistryByIcao("abc123").Return(&registry, nil)
+
+	res, err := http.Get(registryEndpoint.URL + global.AircraftRegistryPath + "abc123")
+	if err != nil {
+		t.Fatalf("error executing request: %q", err)
+	}
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var actual models.AircraftRegistryModel
+	err = json.NewDecoder(res.Body).Decode(&actual)
+	if err != nil {
+		t.Fatalf("error decoding response body: %q", err)
+	}
+
+	assert.Equal(t, registry, actual)
+}
@@ -0,0 +1,81 @@
+package aircraftSearchHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/service/restService"
+	"adsb-api/internal/utility/apiUtility"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+var optionalParams = []string{"q", "limit"}
+
+// searchResult is an aircraft sighting with the path of the history of the aircraft.
+type searchResult struct {
+	models.AircraftSightingModel
+	History string `json:"history"`
+}
+
+// SearchAircraftHandler handles HTTP requests for /aircraft/search?q=&limit= endpoint.
+func SearchAircraftHandler(svc restService.RestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := apiUtility.ValidateURL(w, r, optionalParams)
+		if err != nil {
+			return
+		}
+		handleSearchAircraftGetRequest(w, r, svc)
+	}
+}
+
+// handleSearchAircraftGetRequest handles GET requests for the /aircraft/search?q=&limit= endpoint.
+// Sends the aircraft whose callsign or ICAO code starts with the q parameter, ignoring case, both current and from the
+// history. Each result is an aircraft with one of its callsigns, most recently seen first, and at most limit results
+// are sent.
+func handleSearchAircraftGetRequest(w http.ResponseWriter, r *http.Request, svc restService.RestService) {
+	search := strings.TrimSpace(r.URL.Query().Get("q"))
+	if search == "" || len(search) > 10 {
+		http.Error(w, errorMsg.InvalidQueryParameterSearch, http.StatusBadRequest)
+		return
+	}
+
+	limit := global.SearchDefaultLimit
+	if r.URL.Query().Has("limit") {
+		var err error
+		limit, err = strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			http.Error(w, errorMsg.InvalidQueryParameterLimit, http.StatusBadRequest)
+			return
+		}
+	}
+	if limit > global.SearchMaxLimit {
+		limit = global.SearchMaxLimit
+	}
+
+	res, err := svc.SearchAircraft(search, limit)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorSearchingAircraft, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorSearchingAircraft+": %q Path: %q", err, r.URL)
+		return
+	}
+	if len(res) == 0 {
+		apiUtility.NoContent(w)
+		return
+	}
+
+	results := make([]searchResult, 0, len(res))
+	for _, sighting := range res {
+		results = append(results, searchResult{AircraftSightingModel: sighting,
+			History: global.AircraftHistoryPath + sighting.Icao})
+	}
+
+	err = apiUtility.EncodeData(w, r, results)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
+	}
+}
@@ -0,0 +1,206 @@
+package aircraftSearchHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/handler/router"
+	"adsb-api/internal/utility/mock"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+func TestInvalidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	searchEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftSearchPath, SearchAircraftHandler(mockSvc)),
+	}))
+	defer searchEndpoint.Close()
+
+	var endpoint = searchEndpoint.URL + global.AircraftSearchPath
+
+	tests := []struct {
+		name, url, httpMethod, errorMsg string
+		statusCode                      int
+		setup                           func(mockSvc *mock.MockRestService)
+	}{
+		{
+			name:       "Post request",
+			url:        endpoint + "?q=SAS",
+			httpMethod: http.MethodPost,
+			statusCode: http.StatusMethodNotAllowed,
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
+		},
+		{
+			name:       "Get request with unknown path",
+			url:        endpoint + "/SAS",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
+		},
+		{
+			name:       "Get request without q",
+			url:        endpoint,
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterSearch,
+		},
+		{
+			name:       "Get request with too long q",
+			url:        endpoint + "?q=SAS12345678",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterSearch,
+		},
+		{
+			name:       "Get request with invalid limit",
+			url:        endpoint + "?q=SAS&limit=0",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterLimit,
+		},
+		{
+			name:       "Get request with invalid parameter",
+			url:        endpoint + "?q=SAS&hour=1",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": q, limit",
+		},
+		{
+			name:       "Database returns error",
+			url:        endpoint + "?q=SAS",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusInternalServerError,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().SearchAircraft("SAS", global.SearchDefaultLimit).Return(nil, errors.New("connection refused"))
+			},
+			errorMsg: errorMsg.ErrorSearchingAircraft,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(mockSvc)
+			}
+
+			req, err := http.NewRequest(tt.httpMethod, tt.url, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing %s request: %s", tt.name, tt.httpMethod, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Errorf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+			assert.Equal(t, tt.errorMsg+"\n", string(body))
+		})
+	}
+}
+
+func TestValidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	searchEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftSearchPath, SearchAircraftHandler(mockSvc)),
+	}))
+	defer searchEndpoint.Close()
+
+	var endpoint = searchEndpoint.URL + global.AircraftSearchPath
+
+	sightings := []models.AircraftSightingModel{
+		{Icao: "4CA2D1", Callsign: "SAS123", FirstSeen: "2024-04-11T20:02:41Z", LastSeen: "2024-04-11T20:15:18Z", Current: true},
+		{Icao: "478F3A", Callsign: "SAS123", FirstSeen: "2024-04-10T19:58:02Z", LastSeen: "2024-04-10T21:01:44Z"},
+	}
+
+	tests := []struct {
+		name, url, search string
+		limit, statusCode int
+		sightings         []models.AircraftSightingModel
+	}{
+		{
+			name:       "Search by callsign",
+			url:        endpoint + "?q=sas1",
+			search:     "sas1",
+			limit:      global.SearchDefaultLimit,
+			statusCode: http.StatusOK,
+			sightings:  sightings,
+		},
+		{
+			name:       "Search with limit",
+			url:        endpoint + "?q=4CA&limit=1",
+			search:     "4CA",
+			limit:      1,
+			statusCode: http.StatusOK,
+			sightings:  sightings[:1],
+		},
+		{
+			name:       "Search with limit above the max",
+			url:        endpoint + "?q=4CA&limit=1000",
+			search:     "4CA",
+			limit:      global.SearchMaxLimit,
+			statusCode: http.StatusOK,
+			sightings:  sightings[:1],
+		},
+		{
+			name:       "Search without results",
+			url:        endpoint + "?q=KLM",
+			search:     "KLM",
+			limit:      global.SearchDefaultLimit,
+			statusCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc.EXPECT().SearchAircraft(tt.search, tt.limit).Return(tt.sightings, nil)
+
+			res, err := http.Get(tt.url)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			if tt.sightings == nil {
+				return
+			}
+
+			var actual []searchResult
+			err = json.NewDecoder(res.Body).Decode(&actual)
+			if err != nil {
+				t.Errorf("Test: %s. Error decoding response body: %s", tt.name, err.Error())
+			}
+
+			assert.Len(t, actual, len(tt.sightings))
+			for i, result := range actual {
+				assert.Equal(t, tt.sightings[i], result.AircraftSightingModel)
+				assert.Equal(t, global.AircraftHistoryPath+tt.sightings[i].Icao, result.History)
+			}
+		})
+	}
+}
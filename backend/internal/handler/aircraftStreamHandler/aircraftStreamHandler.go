@@ -0,0 +1,191 @@
+package aircraftStreamHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/service/streamService"
+	"adsb-api/internal/utility/apiUtility"
+	"adsb-api/internal/utility/convert"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// optionalParams are the query parameters of the filter, and the API key of the browsers
+var optionalParams = append(append([]string{}, apiUtility.CurrentFilterParams...), global.ApiKeyParam)
+
+// StreamAircraftHandler handles HTTP requests for
+// /aircraft/stream?bbox=&minAltitude=&maxAltitude=&minSpeed=&maxSpeed=&callsign=&onGround=&api_key= endpoint.
+func StreamAircraftHandler(svc streamService.StreamService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := apiUtility.ValidateURL(w, r, optionalParams)
+		if err != nil {
+			return
+		}
+		handleStreamAircraftGetRequest(w, r, svc)
+	}
+}
+
+// handleStreamAircraftGetRequest handles GET requests for the /aircraft/stream endpoint.
+// Sends Server-Sent Events with the current aircraft matching the query parameters: a snapshot on connect, then the
+// aircraft that changed and the aircraft that are gone after every ingestion cycle. A client reconnecting with
+// Last-Event-ID gets the events it missed instead of a new snapshot, if they are still kept.
+func handleStreamAircraftGetRequest(w http.ResponseWriter, r *http.Request, svc streamService.StreamService) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, errorMsg.ErrorStreamingNotSupported, http.StatusInternalServerError)
+		return
+	}
+
+	filter, err := apiUtility.ParseCurrentFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub := svc.Subscribe(r.Header.Get("Last-Event-ID"))
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Add("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+
+	c := &client{filter: filter}
+	for _, event := range sub.Events {
+		if err = c.write(w, event); err != nil {
+			return
+		}
+	}
+	c.reset(sub.State)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(time.Duration(global.StreamHeartbeat) * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-sub.C:
+			if !open {
+				// the client did not keep up, it reconnects and resumes from its last event
+				return
+			}
+			err = c.write(w, event)
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		}
+
+		if err != nil {
+			log.Debug().Msgf(errorMsg.ErrorWritingStreamEvent+": %q", err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// client represents the aircraft a stream client has been sent, so that an aircraft leaving the filter is sent as
+// removed.
+type client struct {
+	filter models.AircraftCurrentFilter
+	// visible is nil until the first snapshot or reset, while it is not known which aircraft the client has
+	visible map[string]bool
+}
+
+// reset sets the aircraft the client has to the aircraft in state matching the filter.
+func (c *client) reset(state []models.AircraftCurrentModel) {
+	c.visible = make(map[string]bool)
+	for _, ac := range state {
+		if c.filter.Matches(ac) {
+			c.visible[ac.Icao] = true
+		}
+	}
+}
+
+// write writes event to w as the events for this client. A snapshot is written as a 'snapshot' event, other events
+// as an 'update' event with the aircraft that are new or changed, and a 'remove' event with the aircraft that are gone.
+// Only the last event written gets the ID, so a client resuming from it has received all of event.
+func (c *client) write(w http.ResponseWriter, event streamService.Event) error {
+	if event.Snapshot {
+		c.reset(event.Updated)
+
+		var aircraft []models.AircraftCurrentModel
+		for _, ac := range event.Updated {
+			if c.visible[ac.Icao] {
+				aircraft = append(aircraft, ac)
+			}
+		}
+		return writeFeatures(w, "snapshot", event.ID, aircraft)
+	}
+
+	var updated []models.AircraftCurrentModel
+	var removed []string
+	for _, ac := range event.Updated {
+		if c.filter.Matches(ac) {
+			updated = append(updated, ac)
+			if c.visible != nil {
+				c.visible[ac.Icao] = true
+			}
+		} else if c.visible == nil || c.visible[ac.Icao] {
+			removed = append(removed, ac.Icao)
+			delete(c.visible, ac.Icao)
+		}
+	}
+	for _, icao := range event.Removed {
+		if c.visible == nil || c.visible[icao] {
+			removed = append(removed, icao)
+			delete(c.visible, icao)
+		}
+	}
+
+	if len(updated) > 0 {
+		id := event.ID
+		if len(removed) > 0 {
+			id = ""
+		}
+		if err := writeFeatures(w, "update", id, updated); err != nil {
+			return err
+		}
+	}
+	if len(removed) > 0 {
+		return writeEvent(w, "remove", event.ID, struct {
+			Icao []string `json:"icao"`
+		}{removed})
+	}
+	return nil
+}
+
+// writeFeatures writes aircraft as a GeoJSON FeatureCollection event.
+func writeFeatures(w http.ResponseWriter, name string, id string, aircraft []models.AircraftCurrentModel) error {
+	features, err := convert.CurrentModelToGeoJson(aircraft)
+	if err != nil {
+		return err
+	}
+	if features.Features == nil {
+		features.Features = []geoJSON.FeaturePoint{}
+	}
+	return writeEvent(w, name, id, features)
+}
+
+// writeEvent writes data as JSON in a Server-Sent Event. The id field is left out if id is empty.
+func writeEvent(w http.ResponseWriter, name string, id string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		if _, err = fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, encoded)
+	return err
+}
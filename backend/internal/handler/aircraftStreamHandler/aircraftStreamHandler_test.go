@@ -0,0 +1,256 @@
+package aircraftStreamHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/handler/auth"
+	"adsb-api/internal/handler/router"
+	"adsb-api/internal/service/streamService"
+	"adsb-api/internal/utility/mock"
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+var (
+	aircraftA = models.AircraftCurrentModel{Icao: "AAA111", Callsign: "TEST1", Altitude: 1000, Latitude: 60, Longitude: 5}
+	aircraftB = models.AircraftCurrentModel{Icao: "BBB222", Callsign: "TEST2", Altitude: 9000, Latitude: 61, Longitude: 6}
+)
+
+// sseEvent represents a Server-Sent Event read from the stream.
+type sseEvent struct {
+	id, name, data string
+}
+
+// readEvent reads the next event from the stream, skipping comments.
+func readEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if event.name != "" {
+				return event
+			}
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// icaos returns the ICAO of each feature in the FeatureCollection of event.
+func icaos(t *testing.T, event sseEvent) []string {
+	var features geoJSON.FeatureCollectionPoint
+	require.NoError(t, json.Unmarshal([]byte(event.data), &features))
+
+	result := []string{}
+	for _, feature := range features.Features {
+		result = append(result, feature.Properties.Icao)
+	}
+	return result
+}
+
+// setupStream returns a StreamImpl polling each of polls in order.
+func setupStream(t *testing.T, polls ...[]models.AircraftCurrentModel) *streamService.StreamImpl {
+	ctrl := gomock.NewController(t)
+	mockSvc := mock.NewMockRestService(ctrl)
+	for _, poll := range polls {
+		mockSvc.EXPECT().GetCurrentAircraft().Return(poll, nil)
+	}
+	return streamService.InitStreamService(mockSvc, 10)
+}
+
+func TestInvalidRequests(t *testing.T) {
+	svc := setupStream(t)
+	streamEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftStreamPath, StreamAircraftHandler(svc)),
+	}))
+	defer streamEndpoint.Close()
+
+	var endpoint = streamEndpoint.URL + global.AircraftStreamPath
+
+	tests := []struct {
+		name, url, httpMethod, errorMsg string
+		statusCode                      int
+	}{
+		{
+			name:       "Post request",
+			url:        endpoint,
+			httpMethod: http.MethodPost,
+			statusCode: http.StatusMethodNotAllowed,
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
+		},
+		{
+			name:       "Unknown query parameter",
+			url:        endpoint + "?hour=1",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": " + strings.Join(optionalParams, ", "),
+		},
+		{
+			name:       "Invalid bbox",
+			url:        endpoint + "?bbox=1,2,3",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterBbox,
+		},
+		{
+			name:       "Too long URL",
+			url:        endpoint + "/extra",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.httpMethod, test.url, nil)
+			require.NoError(t, err)
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.statusCode, res.StatusCode)
+			assert.Equal(t, test.errorMsg+"\n", string(body))
+		})
+	}
+}
+
+func TestStreamAircraft(t *testing.T) {
+	climbedA := aircraftA
+	climbedA.Altitude = 8000
+
+	svc := setupStream(t,
+		[]models.AircraftCurrentModel{aircraftA, aircraftB},
+		[]models.AircraftCurrentModel{climbedA, aircraftB},
+		[]models.AircraftCurrentModel{climbedA},
+	)
+	require.NoError(t, svc.Poll())
+
+	streamEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftStreamPath, StreamAircraftHandler(svc)),
+	}))
+	defer streamEndpoint.Close()
+
+	res, err := http.Get(streamEndpoint.URL + global.AircraftStreamPath + "?minAltitude=5000")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	reader := bufio.NewReader(res.Body)
+
+	event := readEvent(t, reader)
+	assert.Equal(t, "snapshot", event.name)
+	assert.Equal(t, []string{aircraftB.Icao}, icaos(t, event))
+	assert.NotEmpty(t, event.id)
+
+	// aircraft A climbs into the filter
+	require.NoError(t, svc.Poll())
+	event = readEvent(t, reader)
+	assert.Equal(t, "update", event.name)
+	assert.Equal(t, []string{aircraftA.Icao}, icaos(t, event))
+	assert.NotEmpty(t, event.id)
+
+	// aircraft B is gone
+	require.NoError(t, svc.Poll())
+	event = readEvent(t, reader)
+	assert.Equal(t, "remove", event.name)
+	assert.JSONEq(t, `{"icao":["BBB222"]}`, event.data)
+}
+
+func TestStreamAircraftResume(t *testing.T) {
+	svc := setupStream(t,
+		[]models.AircraftCurrentModel{aircraftA},
+		[]models.AircraftCurrentModel{aircraftB},
+	)
+	require.NoError(t, svc.Poll())
+	lastEventID := svc.Subscribe("").Events[0].ID
+	require.NoError(t, svc.Poll())
+
+	streamEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftStreamPath, StreamAircraftHandler(svc)),
+	}))
+	defer streamEndpoint.Close()
+
+	req, err := http.NewRequest(http.MethodGet, streamEndpoint.URL+global.AircraftStreamPath, nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", lastEventID)
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	reader := bufio.NewReader(res.Body)
+
+	// the missed event is replayed instead of a snapshot, the update and remove share the ID of the event
+	event := readEvent(t, reader)
+	assert.Equal(t, "update", event.name)
+	assert.Equal(t, []string{aircraftB.Icao}, icaos(t, event))
+	assert.Empty(t, event.id)
+
+	event = readEvent(t, reader)
+	assert.Equal(t, "remove", event.name)
+	assert.JSONEq(t, `{"icao":["AAA111"]}`, event.data)
+	assert.NotEmpty(t, event.id)
+	assert.NotEqual(t, lastEventID, event.id)
+}
+
+func TestStreamAircraftApiKey(t *testing.T) {
+	svc := setupStream(t, []models.AircraftCurrentModel{aircraftA, aircraftB})
+	require.NoError(t, svc.Poll())
+
+	// an EventSource can not send headers, so the key is sent in the query
+	global.AnonymousAccess = false
+	defer func() { global.AnonymousAccess = true }()
+
+	ctrl := gomock.NewController(t)
+	mockSvc := mock.NewMockRestService(ctrl)
+	mockSvc.EXPECT().GetApiKey("adsb_live").Return(&models.ApiKeyModel{Name: "dashboard", Scopes: []string{"live"}}, nil)
+
+	streamEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.AircraftStreamPath, StreamAircraftHandler(svc)).Scoped("live"),
+	}, auth.New(mockSvc).Middleware))
+	defer streamEndpoint.Close()
+
+	res, err := http.Get(streamEndpoint.URL + global.AircraftStreamPath)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res, err = http.Get(streamEndpoint.URL + global.AircraftStreamPath + "?minAltitude=5000&" + global.ApiKeyParam +
+		"=adsb_live")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	event := readEvent(t, bufio.NewReader(res.Body))
+	assert.Equal(t, "snapshot", event.name)
+	assert.Equal(t, []string{aircraftB.Icao}, icaos(t, event))
+}
@@ -0,0 +1,210 @@
+// Package auth authenticates the requests of the REST API with API keys, and rate limits them by their key, or by the
+// address of the client for the requests without a key.
+package auth
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/service/restService"
+	"adsb-api/internal/utility/rateLimit"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// window is the period the rate limits are counted over
+const window = time.Minute
+
+// keyCacheTTL is how long a looked up API key, or the lack of one, is cached for. A revoked key is refused at most
+// that long after it is revoked.
+const keyCacheTTL = 30 * time.Second
+
+// rateLimitHeaders are the headers of the rate limit of a response, which are exposed to the browsers
+const rateLimitHeaders = "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After"
+
+// Auth authenticates and rate limits the requests of the REST API.
+type Auth struct {
+	svc       restService.RestService
+	limiter   *rateLimit.Limiter
+	now       func() time.Time
+	mu        sync.Mutex
+	keys      map[string]cachedKey
+	lastPrune time.Time
+}
+
+// cachedKey is a looked up API key, nil if there is no such key, and when it is to be looked up again.
+type cachedKey struct {
+	apiKey  *models.ApiKeyModel
+	expires time.Time
+}
+
+// New returns an Auth looking up the API keys with svc.
+func New(svc restService.RestService) *Auth {
+	return &Auth{svc: svc, limiter: rateLimit.NewLimiter(window), now: time.Now, keys: map[string]cachedKey{}}
+}
+
+// Middleware wraps next, which requires the API key scope scope, or no scope if it is empty, for router.New.
+//
+// A request is authenticated with an API key, as a Bearer token in the Authorization header, in the X-API-Key header or
+// in the global.ApiKeyParam query parameter, and rate limited by its key. A request without a key is rate limited by
+// the address of its client, with the scopes of global.AnonymousScopes, and is answered with 401 if
+// global.AnonymousAccess is false. A request with an invalid key is answered with 401, and counts towards the rate
+// limit of its address. A request over its rate limit is answered with 429, and a request with a key without scope with
+// 403.
+//
+// The keys are cached for keyCacheTTL, as are the invalid keys. A key that is not cached counts towards the rate limit
+// of the address before it is looked up, so that guessing keys reaches the database no more often than that limit.
+func (a *Auth) Middleware(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client := "address:" + clientAddress(r)
+		limit := global.AnonymousRateLimit
+		scopes := global.AnonymousScopes
+
+		key := requestKey(r)
+		if key == "" && !global.AnonymousAccess {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			deny(w, errorMsg.ApiKeyRequired, http.StatusUnauthorized)
+			return
+		}
+		if key != "" {
+			apiKey, cached := a.cachedKey(key)
+			// guessing keys is rate limited as the requests without a key
+			if (!cached || apiKey == nil) && !a.take(w, client, limit) {
+				return
+			}
+			if !cached {
+				var err error
+				apiKey, err = a.svc.GetApiKey(key)
+				if err != nil {
+					deny(w, errorMsg.ErrorRetrievingApiKey, http.StatusInternalServerError)
+					// the path only, as the query may have the key
+					log.Error().Msgf(errorMsg.ErrorRetrievingApiKey+": %q URL: %q", err, r.URL.Path)
+					return
+				}
+				a.cacheKey(key, apiKey)
+			}
+			if apiKey == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				deny(w, errorMsg.InvalidApiKey, http.StatusUnauthorized)
+				return
+			}
+			client = "key:" + apiKey.Name
+			limit = apiKey.RateLimit
+			if limit == 0 {
+				limit = global.ApiKeyRateLimit
+			}
+			scopes = apiKey.Scopes
+		}
+
+		if !a.take(w, client, limit) {
+			return
+		}
+		if scope != "" && !slices.Contains(scopes, scope) {
+			deny(w, fmt.Sprintf(errorMsg.MissingScope, scope), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// cachedKey returns the cached API key of key, nil if key is invalid, and whether it is cached.
+func (a *Auth) cachedKey(key string) (*models.ApiKeyModel, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	cached, found := a.keys[key]
+	if !found || !a.now().Before(cached.expires) {
+		return nil, false
+	}
+	return cached.apiKey, true
+}
+
+// cacheKey caches apiKey, nil if key is invalid, as the API key of key for keyCacheTTL. The expired keys are deleted at
+// most once every keyCacheTTL, so that the invalid keys that were tried do not stay in the cache.
+func (a *Auth) cacheKey(key string, apiKey *models.ApiKeyModel) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if now.Sub(a.lastPrune) >= keyCacheTTL {
+		for k, cached := range a.keys {
+			if !now.Before(cached.expires) {
+				delete(a.keys, k)
+			}
+		}
+		a.lastPrune = now
+	}
+	a.keys[key] = cachedKey{apiKey: apiKey, expires: now.Add(keyCacheTTL)}
+}
+
+// take takes a request from the rate limit of client, and sets the RateLimit headers of the response. Returns false,
+// having answered the request with 429, if client is over its limit.
+func (a *Auth) take(w http.ResponseWriter, client string, limit int) bool {
+	result := a.limiter.Take(client, limit)
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit, int(window.Seconds())))
+	w.Header().Set("Access-Control-Expose-Headers", rateLimitHeaders)
+
+	if result.Allowed {
+		return true
+	}
+	retryAfter := seconds(result.RetryAfter)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	deny(w, fmt.Sprintf(errorMsg.RateLimitExceeded, retryAfter), http.StatusTooManyRequests)
+	return false
+}
+
+// deny answers the request with the error message msg and status code, readable from any origin as the responses of
+// the endpoints.
+func deny(w http.ResponseWriter, msg string, code int) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	http.Error(w, msg, code)
+}
+
+// seconds returns d in whole seconds, rounded up.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// requestKey returns the API key of r, from its Authorization header with the Bearer scheme, or else from its
+// X-API-Key header, or else from its global.ApiKeyParam query parameter, which only the endpoints opened by the
+// EventSource and WebSocket of the browsers accept. Returns an empty string if r has no key.
+func requestKey(r *http.Request) string {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	return strings.TrimSpace(r.URL.Query().Get(global.ApiKeyParam))
+}
+
+// clientAddress returns the IP address of the client of r. If global.TrustProxy is set, it is the last address of the
+// X-Forwarded-For header, which is the one added by the proxy in front of the API. The addresses before it are sent by
+// the client, and can be anything.
+func clientAddress(r *http.Request) string {
+	if global.TrustProxy {
+		forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		if last := strings.TrimSpace(forwarded[len(forwarded)-1]); last != "" {
+			return last
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
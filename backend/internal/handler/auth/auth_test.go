@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/utility/mock"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+// ok answers every request with "ok".
+func ok(w http.ResponseWriter, _ *http.Request) {
+	_, _ = io.WriteString(w, "ok")
+}
+
+// serve sends a request with the headers to the handler of a route requiring scope, and returns the response.
+func serve(auth *Auth, scope string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/aircraft/history/ABC123", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	auth.Middleware(scope, ok)(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	liveKey := &models.ApiKeyModel{Name: "dashboard", Scopes: []string{"live"}, RateLimit: 5}
+
+	tests := []struct {
+		name, scope, body string
+		headers           map[string]string
+		anonymousAccess   bool
+		statusCode, limit int
+		wwwAuthenticate   string
+		setup             func(mockSvc *mock.MockRestService)
+	}{
+		{
+			name:            "Anonymous request",
+			scope:           "history",
+			anonymousAccess: true,
+			statusCode:      http.StatusOK,
+			body:            "ok",
+			limit:           global.AnonymousRateLimit,
+		},
+		{
+			name:            "Anonymous request without scope",
+			scope:           "export",
+			anonymousAccess: true,
+			statusCode:      http.StatusForbidden,
+			body:            fmt.Sprintf(errorMsg.MissingScope, "export") + "\n",
+			limit:           global.AnonymousRateLimit,
+		},
+		{
+			name:            "Anonymous request without anonymous access",
+			scope:           "history",
+			statusCode:      http.StatusUnauthorized,
+			body:            errorMsg.ApiKeyRequired + "\n",
+			wwwAuthenticate: "Bearer",
+		},
+		{
+			name:       "Bearer token",
+			scope:      "live",
+			headers:    map[string]string{"Authorization": "Bearer adsb_live"},
+			statusCode: http.StatusOK,
+			body:       "ok",
+			limit:      5,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetApiKey("adsb_live").Return(liveKey, nil)
+			},
+		},
+		{
+			name:       "X-API-Key header",
+			headers:    map[string]string{"X-API-Key": "adsb_default"},
+			statusCode: http.StatusOK,
+			body:       "ok",
+			limit:      global.ApiKeyRateLimit,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetApiKey("adsb_default").Return(&models.ApiKeyModel{Name: "default"}, nil)
+			},
+		},
+		{
+			name:       "Missing scope",
+			scope:      "export",
+			headers:    map[string]string{"Authorization": "Bearer adsb_live"},
+			statusCode: http.StatusForbidden,
+			body:       fmt.Sprintf(errorMsg.MissingScope, "export") + "\n",
+			limit:      5,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetApiKey("adsb_live").Return(liveKey, nil)
+			},
+		},
+		{
+			name:            "Invalid key",
+			headers:         map[string]string{"X-API-Key": "adsb_invalid"},
+			anonymousAccess: true,
+			statusCode:      http.StatusUnauthorized,
+			body:            errorMsg.InvalidApiKey + "\n",
+			limit:           global.AnonymousRateLimit,
+			wwwAuthenticate: `Bearer error="invalid_token"`,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetApiKey("adsb_invalid").Return(nil, nil)
+			},
+		},
+		{
+			name:       "Error retrieving key",
+			headers:    map[string]string{"X-API-Key": "adsb_key"},
+			statusCode: http.StatusInternalServerError,
+			body:       errorMsg.ErrorRetrievingApiKey + "\n",
+			limit:      global.AnonymousRateLimit,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetApiKey("adsb_key").Return(nil, errors.New("error"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			global.AnonymousAccess = tt.anonymousAccess
+			defer func() { global.AnonymousAccess = true }()
+
+			mockSvc := mock.NewMockRestService(ctrl)
+			if tt.setup != nil {
+				tt.setup(mockSvc)
+			}
+
+			w := serve(New(mockSvc), tt.scope, tt.headers)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+			assert.Equal(t, tt.wwwAuthenticate, w.Header().Get("WWW-Authenticate"))
+			if tt.limit != 0 {
+				assert.Equal(t, fmt.Sprint(tt.limit), w.Header().Get("RateLimit-Limit"))
+				assert.Equal(t, fmt.Sprint(tt.limit-1), w.Header().Get("RateLimit-Remaining"))
+				assert.Equal(t, fmt.Sprintf("%d;w=60", tt.limit), w.Header().Get("RateLimit-Policy"))
+			} else {
+				assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+			}
+		})
+	}
+}
+
+func TestMiddleware_RateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	mockSvc.EXPECT().GetApiKey("adsb_key").Return(&models.ApiKeyModel{Name: "key", RateLimit: 2}, nil)
+
+	auth := New(mockSvc)
+	key := map[string]string{"Authorization": "Bearer adsb_key"}
+
+	assert.Equal(t, http.StatusOK, serve(auth, "", key).Code)
+	assert.Equal(t, http.StatusOK, serve(auth, "", key).Code)
+
+	w := serve(auth, "", key)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, fmt.Sprintf(errorMsg.RateLimitExceeded, 30)+"\n", w.Body.String())
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	// the requests without a key are limited by their address, as are the requests with invalid keys, which are not
+	// looked up once the address is over its limit
+	global.AnonymousRateLimit = 1
+	defer func() { global.AnonymousRateLimit = 60 }()
+
+	assert.Equal(t, http.StatusOK, serve(auth, "", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(auth, "", map[string]string{"X-API-Key": "adsb_invalid"}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(auth, "", nil).Code)
+}
+
+func TestMiddleware_KeyCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	gomock.InOrder(
+		mockSvc.EXPECT().GetApiKey("adsb_key").Return(&models.ApiKeyModel{Name: "key"}, nil),
+		mockSvc.EXPECT().GetApiKey("adsb_key").Return(nil, nil),
+	)
+	mockSvc.EXPECT().GetApiKey("adsb_invalid").Return(nil, nil)
+
+	clock := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	auth := New(mockSvc)
+	auth.now = func() time.Time { return clock }
+	key := map[string]string{"Authorization": "Bearer adsb_key"}
+	invalid := map[string]string{"X-API-Key": "adsb_invalid"}
+
+	assert.Equal(t, http.StatusOK, serve(auth, "", key).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(auth, "", invalid).Code)
+	clock = clock.Add(keyCacheTTL - time.Second)
+	assert.Equal(t, http.StatusOK, serve(auth, "", key).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(auth, "", invalid).Code)
+
+	// the key has been revoked, which is seen once it has expired
+	clock = clock.Add(time.Second)
+	assert.Equal(t, http.StatusUnauthorized, serve(auth, "", key).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(auth, "", key).Code)
+	// the invalid key has expired, and is no longer cached
+	assert.Len(t, auth.keys, 1)
+}
+
+func TestClientAddress(t *testing.T) {
+	tests := []struct {
+		name, address string
+		forwardedFor  []string
+		trustProxy    bool
+	}{
+		{name: "Remote address", address: "192.0.2.1"},
+		{name: "Untrusted proxy", forwardedFor: []string{"198.51.100.7"}, address: "192.0.2.1"},
+		{name: "Trusted proxy", forwardedFor: []string{"203.0.113.2"}, trustProxy: true, address: "203.0.113.2"},
+		{
+			// the first address is sent by the client, the last is added by the proxy
+			name:         "Trusted proxy with an address from the client",
+			forwardedFor: []string{"198.51.100.7, 203.0.113.2"},
+			trustProxy:   true,
+			address:      "203.0.113.2",
+		},
+		{
+			name:         "Trusted proxy adding a header",
+			forwardedFor: []string{"198.51.100.7", "203.0.113.2"},
+			trustProxy:   true,
+			address:      "203.0.113.2",
+		},
+		{name: "Trusted proxy without header", trustProxy: true, address: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			global.TrustProxy = tt.trustProxy
+			defer func() { global.TrustProxy = false }()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			for _, forwardedFor := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", forwardedFor)
+			}
+
+			assert.Equal(t, tt.address, clientAddress(r))
+		})
+	}
+}
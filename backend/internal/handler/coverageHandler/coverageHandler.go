@@ -0,0 +1,110 @@
+package coverageHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/service/restService"
+	"adsb-api/internal/utility/apiUtility"
+	"adsb-api/internal/utility/convert"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var optionalParams = []string{"from", "to", "compareFrom", "compareTo"}
+
+// CoverageHandler handles HTTP requests for /coverage?from=&to=&compareFrom=&compareTo= endpoint.
+func CoverageHandler(svc restService.RestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := apiUtility.ValidateURL(w, r, optionalParams)
+		if err != nil {
+			return
+		}
+		handleCoverageGetRequest(w, r, svc)
+	}
+}
+
+// handleCoverageGetRequest handles GET requests for the /coverage?from=&to=&compareFrom=&compareTo= endpoint.
+// Sends the coverage of the receiver as a polygon for every altitude band, over the days from the from parameter up
+// to the to parameter, every day by default. If the compareFrom or compareTo parameter is given, the coverage over
+// those days is sent as well, to compare the two windows.
+func handleCoverageGetRequest(w http.ResponseWriter, r *http.Request, svc restService.RestService) {
+	filter, err := parseFilter(r.URL.Query(), "from", "to")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	windows := map[string]models.CoverageFilter{"window": filter}
+	if r.URL.Query().Has("compareFrom") || r.URL.Query().Has("compareTo") {
+		windows["compare"], err = parseFilter(r.URL.Query(), "compareFrom", "compareTo")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	featureCollection := geoJSON.FeatureCollectionPolygon{Type: "FeatureCollection"}
+	for _, name := range []string{"window", "compare"} {
+		filter, found := windows[name]
+		if !found {
+			continue
+		}
+
+		coverage, err := svc.GetCoverage(filter)
+		if err != nil {
+			http.Error(w, errorMsg.ErrorRetrievingCoverage, http.StatusInternalServerError)
+			log.Error().Msgf(errorMsg.ErrorRetrievingCoverage+": %q Path: %q", err, r.URL)
+			return
+		}
+
+		window := geoJSON.CoverageWindow{Window: name}
+		if !filter.From.IsZero() {
+			window.From = filter.From.Format(time.DateOnly)
+		}
+		if !filter.To.IsZero() {
+			window.To = filter.To.Format(time.DateOnly)
+		}
+		featureCollection.Features = append(featureCollection.Features,
+			convert.CoverageToGeoJson(coverage, global.CoverageAltitudeBand, window)...)
+	}
+
+	if len(featureCollection.Features) == 0 {
+		apiUtility.NoContent(w)
+		return
+	}
+
+	err = apiUtility.EncodeData(w, r, featureCollection)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
+	}
+}
+
+// parseFilter parses the from and to query parameters, given by their names, to a CoverageFilter.
+// Returns an error if a parameter is not a date, or if to is not after from.
+func parseFilter(query url.Values, from string, to string) (models.CoverageFilter, error) {
+	var filter models.CoverageFilter
+	var err error
+
+	if query.Has(from) {
+		filter.From, err = time.Parse(time.DateOnly, query.Get(from))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterDay)
+		}
+	}
+
+	if query.Has(to) {
+		filter.To, err = time.Parse(time.DateOnly, query.Get(to))
+		if err != nil || !filter.To.After(filter.From) {
+			return filter, errors.New(errorMsg.InvalidQueryParameterDay)
+		}
+	}
+
+	return filter, nil
+}
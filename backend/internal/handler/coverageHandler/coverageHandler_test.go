@@ -0,0 +1,210 @@
+package coverageHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/handler/router"
+	"adsb-api/internal/utility/mock"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+var coverage = []models.CoverageModel{
+	{Band: 10000, Sector: 0, Range: 150, Latitude: 61.7, Longitude: 5.3},
+	{Band: 10000, Sector: 12, Range: 210, Latitude: 58.5, Longitude: 6.1},
+	{Band: 10000, Sector: 27, Range: 120, Latitude: 60.4, Longitude: 3.1},
+}
+
+func TestInvalidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	coverageEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.CoveragePath, CoverageHandler(mockSvc)),
+	}))
+	defer coverageEndpoint.Close()
+
+	var endpoint = coverageEndpoint.URL + global.CoveragePath
+
+	tests := []struct {
+		name, url, httpMethod, errorMsg string
+		statusCode                      int
+		setup                           func(mockSvc *mock.MockRestService)
+	}{
+		{
+			name:       "Post request",
+			url:        endpoint,
+			httpMethod: http.MethodPost,
+			statusCode: http.StatusMethodNotAllowed,
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
+		},
+		{
+			name:       "Get request with unknown path",
+			url:        endpoint + "/10000",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
+		},
+		{
+			name:       "Get request with invalid parameter",
+			url:        endpoint + "?band=10000",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": from, to, compareFrom, compareTo",
+		},
+		{
+			name:       "Get request with timestamp instead of date",
+			url:        endpoint + "?from=2024-04-01T00:00:00Z",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterDay,
+		},
+		{
+			name:       "Get request with compareTo before compareFrom",
+			url:        endpoint + "?compareFrom=2024-04-08&compareTo=2024-04-01",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterDay,
+		},
+		{
+			name:       "Database returns error",
+			url:        endpoint,
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusInternalServerError,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetCoverage(models.CoverageFilter{}).Return(nil, errors.New("connection refused"))
+			},
+			errorMsg: errorMsg.ErrorRetrievingCoverage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(mockSvc)
+			}
+
+			req, err := http.NewRequest(tt.httpMethod, tt.url, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing %s request: %s", tt.name, tt.httpMethod, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Errorf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+			assert.Equal(t, tt.errorMsg+"\n", string(body))
+		})
+	}
+}
+
+func TestValidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	coverageEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.CoveragePath, CoverageHandler(mockSvc)),
+	}))
+	defer coverageEndpoint.Close()
+
+	var endpoint = coverageEndpoint.URL + global.CoveragePath
+
+	april := models.CoverageFilter{
+		From: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	march := models.CoverageFilter{From: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name, url  string
+		statusCode int
+		setup      func(mockSvc *mock.MockRestService)
+		windows    []geoJSON.CoverageWindow
+	}{
+		{
+			name:       "All coverage",
+			url:        endpoint,
+			statusCode: http.StatusOK,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetCoverage(models.CoverageFilter{}).Return(coverage, nil)
+			},
+			windows: []geoJSON.CoverageWindow{{Window: "window"}},
+		},
+		{
+			name:       "Compare windows",
+			url:        endpoint + "?from=2024-04-01&to=2024-05-01&compareFrom=2024-03-01",
+			statusCode: http.StatusOK,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetCoverage(april).Return(coverage, nil)
+				mockSvc.EXPECT().GetCoverage(march).Return(coverage, nil)
+			},
+			windows: []geoJSON.CoverageWindow{
+				{Window: "window", From: "2024-04-01", To: "2024-05-01"},
+				{Window: "compare", From: "2024-03-01"},
+			},
+		},
+		{
+			name:       "No coverage",
+			url:        endpoint + "?from=2024-04-01",
+			statusCode: http.StatusNoContent,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetCoverage(models.CoverageFilter{From: april.From}).Return(nil, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mockSvc)
+
+			res, err := http.Get(tt.url)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			if tt.windows == nil {
+				return
+			}
+
+			var actual geoJSON.FeatureCollectionPolygon
+			err = json.NewDecoder(res.Body).Decode(&actual)
+			if err != nil {
+				t.Errorf("Test: %s. Error decoding response body: %s", tt.name, err.Error())
+			}
+
+			assert.Len(t, actual.Features, len(tt.windows))
+			for i, feature := range actual.Features {
+				assert.Equal(t, tt.windows[i], feature.Properties.CoverageWindow)
+				assert.Equal(t, 10000, feature.Properties.MinAltitude)
+				assert.Equal(t, 20000, feature.Properties.MaxAltitude)
+				assert.Equal(t, float64(210), feature.Properties.MaxRange)
+			}
+		})
+	}
+}
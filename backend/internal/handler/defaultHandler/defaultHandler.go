@@ -17,27 +17,31 @@ type DefaultStruct struct {
 
 // DefaultHandler function, which prints info about the service
 func DefaultHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		var endpoints []string
-		endpoints = append(endpoints, global.AircraftCurrentPath)
-		endpoints = append(endpoints, global.AircraftHistoryPath)
+	var endpoints []string
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.AircraftCurrentPath)
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.AircraftHistoryPath)
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.AircraftRegistryPath)
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.AircraftStreamPath)
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.AircraftLivePath)
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.AircraftSearchPath)
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.StatsPath)
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.CoveragePath)
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.HeatmapPath)
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.ExportHistoryPath)
+	endpoints = append(endpoints, global.ApiVersionPrefix+global.OpenApiPath)
 
-		madeBy := []string{"Andreas Follevaag Malde", "Fredrik Sundt-Hansen"}
+	madeBy := []string{"Andreas Follevaag Malde", "Fredrik Sundt-Hansen"}
 
-		out := DefaultStruct{
-			Name:      "ADS-B Reception, Processing, Displaying and Analysis",
-			Version:   global.VERSION,
-			MadeBy:    madeBy,
-			Endpoints: endpoints,
-		}
+	out := DefaultStruct{
+		Name:      "ADS-B Reception, Processing, Displaying and Analysis",
+		Version:   global.VERSION,
+		MadeBy:    madeBy,
+		Endpoints: endpoints,
+	}
 
-		err := apiUtility.EncodeJsonData(w, out)
-		if err != nil {
-			http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
-			log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
-		}
-	default:
-		http.Error(w, "Method is not supported", http.StatusMethodNotAllowed)
+	err := apiUtility.EncodeData(w, r, out)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
 	}
 }
@@ -0,0 +1,133 @@
+package exportHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/service/restService"
+	"adsb-api/internal/utility/apiUtility"
+	"adsb-api/internal/utility/convert"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var optionalParams = []string{"from", "to", "bbox", "format"}
+
+// filenameTimeFormat is the format of the time range in the filename of an export
+const filenameTimeFormat = "20060102T150405Z"
+
+// exportFormats are the content types of each format of /export/history
+var exportFormats = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"czml":   "application/json",
+}
+
+// ExportHistoryHandler handles HTTP requests for /export/history?from=&to=&bbox=&format= endpoint.
+func ExportHistoryHandler(svc restService.RestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := apiUtility.ValidateURL(w, r, optionalParams)
+		if err != nil {
+			return
+		}
+		handleExportHistoryGetRequest(w, r, svc)
+	}
+}
+
+// handleExportHistoryGetRequest handles GET requests for the /export/history?from=&to=&bbox=&format= endpoint.
+// Streams every history position within the time range given by the from and to parameters, and the bounding box given
+// by the bbox parameter, as a CSV file, an NDJSON file with format=ndjson, or a CZML document with a packet for every
+// aircraft with format=czml. The rows are written as they are read from the database, so the export is never held in
+// memory, and are gzip compressed if the client accepts it.
+func handleExportHistoryGetRequest(w http.ResponseWriter, r *http.Request, svc restService.RestService) {
+	filter, err := parseFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "csv"
+	}
+	contentType, found := exportFormats[format]
+	if !found {
+		http.Error(w, errorMsg.InvalidQueryParameterExport, http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("history_%s_%s.%s",
+		filter.From.UTC().Format(filenameTimeFormat), filter.To.UTC().Format(filenameTimeFormat), format)
+	fw := apiUtility.NewFileStreamWriter(w, r, contentType, filename)
+
+	var stream convert.HistoryExportStream
+	switch format {
+	case "csv":
+		stream = convert.NewHistoryCsvStream(fw)
+	case "ndjson":
+		stream = convert.NewHistoryNdjsonStream(fw)
+	case "czml":
+		// the packet of an aircraft is written once all of its positions are read
+		filter.ByAircraft = true
+		stream = convert.NewHistoryCzmlStream(fw, strings.TrimSuffix(filename, ".czml"), filter.From, filter.To)
+	}
+
+	err = svc.StreamHistoryExport(filter, stream.Write)
+	if err != nil && !fw.Started() {
+		http.Error(w, errorMsg.ErrorRetrievingExport, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorRetrievingExport+": %q Path: %q", err, r.URL)
+		return
+	} else if err != nil {
+		log.Error().Msgf(errorMsg.ErrorStreamingExport+": %q Path: %q", err, r.URL)
+		return
+	}
+
+	if stream.Rows() == 0 {
+		apiUtility.NoContent(w)
+		return
+	}
+
+	err = stream.Close()
+	if err == nil {
+		err = fw.Close()
+	}
+	if err != nil {
+		log.Error().Msgf(errorMsg.ErrorStreamingExport+": %q Path: %q", err, r.URL)
+	}
+}
+
+// parseFilter parses the from, to and bbox query parameters to a HistoryExportFilter. from and to are required, and can
+// be at most global.ExportMaxDays days apart. Returns an error with the message for the first invalid parameter.
+func parseFilter(query url.Values) (models.HistoryExportFilter, error) {
+	var filter models.HistoryExportFilter
+	var err error
+
+	filter.From, err = time.Parse(time.RFC3339, query.Get("from"))
+	if err != nil {
+		return filter, errors.New(errorMsg.InvalidQueryParameterFrom)
+	}
+
+	filter.To, err = time.Parse(time.RFC3339, query.Get("to"))
+	if err != nil || !filter.To.After(filter.From) {
+		return filter, errors.New(errorMsg.InvalidQueryParameterTo)
+	}
+
+	if filter.To.Sub(filter.From) > time.Duration(global.ExportMaxDays)*24*time.Hour {
+		return filter, fmt.Errorf(errorMsg.InvalidQueryParameterMaxRange, global.ExportMaxDays)
+	}
+
+	if query.Has("bbox") {
+		filter.Bbox, err = apiUtility.ParseBbox(query.Get("bbox"))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterBbox)
+		}
+	}
+
+	return filter, nil
+}
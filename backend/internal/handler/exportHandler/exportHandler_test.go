@@ -0,0 +1,287 @@
+package exportHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/handler/router"
+	"adsb-api/internal/utility/mock"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+var (
+	rangeQuery = "?from=2024-04-11T00:00:00Z&to=2024-04-12T00:00:00Z"
+	filter     = models.HistoryExportFilter{
+		From: time.Date(2024, 4, 11, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 4, 12, 0, 0, 0, 0, time.UTC),
+	}
+	altitude = 35000
+	history  = []models.AircraftHistoryModel{
+		{Icao: "4CA2D1", Latitude: 60.5, Longitude: 5.25, Timestamp: "2024-04-11T10:00:00Z", Altitude: &altitude},
+		{Icao: "484506", Latitude: 59.1, Longitude: 4.75, Timestamp: "2024-04-11T10:00:05Z"},
+	}
+)
+
+// streamHistory returns a function streaming history to the handle of StreamHistoryExport, and then returning err.
+func streamHistory(history []models.AircraftHistoryModel, err error) func(models.HistoryExportFilter,
+	func(models.AircraftHistoryModel) error) error {
+	return func(filter models.HistoryExportFilter, handle func(models.AircraftHistoryModel) error) error {
+		for _, ac := range history {
+			if handleErr := handle(ac); handleErr != nil {
+				return handleErr
+			}
+		}
+		return err
+	}
+}
+
+func TestInvalidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	exportEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.ExportHistoryPath, ExportHistoryHandler(mockSvc)),
+	}))
+	defer exportEndpoint.Close()
+
+	var endpoint = exportEndpoint.URL + global.ExportHistoryPath
+
+	tests := []struct {
+		name, url, httpMethod, errorMsg string
+		statusCode                      int
+		setup                           func(mockSvc *mock.MockRestService)
+	}{
+		{
+			name:       "Post request",
+			url:        endpoint + rangeQuery,
+			httpMethod: http.MethodPost,
+			statusCode: http.StatusMethodNotAllowed,
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
+		},
+		{
+			name:       "Get request with unknown path",
+			url:        endpoint + "/4CA2D1",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
+		},
+		{
+			name:       "Get request with invalid parameter",
+			url:        endpoint + rangeQuery + "&icao=4CA2D1",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": from, to, bbox, format",
+		},
+		{
+			name:       "Get request without from",
+			url:        endpoint + "?to=2024-04-12T00:00:00Z",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterFrom,
+		},
+		{
+			name:       "Get request without to",
+			url:        endpoint + "?from=2024-04-11T00:00:00Z",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterTo,
+		},
+		{
+			name:       "Get request with too long time range",
+			url:        endpoint + "?from=2024-04-01T00:00:00Z&to=2024-04-12T00:00:00Z",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   fmt.Sprintf(errorMsg.InvalidQueryParameterMaxRange, global.ExportMaxDays),
+		},
+		{
+			name:       "Get request with invalid bbox",
+			url:        endpoint + rangeQuery + "&bbox=4,58,6",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterBbox,
+		},
+		{
+			name:       "Get request with invalid format",
+			url:        endpoint + rangeQuery + "&format=xlsx",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterExport,
+		},
+		{
+			name:       "Database returns error",
+			url:        endpoint + rangeQuery,
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusInternalServerError,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().StreamHistoryExport(filter, gomock.Any()).
+					DoAndReturn(streamHistory(history, errors.New("connection refused")))
+			},
+			errorMsg: errorMsg.ErrorRetrievingExport,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(mockSvc)
+			}
+
+			req, err := http.NewRequest(tt.httpMethod, tt.url, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing %s request: %s", tt.name, tt.httpMethod, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Errorf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+			assert.Equal(t, tt.errorMsg+"\n", string(body))
+		})
+	}
+}
+
+func TestValidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	exportEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.ExportHistoryPath, ExportHistoryHandler(mockSvc)),
+	}))
+	defer exportEndpoint.Close()
+
+	var endpoint = exportEndpoint.URL + global.ExportHistoryPath
+
+	bboxFilter := filter
+	bboxFilter.Bbox = &models.BoundingBox{MinLon: 4, MinLat: 58, MaxLon: 7, MaxLat: 62}
+	aircraftFilter := filter
+	aircraftFilter.ByAircraft = true
+
+	// czmlPosition and czmlStyle are the parts of an aircraft packet around its samples
+	czmlPosition := `"interpolationAlgorithm":"LAGRANGE","interpolationDegree":1,` +
+		`"forwardExtrapolationType":"NONE","backwardExtrapolationType":"NONE"}`
+	czmlStyle := `"point":{"pixelSize":8,"color":{"rgba":[255,200,0,255]}},"path":{"width":2,"leadTime":0,` +
+		`"trailTime":600,"resolution":5,"material":{"solidColor":{"color":{"rgba":[255,200,0,255]}}}}}`
+
+	tests := []struct {
+		name, url, contentType, filename, body string
+		gzip                                   bool
+		setup                                  func(mockSvc *mock.MockRestService)
+	}{
+		{
+			name:        "CSV",
+			url:         endpoint + rangeQuery,
+			contentType: "text/csv; charset=utf-8",
+			filename:    "history_20240411T000000Z_20240412T000000Z.csv",
+			body: "icao,timestamp,latitude,longitude,altitude\n" +
+				"4CA2D1,2024-04-11T10:00:00Z,60.5,5.25,35000\n" +
+				"484506,2024-04-11T10:00:05Z,59.1,4.75,\n",
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().StreamHistoryExport(filter, gomock.Any()).DoAndReturn(streamHistory(history, nil))
+			},
+		},
+		{
+			name:        "Gzip compressed NDJSON within a bounding box",
+			url:         endpoint + rangeQuery + "&bbox=4,58,7,62&format=NDJSON",
+			contentType: "application/x-ndjson",
+			filename:    "history_20240411T000000Z_20240412T000000Z.ndjson",
+			body: `{"icao":"4CA2D1","latitude":60.5,"longitude":5.25,"timestamp":"2024-04-11T10:00:00Z","altitude":35000}` + "\n" +
+				`{"icao":"484506","latitude":59.1,"longitude":4.75,"timestamp":"2024-04-11T10:00:05Z"}` + "\n",
+			gzip: true,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().StreamHistoryExport(bboxFilter, gomock.Any()).DoAndReturn(streamHistory(history, nil))
+			},
+		},
+		{
+			name:        "CZML ordered by aircraft",
+			url:         endpoint + rangeQuery + "&format=czml",
+			contentType: "application/json",
+			filename:    "history_20240411T000000Z_20240412T000000Z.czml",
+			body: `[{"id":"document","name":"history_20240411T000000Z_20240412T000000Z","version":"1.0",` +
+				`"clock":{"interval":"2024-04-11T00:00:00Z/2024-04-12T00:00:00Z","currentTime":"2024-04-11T00:00:00Z",` +
+				`"multiplier":60,"range":"LOOP_STOP","step":"SYSTEM_CLOCK_MULTIPLIER"}},` +
+				`{"id":"4CA2D1","name":"4CA2D1","availability":"2024-04-11T10:00:00Z/2024-04-11T10:00:00Z",` +
+				`"position":{"epoch":"2024-04-11T10:00:00Z","cartographicDegrees":[0,5.25,60.5,10668],` + czmlPosition +
+				`,` + czmlStyle + `,` +
+				`{"id":"484506","name":"484506","availability":"2024-04-11T10:00:05Z/2024-04-11T10:00:05Z",` +
+				`"position":{"epoch":"2024-04-11T10:00:05Z","cartographicDegrees":[0,4.75,59.1,0],` + czmlPosition +
+				`,` + czmlStyle + "]\n",
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().StreamHistoryExport(aircraftFilter, gomock.Any()).DoAndReturn(streamHistory(history, nil))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mockSvc)
+
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			if tt.gzip {
+				// setting the header turns off the transparent decompression of the client
+				req.Header.Set("Accept-Encoding", "gzip")
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, tt.contentType, res.Header.Get("Content-Type"))
+			assert.Equal(t, "attachment; filename="+tt.filename, res.Header.Get("Content-Disposition"))
+
+			var reader io.Reader = res.Body
+			if tt.gzip {
+				assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+				reader, err = gzip.NewReader(res.Body)
+				if err != nil {
+					t.Fatalf("Test: %s. Error reading gzip response: %s", tt.name, err.Error())
+				}
+			}
+			body, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+
+	t.Run("No history", func(t *testing.T) {
+		mockSvc.EXPECT().StreamHistoryExport(filter, gomock.Any()).DoAndReturn(streamHistory(nil, nil))
+
+		res, err := http.Get(endpoint + rangeQuery)
+		if err != nil {
+			t.Fatalf("Error executing request: %s", err.Error())
+		}
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
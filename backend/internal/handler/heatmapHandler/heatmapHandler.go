@@ -0,0 +1,158 @@
+package heatmapHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/service/restService"
+	"adsb-api/internal/utility/apiUtility"
+	"adsb-api/internal/utility/convert"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var optionalParams = []string{"bbox", "from", "to", "cell", "format"}
+
+// defaultCell is the size in degrees of each cell of /heatmap without the cell query parameter
+const defaultCell = 1.0
+
+// heatmapArray is the compact array format of the heatmap, where each cell is its minimum longitude, minimum latitude
+// and count.
+type heatmapArray struct {
+	Cell  float64      `json:"cell"`
+	From  string       `json:"from"`
+	To    string       `json:"to"`
+	Cells [][3]float64 `json:"cells"`
+}
+
+// HeatmapHandler handles HTTP requests for /heatmap?bbox=&from=&to=&cell=&format= endpoint.
+func HeatmapHandler(svc restService.RestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := apiUtility.ValidateURL(w, r, optionalParams)
+		if err != nil {
+			return
+		}
+		handleHeatmapGetRequest(w, r, svc)
+	}
+}
+
+// handleHeatmapGetRequest handles GET requests for the /heatmap?bbox=&from=&to=&cell=&format= endpoint.
+// Sends the number of history positions in every cell of a grid of cell degrees, defaultCell by default, within the
+// bounding box and time range given by the bbox, from and to parameters. The time range is the last
+// global.HeatmapDefaultHours hours by default. Cells are sent as GeoJSON polygons, or as a compact array with
+// format=array.
+func handleHeatmapGetRequest(w http.ResponseWriter, r *http.Request, svc restService.RestService) {
+	filter, err := parseFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format != "" && format != "geojson" && format != "array" {
+		http.Error(w, errorMsg.InvalidQueryParameterFormat, http.StatusBadRequest)
+		return
+	}
+
+	cells, err := svc.GetHeatmap(filter)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorRetrievingHeatmap, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorRetrievingHeatmap+": %q Path: %q", err, r.URL)
+		return
+	}
+	if len(cells) == 0 {
+		apiUtility.NoContent(w)
+		return
+	}
+
+	var res interface{}
+	if format == "array" {
+		res = heatmapArray{
+			Cell:  filter.Cell,
+			From:  filter.From.UTC().Format(time.RFC3339),
+			To:    filter.To.UTC().Format(time.RFC3339),
+			Cells: convert.HeatmapToArray(cells, filter.Cell),
+		}
+	} else {
+		res = convert.HeatmapToGeoJson(cells, filter.Cell)
+	}
+
+	err = apiUtility.EncodeData(w, r, res)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
+	}
+}
+
+// parseFilter parses the bbox, from, to and cell query parameters to a HeatmapFilter. to defaults to now, and from to
+// global.HeatmapDefaultHours hours before to. Returns an error with the message for the first invalid parameter, if the
+// time range is longer than global.HeatmapMaxDays days, or if the grid has more than global.HeatmapMaxCells cells.
+func parseFilter(query url.Values) (models.HeatmapFilter, error) {
+	filter := models.HeatmapFilter{To: time.Now().UTC(), Cell: defaultCell}
+	var err error
+
+	if query.Has("bbox") {
+		filter.Bbox, err = apiUtility.ParseBbox(query.Get("bbox"))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterBbox)
+		}
+	}
+
+	if query.Has("to") {
+		filter.To, err = time.Parse(time.RFC3339, query.Get("to"))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterTo)
+		}
+	}
+
+	if query.Has("from") {
+		filter.From, err = time.Parse(time.RFC3339, query.Get("from"))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterFrom)
+		}
+	} else {
+		filter.From = filter.To.Add(-time.Duration(global.HeatmapDefaultHours) * time.Hour)
+	}
+
+	if !filter.To.After(filter.From) {
+		return filter, errors.New(errorMsg.InvalidQueryParameterTo)
+	}
+	if filter.To.Sub(filter.From) > time.Duration(global.HeatmapMaxDays)*24*time.Hour {
+		return filter, fmt.Errorf(errorMsg.InvalidQueryParameterMaxRange, global.HeatmapMaxDays)
+	}
+
+	if query.Has("cell") {
+		filter.Cell, err = strconv.ParseFloat(query.Get("cell"), 64)
+		if err != nil || filter.Cell <= 0 || math.IsNaN(filter.Cell) || math.IsInf(filter.Cell, 0) {
+			return filter, errors.New(errorMsg.InvalidQueryParameterCell)
+		}
+	}
+
+	if gridCells(filter) > float64(global.HeatmapMaxCells) {
+		return filter, fmt.Errorf(errorMsg.InvalidQueryParameterCellCount, global.HeatmapMaxCells)
+	}
+
+	return filter, nil
+}
+
+// gridCells returns the number of cells of the grid of filter over its bounding box, or the whole world without one.
+func gridCells(filter models.HeatmapFilter) float64 {
+	width, height := 360.0, 180.0
+	if filter.Bbox != nil {
+		width = filter.Bbox.MaxLon - filter.Bbox.MinLon
+		if width < 0 {
+			// the bounding box crosses the antimeridian
+			width += 360
+		}
+		height = filter.Bbox.MaxLat - filter.Bbox.MinLat
+	}
+	return math.Max(math.Ceil(width/filter.Cell), 1) * math.Max(math.Ceil(height/filter.Cell), 1)
+}
@@ -0,0 +1,249 @@
+package heatmapHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/handler/router"
+	"adsb-api/internal/utility/mock"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+var (
+	rangeQuery = "?from=2024-04-11T00:00:00Z&to=2024-04-12T00:00:00Z"
+	filter     = models.HeatmapFilter{
+		From: time.Date(2024, 4, 11, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 4, 12, 0, 0, 0, 0, time.UTC),
+		Cell: defaultCell,
+	}
+	cells = []models.HeatmapCellModel{{X: 52, Y: 603, Count: 120}, {X: 53, Y: 603, Count: 45}}
+)
+
+func TestInvalidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	heatmapEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.HeatmapPath, HeatmapHandler(mockSvc)),
+	}))
+	defer heatmapEndpoint.Close()
+
+	var endpoint = heatmapEndpoint.URL + global.HeatmapPath
+
+	tests := []struct {
+		name, url, httpMethod, errorMsg string
+		statusCode                      int
+		setup                           func(mockSvc *mock.MockRestService)
+	}{
+		{
+			name:       "Post request",
+			url:        endpoint,
+			httpMethod: http.MethodPost,
+			statusCode: http.StatusMethodNotAllowed,
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
+		},
+		{
+			name:       "Get request with unknown path",
+			url:        endpoint + "/cells",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
+		},
+		{
+			name:       "Get request with invalid parameter",
+			url:        endpoint + "?limit=5",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": bbox, from, to, cell, format",
+		},
+		{
+			name:       "Get request with invalid bbox",
+			url:        endpoint + "?bbox=4,58,6",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterBbox,
+		},
+		{
+			// a NaN bbox would make every cell count check pass
+			name:       "Get request with NaN bbox",
+			url:        endpoint + "?bbox=NaN,NaN,NaN,NaN&cell=0.0001",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterBbox,
+		},
+		{
+			name:       "Get request with invalid from",
+			url:        endpoint + "?from=yesterday",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterFrom,
+		},
+		{
+			name:       "Get request with to before from",
+			url:        endpoint + "?from=2024-04-12T00:00:00Z&to=2024-04-11T00:00:00Z",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterTo,
+		},
+		{
+			name:       "Get request with too long time range",
+			url:        endpoint + "?from=2024-04-01T00:00:00Z&to=2024-04-12T00:00:00Z",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   fmt.Sprintf(errorMsg.InvalidQueryParameterMaxRange, global.HeatmapMaxDays),
+		},
+		{
+			name:       "Get request with invalid cell",
+			url:        endpoint + "?cell=-0.5",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterCell,
+		},
+		{
+			name:       "Get request with too many cells",
+			url:        endpoint + "?cell=0.01",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   fmt.Sprintf(errorMsg.InvalidQueryParameterCellCount, global.HeatmapMaxCells),
+		},
+		{
+			name:       "Get request with invalid format",
+			url:        endpoint + "?format=png",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterFormat,
+		},
+		{
+			name:       "Database returns error",
+			url:        endpoint + rangeQuery,
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusInternalServerError,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetHeatmap(filter).Return(nil, errors.New("connection refused"))
+			},
+			errorMsg: errorMsg.ErrorRetrievingHeatmap,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(mockSvc)
+			}
+
+			req, err := http.NewRequest(tt.httpMethod, tt.url, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing %s request: %s", tt.name, tt.httpMethod, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Errorf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+			assert.Equal(t, tt.errorMsg+"\n", string(body))
+		})
+	}
+}
+
+func TestValidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	heatmapEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.HeatmapPath, HeatmapHandler(mockSvc)),
+	}))
+	defer heatmapEndpoint.Close()
+
+	var endpoint = heatmapEndpoint.URL + global.HeatmapPath
+
+	t.Run("GeoJSON cells within a bounding box", func(t *testing.T) {
+		bboxFilter := filter
+		bboxFilter.Bbox = &models.BoundingBox{MinLon: 4, MinLat: 58, MaxLon: 7, MaxLat: 62}
+		bboxFilter.Cell = 0.1
+		mockSvc.EXPECT().GetHeatmap(bboxFilter).Return(cells, nil)
+
+		res, err := http.Get(endpoint + rangeQuery + "&bbox=4,58,7,62&cell=0.1")
+		if err != nil {
+			t.Fatalf("Error executing request: %s", err.Error())
+		}
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var actual geoJSON.FeatureCollectionHeatmap
+		err = json.NewDecoder(res.Body).Decode(&actual)
+		if err != nil {
+			t.Fatalf("Error decoding response body: %s", err.Error())
+		}
+		assert.Len(t, actual.Features, 2)
+		assert.Equal(t, 120, actual.Features[0].Properties.Count)
+		assert.Equal(t, []float32{5.2, 60.3}, actual.Features[0].Geometry.Coordinates[0][0])
+	})
+
+	t.Run("Array cells", func(t *testing.T) {
+		mockSvc.EXPECT().GetHeatmap(filter).Return(cells, nil)
+
+		res, err := http.Get(endpoint + rangeQuery + "&format=array")
+		if err != nil {
+			t.Fatalf("Error executing request: %s", err.Error())
+		}
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var actual heatmapArray
+		err = json.NewDecoder(res.Body).Decode(&actual)
+		if err != nil {
+			t.Fatalf("Error decoding response body: %s", err.Error())
+		}
+		assert.Equal(t, heatmapArray{
+			Cell:  defaultCell,
+			From:  "2024-04-11T00:00:00Z",
+			To:    "2024-04-12T00:00:00Z",
+			Cells: [][3]float64{{52, 603, 120}, {53, 603, 45}},
+		}, actual)
+	})
+
+	t.Run("Default time range without positions", func(t *testing.T) {
+		mockSvc.EXPECT().GetHeatmap(gomock.Any()).DoAndReturn(
+			func(filter models.HeatmapFilter) ([]models.HeatmapCellModel, error) {
+				assert.Equal(t, filter.To.Add(-time.Duration(global.HeatmapDefaultHours)*time.Hour), filter.From)
+				assert.WithinDuration(t, time.Now(), filter.To, time.Minute)
+				assert.Nil(t, filter.Bbox)
+				return nil, nil
+			})
+
+		res, err := http.Get(endpoint)
+		if err != nil {
+			t.Fatalf("Error executing request: %s", err.Error())
+		}
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
@@ -0,0 +1,83 @@
+package openapiHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/utility/apiUtility"
+	"adsb-api/resources"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// document is the OpenAPI document, built on the first request
+	document    map[string]interface{}
+	documentErr error
+	once        sync.Once
+)
+
+// OpenApiHandler handles HTTP requests for /openapi.json endpoint.
+func OpenApiHandler(w http.ResponseWriter, r *http.Request) {
+	err := apiUtility.ValidateURL(w, r, []string{})
+	if err != nil {
+		return
+	}
+	handleOpenApiGetRequest(w, r)
+}
+
+// handleOpenApiGetRequest handles GET requests for the /openapi.json endpoint.
+// Sends the OpenAPI document of every endpoint of the API, as JSON regardless of the Accept header.
+func handleOpenApiGetRequest(w http.ResponseWriter, r *http.Request) {
+	once.Do(func() {
+		document, documentErr = Document()
+	})
+	if documentErr != nil {
+		http.Error(w, errorMsg.ErrorBuildingOpenApi, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorBuildingOpenApi+": %q Path: %q", documentErr, r.URL)
+		return
+	}
+
+	err := apiUtility.EncodeJsonData(w, document)
+	if err != nil {
+		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
+	}
+}
+
+// Document returns the OpenAPI document of the API, with the version of the service and the GeoJSON schema of the
+// responses filled in from resources.GeoJsonSchema.
+func Document() (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	err := json.Unmarshal(resources.OpenApi, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var geoJson map[string]interface{}
+	err = json.Unmarshal(resources.GeoJsonSchema, &geoJson)
+	if err != nil {
+		return nil, err
+	}
+
+	info, ok := doc["info"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("openapi document has no info")
+	}
+	info["version"] = global.VERSION
+
+	components, ok := doc["components"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("openapi document has no components")
+	}
+	schemas, ok := components["schemas"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("openapi document has no schemas")
+	}
+	schemas["GeoJSON"] = geoJson
+
+	return doc, nil
+}
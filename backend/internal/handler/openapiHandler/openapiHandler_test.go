@@ -0,0 +1,134 @@
+package openapiHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/handler/router"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+func TestInvalidRequests(t *testing.T) {
+	openApiEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.OpenApiPath, OpenApiHandler),
+	}))
+	defer openApiEndpoint.Close()
+
+	var endpoint = openApiEndpoint.URL + global.OpenApiPath
+
+	tests := []struct {
+		name, url, httpMethod, errorMsg string
+		statusCode                      int
+	}{
+		{
+			name:       "Post request",
+			url:        endpoint,
+			httpMethod: http.MethodPost,
+			statusCode: http.StatusMethodNotAllowed,
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
+		},
+		{
+			name:       "Too long URL",
+			url:        endpoint + "/yaml",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   "404 page not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.httpMethod, tt.url, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			assert.Equal(t, tt.errorMsg+"\n", string(body))
+		})
+	}
+}
+
+func TestValidRequests(t *testing.T) {
+	openApiEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.OpenApiPath, OpenApiHandler),
+	}))
+	defer openApiEndpoint.Close()
+
+	res, err := http.Get(openApiEndpoint.URL + global.OpenApiPath)
+	if err != nil {
+		t.Fatalf("error executing request: %q", err)
+	}
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+	var doc struct {
+		OpenApi string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&doc)
+	if err != nil {
+		t.Fatalf("error decoding response body: %q", err)
+	}
+
+	assert.Equal(t, "3.1.0", doc.OpenApi)
+	assert.Equal(t, global.VERSION, doc.Info.Version)
+	// the GeoJSON schema is filled in from resources/schemas/geoJson.json
+	assert.Equal(t, "GeoJSON", doc.Components.Schemas["GeoJSON"]["title"])
+}
+
+// TestDocumentReferences fails if a $ref of the document points to a component that does not exist.
+func TestDocumentReferences(t *testing.T) {
+	doc, err := Document()
+	if err != nil {
+		t.Fatalf("error building openapi document: %q", err)
+	}
+	components := doc["components"].(map[string]interface{})
+
+	var check func(value interface{})
+	check = func(value interface{}) {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if ref, ok := value["$ref"].(string); ok {
+				kind, name, _ := strings.Cut(strings.TrimPrefix(ref, "#/components/"), "/")
+				section, _ := components[kind].(map[string]interface{})
+				assert.Contains(t, section, name, ref)
+			}
+			for _, v := range value {
+				check(v)
+			}
+		case []interface{}:
+			for _, v := range value {
+				check(v)
+			}
+		}
+	}
+	check(doc)
+}
@@ -0,0 +1,98 @@
+// Package router routes the requests of the REST API to the handler of their method and path.
+package router
+
+import (
+	"adsb-api/internal/global"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Route is an endpoint of the API. Pattern is the path of the endpoint, where a path parameter is a segment in braces,
+// e.g. /aircraft/current/{icao}, which the handler gets with r.PathValue. A pattern ending in {$} only matches the path
+// up to it, e.g. /aircraft/current/{$}. Scope is the API key scope the route requires, if any.
+type Route struct {
+	Method  string
+	Pattern string
+	Handler http.HandlerFunc
+	Scope   string
+}
+
+// Middleware wraps the handler of a route requiring scope, which is empty if the route does not require one.
+type Middleware func(scope string, next http.HandlerFunc) http.HandlerFunc
+
+// Get returns the route of the GET requests of pattern.
+func Get(pattern string, handler http.HandlerFunc) Route {
+	return Route{Method: http.MethodGet, Pattern: pattern, Handler: handler}
+}
+
+// Scoped returns the route requiring the API key scope scope.
+func (route Route) Scoped(scope string) Route {
+	route.Scope = scope
+	return route
+}
+
+// New returns a handler routing every request to the handler of the route matching its method and path. Every route is
+// served under global.ApiVersionPrefix, and at its unversioned path with the deprecation headers of deprecated.
+// A path without a route is answered with 404, and a path with routes for other methods only is answered with 405
+// and an Allow header with the methods of its routes. The handler of every route is wrapped in middleware, the first
+// being the outermost. The CORS preflight requests of every path are answered without the middleware, see preflight.
+func New(routes []Route, middleware ...Middleware) http.Handler {
+	mux := http.NewServeMux()
+	methods := make(map[string][]string)
+	for _, route := range routes {
+		handler := route.Handler
+		for i := len(middleware) - 1; i >= 0; i-- {
+			handler = middleware[i](route.Scope, handler)
+		}
+		mux.HandleFunc(route.Method+" "+global.ApiVersionPrefix+route.Pattern, handler)
+		mux.HandleFunc(route.Method+" "+route.Pattern, deprecated(handler))
+		methods[route.Pattern] = append(methods[route.Pattern], route.Method)
+	}
+
+	for pattern, patternMethods := range methods {
+		handler := preflight(patternMethods)
+		mux.HandleFunc(http.MethodOptions+" "+global.ApiVersionPrefix+pattern, handler)
+		mux.HandleFunc(http.MethodOptions+" "+pattern, handler)
+	}
+	return mux
+}
+
+// preflight answers the OPTIONS requests of a path with routes for methods, for browsers to send requests with an API
+// key in the Authorization or X-API-Key header from any origin. The preflight request itself has no API key.
+func preflight(methods []string) http.HandlerFunc {
+	var allowed []string
+	for _, method := range methods {
+		allowed = append(allowed, method)
+		if method == http.MethodGet {
+			allowed = append(allowed, http.MethodHead)
+		}
+	}
+	allowed = append(allowed, http.MethodOptions)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowed, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, X-API-Key")
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// deprecated adds the headers of a deprecated path to the responses of handler: Deprecation with the date the
+// unversioned paths were deprecated, Sunset with the date they are removed, if it is set, and a Link to the path of
+// the current version.
+func deprecated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(global.UnversionedDeprecated.Unix(), 10))
+		if !global.UnversionedSunset.IsZero() {
+			w.Header().Set("Sunset", global.UnversionedSunset.UTC().Format(http.TimeFormat))
+		}
+		successor := global.ApiVersionPrefix + r.URL.EscapedPath()
+		if r.URL.RawQuery != "" {
+			successor += "?" + r.URL.RawQuery
+		}
+		w.Header().Set("Link", "<"+strings.ReplaceAll(successor, ">", "%3E")+`>; rel="successor-version"`)
+		handler(w, r)
+	}
+}
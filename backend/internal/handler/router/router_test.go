@@ -0,0 +1,211 @@
+package router
+
+import (
+	"adsb-api/internal/global"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+// echo writes the icao path parameter of the request.
+func echo(w http.ResponseWriter, r *http.Request) {
+	_, _ = io.WriteString(w, r.PathValue("icao"))
+}
+
+func TestRouter(t *testing.T) {
+	server := httptest.NewServer(New([]Route{
+		Get(global.AircraftHistoryPath+"{icao}", echo),
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name, path, httpMethod, body, allow string
+		statusCode                          int
+		deprecated                          bool
+	}{
+		{
+			name:       "Versioned path",
+			path:       global.ApiVersionPrefix + global.AircraftHistoryPath + "ABC123",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusOK,
+			body:       "ABC123",
+		},
+		{
+			name:       "Unversioned path",
+			path:       global.AircraftHistoryPath + "ABC123?hour=2",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusOK,
+			body:       "ABC123",
+			deprecated: true,
+		},
+		{
+			name:       "Method not allowed",
+			path:       global.ApiVersionPrefix + global.AircraftHistoryPath + "ABC123",
+			httpMethod: http.MethodPost,
+			statusCode: http.StatusMethodNotAllowed,
+			body:       http.StatusText(http.StatusMethodNotAllowed) + "\n",
+			allow:      "GET, HEAD, OPTIONS",
+		},
+		{
+			name:       "Path without parameter",
+			path:       global.ApiVersionPrefix + global.AircraftHistoryPath,
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			body:       "404 page not found\n",
+		},
+		{
+			name:       "Unknown version",
+			path:       "/v2" + global.AircraftHistoryPath + "ABC123",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			body:       "404 page not found\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.httpMethod, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			assert.Equal(t, tt.body, string(body))
+			assert.Equal(t, tt.allow, res.Header.Get("Allow"))
+
+			if tt.deprecated {
+				assert.Equal(t, "@1792368000", res.Header.Get("Deprecation"))
+				assert.Equal(t, "</v1/aircraft/history/ABC123?hour=2>; rel=\"successor-version\"", res.Header.Get("Link"))
+			} else {
+				assert.Empty(t, res.Header.Get("Deprecation"))
+				assert.Empty(t, res.Header.Get("Link"))
+			}
+			assert.Empty(t, res.Header.Get("Sunset"))
+		})
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	called := false
+	server := httptest.NewServer(New([]Route{
+		Get(global.AircraftHistoryPath+"{icao}", echo).Scoped("history"),
+	}, func(scope string, next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			next(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, path := range []string{
+		global.ApiVersionPrefix + global.AircraftHistoryPath + "ABC123",
+		global.AircraftHistoryPath + "ABC123",
+	} {
+		t.Run(path, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodOptions, server.URL+path, nil)
+			if err != nil {
+				t.Fatalf("Error creating request: %s", err.Error())
+			}
+			req.Header.Set("Origin", "https://example.com")
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			req.Header.Set("Access-Control-Request-Headers", "authorization")
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Error executing request: %s", err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusNoContent, res.StatusCode)
+			assert.Equal(t, "*", res.Header.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "GET, HEAD, OPTIONS", res.Header.Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, "Authorization, X-API-Key", res.Header.Get("Access-Control-Allow-Headers"))
+		})
+	}
+
+	// the preflight request has no API key, so it is answered without the middleware
+	assert.False(t, called)
+}
+
+func TestSunset(t *testing.T) {
+	global.UnversionedSunset = time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+	defer func() { global.UnversionedSunset = time.Time{} }()
+
+	server := httptest.NewServer(New([]Route{Get(global.AircraftHistoryPath+"{icao}", echo)}))
+	defer server.Close()
+
+	res, err := http.Get(server.URL + global.AircraftHistoryPath + "ABC123")
+	if err != nil {
+		t.Fatalf("Error executing request: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	assert.Equal(t, "Thu, 01 Apr 2027 00:00:00 GMT", res.Header.Get("Sunset"))
+
+	res, err = http.Get(server.URL + global.ApiVersionPrefix + global.AircraftHistoryPath + "ABC123")
+	if err != nil {
+		t.Fatalf("Error executing request: %s", err.Error())
+	}
+	defer res.Body.Close()
+
+	assert.Empty(t, res.Header.Get("Sunset"))
+}
+
+func TestMiddleware(t *testing.T) {
+	// tag writes the name of the middleware and the scope of the route before calling the handler
+	tag := func(name string) Middleware {
+		return func(scope string, next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, name+":"+scope+" ")
+				next(w, r)
+			}
+		}
+	}
+	server := httptest.NewServer(New([]Route{
+		Get(global.AircraftHistoryPath+"{icao}", echo).Scoped("history"),
+		Get(global.AircraftRegistryPath+"{icao}", echo),
+	}, tag("first"), tag("second")))
+	defer server.Close()
+
+	tests := []struct {
+		name, path, body string
+	}{
+		{name: "Scoped route", path: global.ApiVersionPrefix + global.AircraftHistoryPath + "ABC123", body: "first:history second:history ABC123"},
+		{name: "Unversioned path", path: global.AircraftHistoryPath + "ABC123", body: "first:history second:history ABC123"},
+		{name: "Unscoped route", path: global.ApiVersionPrefix + global.AircraftRegistryPath + "ABC123", body: "first: second: ABC123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := http.Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package statsHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/service/restService"
+	"adsb-api/internal/utility/apiUtility"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// statsParams are the query parameters of each statistic of /stats/{stat}
+var statsParams = map[string][]string{
+	"traffic":   {"from", "to", "interval"},
+	"busiest":   {"from", "to", "limit"},
+	"altitudes": {"from", "to", "band"},
+	"airlines":  {"from", "to", "limit"},
+}
+
+// defaultAltitudeBand is the height in feet of each band of /stats/altitudes without the band query parameter
+const defaultAltitudeBand = 1000
+
+// statsResponse is a statistic along with the time range it covers.
+type statsResponse struct {
+	From  string      `json:"from"`
+	To    string      `json:"to"`
+	Stats interface{} `json:"stats"`
+}
+
+// StatsHandler handles HTTP requests for /stats/traffic?from=&to=&interval=, /stats/busiest?from=&to=&limit=,
+// /stats/altitudes?from=&to=&band= and /stats/airlines?from=&to=&limit= endpoints.
+func StatsHandler(svc restService.RestService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stat := r.PathValue("stat")
+		params, found := statsParams[stat]
+		if !found {
+			http.Error(w, errorMsg.StatsNotFound, http.StatusNotFound)
+			return
+		}
+
+		err := apiUtility.ValidateURL(w, r, params)
+		if err != nil {
+			return
+		}
+		handleStatsGetRequest(w, r, svc, stat)
+	}
+}
+
+// handleStatsGetRequest handles GET requests for the /stats/{stat} endpoints.
+// Sends the statistic stat of the aircraft history within the time range given by the from and to parameters, the last
+// global.StatsDefaultDays days by default:
+//   - traffic: the unique aircraft, positions and messages for every hour, or every day with interval=day
+//   - busiest: the limit hours with the most unique aircraft
+//   - altitudes: a histogram of the altitudes, in bands of band feet
+//   - airlines: the limit callsign prefixes, i.e. airlines, with the most unique aircraft
+func handleStatsGetRequest(w http.ResponseWriter, r *http.Request, svc restService.RestService, stat string) {
+	filter, err := parseFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var res interface{}
+	var n int
+	switch stat {
+	case "traffic":
+		interval := strings.ToLower(r.URL.Query().Get("interval"))
+		if interval == "" {
+			interval = "hour"
+		} else if interval != "hour" && interval != "day" {
+			http.Error(w, errorMsg.InvalidQueryParameterInterval, http.StatusBadRequest)
+			return
+		}
+
+		var stats []models.TrafficStatsModel
+		stats, err = svc.GetTrafficStats(filter, interval)
+		res, n = stats, len(stats)
+	case "busiest":
+		limit, limitErr := parseLimit(r.URL.Query())
+		if limitErr != nil {
+			http.Error(w, limitErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var stats []models.TrafficStatsModel
+		stats, err = svc.GetBusiestHours(filter, limit)
+		res, n = stats, len(stats)
+	case "altitudes":
+		band := defaultAltitudeBand
+		if r.URL.Query().Has("band") {
+			var bandErr error
+			band, bandErr = strconv.Atoi(r.URL.Query().Get("band"))
+			if bandErr != nil || band <= 0 {
+				http.Error(w, errorMsg.InvalidQueryParameterBand, http.StatusBadRequest)
+				return
+			}
+		}
+
+		var stats []models.AltitudeStatsModel
+		stats, err = svc.GetAltitudeStats(filter, band)
+		res, n = stats, len(stats)
+	case "airlines":
+		limit, limitErr := parseLimit(r.URL.Query())
+		if limitErr != nil {
+			http.Error(w, limitErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var stats []models.AirlineStatsModel
+		stats, err = svc.GetAirlineStats(filter, limit)
+		res, n = stats, len(stats)
+	}
+
+	if err != nil {
+		http.Error(w, errorMsg.ErrorRetrievingStats, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorRetrievingStats+": %q Path: %q", err, r.URL)
+		return
+	}
+	if n == 0 {
+		apiUtility.NoContent(w)
+		return
+	}
+
+	err = apiUtility.EncodeData(w, r, statsResponse{
+		From:  filter.From.UTC().Format(time.RFC3339),
+		To:    filter.To.UTC().Format(time.RFC3339),
+		Stats: res,
+	})
+	if err != nil {
+		http.Error(w, errorMsg.ErrorEncodingJsonData, http.StatusInternalServerError)
+		log.Error().Msgf(errorMsg.ErrorEncodingJsonData+": %q", err)
+	}
+}
+
+// parseFilter parses the from and to query parameters to a StatsFilter. to defaults to now, and from to
+// global.StatsDefaultDays days before to. Returns an error with the message for the first invalid parameter, or if the
+// time range is longer than global.StatsMaxDays days.
+func parseFilter(query url.Values) (models.StatsFilter, error) {
+	filter := models.StatsFilter{To: time.Now().UTC()}
+	var err error
+
+	if query.Has("to") {
+		filter.To, err = time.Parse(time.RFC3339, query.Get("to"))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterTo)
+		}
+	}
+
+	if query.Has("from") {
+		filter.From, err = time.Parse(time.RFC3339, query.Get("from"))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterFrom)
+		}
+	} else {
+		filter.From = filter.To.AddDate(0, 0, -global.StatsDefaultDays)
+	}
+
+	if !filter.To.After(filter.From) {
+		return filter, errors.New(errorMsg.InvalidQueryParameterTo)
+	}
+	if filter.To.Sub(filter.From) > time.Duration(global.StatsMaxDays)*24*time.Hour {
+		return filter, fmt.Errorf(errorMsg.InvalidQueryParameterMaxRange, global.StatsMaxDays)
+	}
+
+	return filter, nil
+}
+
+// parseLimit parses the limit query parameter, global.StatsDefaultLimit by default. A limit above
+// global.StatsMaxLimit is lowered to it.
+func parseLimit(query url.Values) (int, error) {
+	limit := global.StatsDefaultLimit
+	if query.Has("limit") {
+		var err error
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || limit <= 0 {
+			return 0, errors.New(errorMsg.InvalidQueryParameterLimit)
+		}
+	}
+	if limit > global.StatsMaxLimit {
+		limit = global.StatsMaxLimit
+	}
+	return limit, nil
+}
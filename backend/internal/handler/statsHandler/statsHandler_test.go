@@ -0,0 +1,295 @@
+package statsHandler
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/handler/router"
+	"adsb-api/internal/utility/mock"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+var (
+	rangeQuery = "?from=2024-04-08T00:00:00Z&to=2024-04-15T00:00:00Z"
+	filter     = models.StatsFilter{
+		From: time.Date(2024, 4, 8, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC),
+	}
+)
+
+func TestInvalidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	statsEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.StatsPath+"{stat...}", StatsHandler(mockSvc)),
+	}))
+	defer statsEndpoint.Close()
+
+	var endpoint = statsEndpoint.URL + global.StatsPath
+
+	tests := []struct {
+		name, url, httpMethod, errorMsg string
+		statusCode                      int
+		setup                           func(mockSvc *mock.MockRestService)
+	}{
+		{
+			name:       "Post request",
+			url:        endpoint + "traffic",
+			httpMethod: http.MethodPost,
+			statusCode: http.StatusMethodNotAllowed,
+			errorMsg:   http.StatusText(http.StatusMethodNotAllowed),
+		},
+		{
+			name:       "Get request with unknown path",
+			url:        endpoint + "traffic/hour",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   errorMsg.StatsNotFound,
+		},
+		{
+			name:       "Get request without statistic",
+			url:        endpoint,
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   errorMsg.StatsNotFound,
+		},
+		{
+			name:       "Get request with unknown statistic",
+			url:        endpoint + "speeds",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   errorMsg.StatsNotFound,
+		},
+		{
+			name:       "Get request with unknown statistic and parameters",
+			url:        endpoint + "speeds?from=2024-01-01T00:00:00Z",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusNotFound,
+			errorMsg:   errorMsg.StatsNotFound,
+		},
+		{
+			name:       "Get request with parameter of another statistic",
+			url:        endpoint + "traffic?limit=5",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.ErrorInvalidQueryParams + ": from, to, interval",
+		},
+		{
+			name:       "Get request with invalid from",
+			url:        endpoint + "traffic?from=yesterday",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterFrom,
+		},
+		{
+			name:       "Get request with to before from",
+			url:        endpoint + "traffic?from=2024-04-15T00:00:00Z&to=2024-04-08T00:00:00Z",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterTo,
+		},
+		{
+			name:       "Get request with too long time range",
+			url:        endpoint + "traffic?from=2024-01-01T00:00:00Z&to=2024-04-15T00:00:00Z",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   fmt.Sprintf(errorMsg.InvalidQueryParameterMaxRange, global.StatsMaxDays),
+		},
+		{
+			name:       "Get request with invalid interval",
+			url:        endpoint + "traffic?interval=week",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterInterval,
+		},
+		{
+			name:       "Get request with invalid limit",
+			url:        endpoint + "busiest?limit=0",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterLimit,
+		},
+		{
+			name:       "Get request with invalid band",
+			url:        endpoint + "altitudes?band=-500",
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusBadRequest,
+			errorMsg:   errorMsg.InvalidQueryParameterBand,
+		},
+		{
+			name:       "Database returns error",
+			url:        endpoint + "airlines" + rangeQuery,
+			httpMethod: http.MethodGet,
+			statusCode: http.StatusInternalServerError,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetAirlineStats(filter, global.StatsDefaultLimit).Return(nil, errors.New("connection refused"))
+			},
+			errorMsg: errorMsg.ErrorRetrievingStats,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(mockSvc)
+			}
+
+			req, err := http.NewRequest(tt.httpMethod, tt.url, nil)
+			if err != nil {
+				t.Fatalf("Test: %s. Error creating request: %s", tt.name, err.Error())
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing %s request: %s", tt.name, tt.httpMethod, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Errorf("Test: %s. Error reading response body: %s", tt.name, err.Error())
+			}
+			assert.Equal(t, tt.errorMsg+"\n", string(body))
+		})
+	}
+}
+
+func TestValidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock.NewMockRestService(ctrl)
+	statsEndpoint := httptest.NewServer(router.New([]router.Route{
+		router.Get(global.StatsPath+"{stat...}", StatsHandler(mockSvc)),
+	}))
+	defer statsEndpoint.Close()
+
+	var endpoint = statsEndpoint.URL + global.StatsPath
+
+	traffic := []models.TrafficStatsModel{
+		{Time: "2024-04-11T20:00:00Z", Aircraft: 42, Positions: 3120, Messages: 9360},
+		{Time: "2024-04-11T21:00:00Z", Aircraft: 37, Positions: 2840, Messages: 8520},
+	}
+	altitudes := []models.AltitudeStatsModel{{Min: 35000, Max: 35500, Aircraft: 12, Positions: 840}}
+	airlines := []models.AirlineStatsModel{{Prefix: "SAS", Aircraft: 18, Callsigns: 25, Positions: 1460}}
+
+	tests := []struct {
+		name, url  string
+		statusCode int
+		setup      func(mockSvc *mock.MockRestService)
+		expected   interface{}
+	}{
+		{
+			name:       "Traffic per hour",
+			url:        endpoint + "traffic" + rangeQuery,
+			statusCode: http.StatusOK,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetTrafficStats(filter, "hour").Return(traffic, nil)
+			},
+			expected: traffic,
+		},
+		{
+			name:       "Traffic per day",
+			url:        endpoint + "traffic" + rangeQuery + "&interval=DAY",
+			statusCode: http.StatusOK,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetTrafficStats(filter, "day").Return(traffic, nil)
+			},
+			expected: traffic,
+		},
+		{
+			name:       "Busiest hours with limit above the max",
+			url:        endpoint + "busiest" + rangeQuery + "&limit=1000",
+			statusCode: http.StatusOK,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetBusiestHours(filter, global.StatsMaxLimit).Return(traffic, nil)
+			},
+			expected: traffic,
+		},
+		{
+			name:       "Altitudes with band",
+			url:        endpoint + "altitudes" + rangeQuery + "&band=500",
+			statusCode: http.StatusOK,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetAltitudeStats(filter, 500).Return(altitudes, nil)
+			},
+			expected: altitudes,
+		},
+		{
+			name:       "Airlines",
+			url:        endpoint + "airlines" + rangeQuery,
+			statusCode: http.StatusOK,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetAirlineStats(filter, global.StatsDefaultLimit).Return(airlines, nil)
+			},
+			expected: airlines,
+		},
+		{
+			name:       "Default time range without traffic",
+			url:        endpoint + "altitudes",
+			statusCode: http.StatusNoContent,
+			setup: func(mockSvc *mock.MockRestService) {
+				mockSvc.EXPECT().GetAltitudeStats(gomock.Any(), defaultAltitudeBand).DoAndReturn(
+					func(filter models.StatsFilter, band int) ([]models.AltitudeStatsModel, error) {
+						assert.Equal(t, filter.To.AddDate(0, 0, -global.StatsDefaultDays), filter.From)
+						assert.WithinDuration(t, time.Now(), filter.To, time.Minute)
+						return nil, nil
+					})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mockSvc)
+
+			res, err := http.Get(tt.url)
+			if err != nil {
+				t.Fatalf("Test: %s. Error executing request: %s", tt.name, err.Error())
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			if tt.expected == nil {
+				return
+			}
+
+			var actual statsResponse
+			err = json.NewDecoder(res.Body).Decode(&actual)
+			if err != nil {
+				t.Errorf("Test: %s. Error decoding response body: %s", tt.name, err.Error())
+			}
+
+			expected, err := json.Marshal(tt.expected)
+			if err != nil {
+				t.Fatalf("Test: %s. Error encoding expected stats: %s", tt.name, err.Error())
+			}
+			stats, err := json.Marshal(actual.Stats)
+			if err != nil {
+				t.Fatalf("Test: %s. Error encoding stats: %s", tt.name, err.Error())
+			}
+
+			assert.Equal(t, "2024-04-08T00:00:00Z", actual.From)
+			assert.Equal(t, "2024-04-15T00:00:00Z", actual.To)
+			assert.JSONEq(t, string(expected), string(stats))
+		})
+	}
+}
@@ -3,15 +3,24 @@ package cleanupJob
 import (
 	"adsb-api/internal/db"
 	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/utility/archive"
 
 	"github.com/rs/zerolog/log"
 )
 
 // CleanupJob represents a job to clean up old history data from the database.
 // It contains the database instance and the maximum number of days of history to keep.
+// If RollupResolution is set, old history is downsampled into the rollup table before it is deleted, and the rollup
+// itself is kept for MaxDaysRollup days (0 keeps it forever). If ArchiveDir is set, old history is written to a
+// compressed archive in that directory before it is deleted. The aircraft sightings are kept for as long as the history
+// or the rollup that they lead to.
 type CleanupJob struct {
-	db             db.Database
-	MaxDaysHistory int
+	db               db.Database
+	MaxDaysHistory   int
+	RollupResolution int
+	MaxDaysRollup    int
+	ArchiveDir       string
 }
 
 // NewCleanupJob initializes a new job for cleaning hold history data.
@@ -19,10 +28,104 @@ func NewCleanupJob(db db.Database, days int) *CleanupJob {
 	return &CleanupJob{db: db, MaxDaysHistory: days}
 }
 
+// NewTieredCleanupJob initializes a new job for cleaning old history data, that archives it to archiveDir and keeps
+// one point every resolution seconds in the rollup table for rollupDays days. An empty archiveDir or a resolution of 0
+// disables the respective tier.
+func NewTieredCleanupJob(db db.Database, days int, resolution int, rollupDays int, archiveDir string) *CleanupJob {
+	return &CleanupJob{db: db, MaxDaysHistory: days, RollupResolution: resolution, MaxDaysRollup: rollupDays,
+		ArchiveDir: archiveDir}
+}
+
 // Execute is the function be used with scheduler.
 func (cj *CleanupJob) Execute() {
-	if err := cj.db.DeleteOldHistory(cj.MaxDaysHistory); err != nil {
-		log.Error().Msgf(errorMsg.ErrorDeletingOldHistory+": %q", err)
+	if cj.RollupResolution > 0 || cj.ArchiveDir != "" {
+		cj.executeTiered()
+	} else {
+		if err := cj.db.DeleteOldHistory(cj.MaxDaysHistory); err != nil {
+			log.Error().Msgf(errorMsg.ErrorDeletingOldHistory+": %q", err)
+		}
+		log.Info().Msgf(errorMsg.InfoOldHistoryDataDeleted)
+	}
+
+	cj.deleteOldSightings()
+}
+
+// deleteOldSightings deletes the aircraft sightings older than the history, or the rollup if there is one. Nothing is
+// deleted if the rollup is kept forever.
+func (cj *CleanupJob) deleteOldSightings() {
+	days := cj.MaxDaysHistory
+	if cj.RollupResolution > 0 {
+		if cj.MaxDaysRollup == 0 {
+			return
+		}
+		days = cj.MaxDaysRollup
+	}
+
+	if err := cj.db.DeleteOldAircraftSightings(days); err != nil {
+		log.Error().Msgf(errorMsg.ErrorDeletingOldSightings+": %q", err)
+	}
+}
+
+// executeTiered archives and rolls up the history older than MaxDaysHistory before deleting it.
+// The raw history is only deleted if both the archive and the rollup succeeded.
+func (cj *CleanupJob) executeTiered() {
+	cutoff, err := cj.db.SelectHistoryCutoff(cj.MaxDaysHistory)
+	if err != nil {
+		log.Error().Msgf(errorMsg.ErrorSelectingHistoryCutoff+": %q", err)
+		return
+	}
+
+	if cutoff != "" {
+		if cj.ArchiveDir != "" {
+			if err := cj.archiveHistory(cutoff); err != nil {
+				log.Error().Msgf(errorMsg.ErrorArchivingOldHistory+": %q", err)
+				return
+			}
+		}
+
+		if cj.RollupResolution > 0 {
+			if err := cj.db.InsertHistoryRollup(cutoff, cj.RollupResolution); err != nil {
+				log.Error().Msgf(errorMsg.ErrorRollingUpOldHistory+": %q", err)
+				return
+			}
+			log.Info().Msgf(errorMsg.InfoOldHistoryRolledUp)
+		}
+
+		if err := cj.db.DeleteHistoryBefore(cutoff); err != nil {
+			log.Error().Msgf(errorMsg.ErrorDeletingOldHistory+": %q", err)
+			return
+		}
+		log.Info().Msgf(errorMsg.InfoOldHistoryDataDeleted)
+	}
+
+	if cj.MaxDaysRollup > 0 {
+		if err := cj.db.DeleteOldHistoryRollup(cj.MaxDaysRollup); err != nil {
+			log.Error().Msgf(errorMsg.ErrorDeletingOldRollup+": %q", err)
+		}
+	}
+}
+
+// archiveHistory writes every row older than cutoff to a new archive in ArchiveDir. An archive that is already there
+// for cutoff is complete, e.g. from a run that failed to delete the history after archiving it, and is kept.
+func (cj *CleanupJob) archiveHistory(cutoff string) error {
+	path, manifest, err := archive.FindArchive(cj.ArchiveDir, cutoff)
+	if err != nil {
+		return err
+	}
+	if manifest != nil {
+		log.Info().Msgf(errorMsg.InfoOldHistoryWasArchived+": %d rows in %s", manifest.Rows, path)
+		return nil
+	}
+
+	stream := func(handle func(models.AircraftHistoryModel) error) error {
+		return cj.db.StreamHistoryBefore(cutoff, handle)
+	}
+	path, rows, err := archive.WriteArchive(cj.ArchiveDir, cutoff, stream)
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		log.Info().Msgf(errorMsg.InfoOldHistoryArchived+": %d rows written to %s", rows, path)
 	}
-	log.Info().Msgf(errorMsg.InfoOldHistoryDataDeleted)
+	return nil
 }
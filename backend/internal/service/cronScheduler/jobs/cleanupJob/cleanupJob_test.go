@@ -3,6 +3,8 @@ package cleanupJob
 import (
 	"adsb-api/internal/global"
 	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/utility/archive"
 	"adsb-api/internal/utility/mock"
 	"bytes"
 	"errors"
@@ -12,6 +14,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -45,6 +48,7 @@ func TestCleanupJob_Execute(t *testing.T) {
 	job := NewCleanupJob(mockDB, MaxDaysHistory)
 
 	mockDB.EXPECT().DeleteOldHistory(MaxDaysHistory).Return(nil)
+	mockDB.EXPECT().DeleteOldAircraftSightings(MaxDaysHistory).Return(nil)
 
 	job.Execute()
 
@@ -73,6 +77,7 @@ func TestCleanupJob_Execute_ErrorDeletingOldHistory(t *testing.T) {
 	var errorMessage = "mockData error deleting old history data"
 
 	mockDB.EXPECT().DeleteOldHistory(MaxDaysHistory).Return(errors.New(errorMessage))
+	mockDB.EXPECT().DeleteOldAircraftSightings(MaxDaysHistory).Return(nil)
 
 	job.Execute()
 
@@ -81,3 +86,166 @@ func TestCleanupJob_Execute_ErrorDeletingOldHistory(t *testing.T) {
 	assert.Contains(t, logOutput, fmt.Errorf(errorMsg.ErrorDeletingOldHistory+": %q", errorMessage).Error())
 	log.Logger = zerolog.New(os.Stderr)
 }
+
+func TestCleanupJob_Execute_Tiered(t *testing.T) {
+	var logBuffer bytes.Buffer
+	log.Logger = zerolog.New(&logBuffer)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	cutoff := "2024-01-01T00:00:00Z"
+
+	job := NewTieredCleanupJob(mockDB, 5, 60, 30, "")
+
+	gomock.InOrder(
+		mockDB.EXPECT().SelectHistoryCutoff(5).Return(cutoff, nil),
+		mockDB.EXPECT().InsertHistoryRollup(cutoff, 60).Return(nil),
+		mockDB.EXPECT().DeleteHistoryBefore(cutoff).Return(nil),
+		mockDB.EXPECT().DeleteOldHistoryRollup(30).Return(nil),
+		// the sightings are kept as long as the rollup
+		mockDB.EXPECT().DeleteOldAircraftSightings(30).Return(nil),
+	)
+
+	job.Execute()
+
+	logOutput := logBuffer.String()
+
+	assert.Contains(t, logOutput, errorMsg.InfoOldHistoryRolledUp)
+	assert.Contains(t, logOutput, errorMsg.InfoOldHistoryDataDeleted)
+	log.Logger = zerolog.New(os.Stderr)
+}
+
+func TestCleanupJob_Execute_TieredEmptyHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+
+	job := NewTieredCleanupJob(mockDB, 5, 60, 0, "")
+
+	mockDB.EXPECT().SelectHistoryCutoff(5).Return("", nil)
+
+	job.Execute()
+}
+
+func TestCleanupJob_Execute_TieredErrorRollingUp(t *testing.T) {
+	var logBuffer bytes.Buffer
+	log.Logger = zerolog.New(&logBuffer)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	cutoff := "2024-01-01T00:00:00Z"
+
+	job := NewTieredCleanupJob(mockDB, 5, 60, 30, "")
+
+	var errorMessage = "mockData error rolling up old history data"
+
+	// DeleteHistoryBefore must not be called when the rollup fails
+	mockDB.EXPECT().SelectHistoryCutoff(5).Return(cutoff, nil)
+	mockDB.EXPECT().InsertHistoryRollup(cutoff, 60).Return(errors.New(errorMessage))
+	mockDB.EXPECT().DeleteOldAircraftSightings(30).Return(nil)
+
+	job.Execute()
+
+	logOutput := logBuffer.String()
+
+	assert.Contains(t, logOutput, errorMsg.ErrorRollingUpOldHistory)
+	assert.NotContains(t, logOutput, errorMsg.InfoOldHistoryDataDeleted)
+	log.Logger = zerolog.New(os.Stderr)
+}
+
+// streamRows returns a function streaming rows to the handle of StreamHistoryBefore.
+func streamRows(rows []models.AircraftHistoryModel) func(string, func(models.AircraftHistoryModel) error) error {
+	return func(_ string, handle func(models.AircraftHistoryModel) error) error {
+		for _, row := range rows {
+			if err := handle(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestCleanupJob_Execute_TieredWithArchive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	dir := t.TempDir()
+	cutoff := "2024-01-02T00:00:00Z"
+	rows := []models.AircraftHistoryModel{{Icao: "TEST", Latitude: 1, Longitude: 1, Timestamp: "2024-01-01T00:00:00Z"}}
+
+	job := NewTieredCleanupJob(mockDB, 5, 0, 0, dir)
+
+	gomock.InOrder(
+		mockDB.EXPECT().SelectHistoryCutoff(5).Return(cutoff, nil),
+		mockDB.EXPECT().StreamHistoryBefore(cutoff, gomock.Any()).DoAndReturn(streamRows(rows)),
+		mockDB.EXPECT().DeleteHistoryBefore(cutoff).Return(nil),
+		mockDB.EXPECT().DeleteOldAircraftSightings(5).Return(nil),
+	)
+
+	job.Execute()
+
+	manifests, err := filepath.Glob(filepath.Join(dir, "*", archive.ManifestName))
+	if err != nil {
+		t.Fatalf("error listing archives: %q", err)
+	}
+	assert.Len(t, manifests, 1)
+}
+
+func TestCleanupJob_Execute_TieredAlreadyArchived(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	dir := t.TempDir()
+	cutoff := "2024-01-02T00:00:00Z"
+	rows := []models.AircraftHistoryModel{{Icao: "TEST", Latitude: 1, Longitude: 1, Timestamp: "2024-01-01T00:00:00Z"}}
+
+	// a previous run archived the history but failed to delete it
+	_, _, err := archive.WriteArchive(dir, cutoff, func(handle func(models.AircraftHistoryModel) error) error {
+		return streamRows(rows)(cutoff, handle)
+	})
+	if err != nil {
+		t.Fatalf("error writing archive: %q", err)
+	}
+
+	job := NewTieredCleanupJob(mockDB, 5, 0, 0, dir)
+
+	// the history is deleted without being archived again
+	gomock.InOrder(
+		mockDB.EXPECT().SelectHistoryCutoff(5).Return(cutoff, nil),
+		mockDB.EXPECT().DeleteHistoryBefore(cutoff).Return(nil),
+		mockDB.EXPECT().DeleteOldAircraftSightings(5).Return(nil),
+	)
+
+	job.Execute()
+}
+
+func TestCleanupJob_Execute_TieredErrorArchiving(t *testing.T) {
+	var logBuffer bytes.Buffer
+	log.Logger = zerolog.New(&logBuffer)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	cutoff := "2024-01-02T00:00:00Z"
+	// invalid timestamp makes the archive write fail
+	rows := []models.AircraftHistoryModel{{Icao: "TEST", Timestamp: "invalid"}}
+
+	job := NewTieredCleanupJob(mockDB, 5, 60, 0, t.TempDir())
+
+	// neither the rollup nor the deletion may happen when the archive fails
+	mockDB.EXPECT().SelectHistoryCutoff(5).Return(cutoff, nil)
+	mockDB.EXPECT().StreamHistoryBefore(cutoff, gomock.Any()).DoAndReturn(streamRows(rows))
+
+	job.Execute()
+
+	assert.Contains(t, logBuffer.String(), errorMsg.ErrorArchivingOldHistory)
+	log.Logger = zerolog.New(os.Stderr)
+}
@@ -0,0 +1,41 @@
+package coverageJob
+
+import (
+	"adsb-api/internal/db"
+	"adsb-api/internal/global/errorMsg"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CoverageJob represents a job to update the receiver coverage from the history.
+// It contains the database instance, the position of the receiver, the number of bearing sectors and the height of the
+// altitude bands in feet. The coverage is kept for MaxDaysCoverage days (0 keeps it forever).
+type CoverageJob struct {
+	db              db.Database
+	Latitude        float64
+	Longitude       float64
+	Sectors         int
+	AltitudeBand    int
+	MaxDaysCoverage int
+}
+
+// NewCoverageJob initializes a new job for updating the coverage of the receiver at latitude and longitude.
+func NewCoverageJob(db db.Database, latitude float64, longitude float64, sectors int, band int, days int) *CoverageJob {
+	return &CoverageJob{db: db, Latitude: latitude, Longitude: longitude, Sectors: sectors, AltitudeBand: band,
+		MaxDaysCoverage: days}
+}
+
+// Execute is the function be used with scheduler.
+func (cj *CoverageJob) Execute() {
+	if err := cj.db.UpsertCoverageFromHistory(cj.Latitude, cj.Longitude, cj.Sectors, cj.AltitudeBand); err != nil {
+		log.Error().Msgf(errorMsg.ErrorUpdatingCoverage+": %q", err)
+		return
+	}
+	log.Info().Msgf(errorMsg.InfoCoverageUpdated)
+
+	if cj.MaxDaysCoverage > 0 {
+		if err := cj.db.DeleteOldCoverage(cj.MaxDaysCoverage); err != nil {
+			log.Error().Msgf(errorMsg.ErrorDeletingOldCoverage+": %q", err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package coverageJob
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/utility/mock"
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+func TestCoverageJob_Execute(t *testing.T) {
+	var logBuffer bytes.Buffer
+	log.Logger = zerolog.New(&logBuffer)
+	defer func() { log.Logger = zerolog.New(os.Stderr) }()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	job := NewCoverageJob(mockDB, 60.39, 5.32, 36, 10000, 90)
+
+	mockDB.EXPECT().UpsertCoverageFromHistory(60.39, 5.32, 36, 10000).Return(nil)
+	mockDB.EXPECT().DeleteOldCoverage(90).Return(nil)
+
+	job.Execute()
+
+	assert.Contains(t, logBuffer.String(), errorMsg.InfoCoverageUpdated)
+}
+
+func TestCoverageJob_Execute_KeepForever(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	job := NewCoverageJob(mockDB, 60.39, 5.32, 36, 10000, 0)
+
+	// DeleteOldCoverage is not expected
+	mockDB.EXPECT().UpsertCoverageFromHistory(60.39, 5.32, 36, 10000).Return(nil)
+
+	job.Execute()
+}
+
+func TestCoverageJob_Execute_ErrorUpdatingCoverage(t *testing.T) {
+	var logBuffer bytes.Buffer
+	log.Logger = zerolog.New(&logBuffer)
+	defer func() { log.Logger = zerolog.New(os.Stderr) }()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	job := NewCoverageJob(mockDB, 60.39, 5.32, 36, 10000, 90)
+
+	// old coverage is not deleted when the coverage could not be updated
+	mockDB.EXPECT().UpsertCoverageFromHistory(60.39, 5.32, 36, 10000).Return(errors.New("connection refused"))
+
+	job.Execute()
+
+	assert.Contains(t, logBuffer.String(), errorMsg.ErrorUpdatingCoverage)
+	assert.NotContains(t, logBuffer.String(), errorMsg.InfoCoverageUpdated)
+}
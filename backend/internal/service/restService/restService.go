@@ -2,15 +2,34 @@ package restService
 
 import (
 	"adsb-api/internal/db"
+	"adsb-api/internal/global"
 	"adsb-api/internal/global/models"
+	"adsb-api/internal/utility/apiKey"
+	"time"
 )
 
 // RestService is an interface representing a RESTful service for retrieving database data through the repository in
 // internal/db/database.go
 type RestService interface {
 	GetCurrentAircraft() ([]models.AircraftCurrentModel, error)
+	GetCurrentAircraftVersion() (models.AircraftCurrentVersion, error)
+	GetCurrentAircraftWithVersion(filter models.AircraftCurrentFilter) ([]models.AircraftCurrentModel, models.AircraftCurrentVersion, error)
+	GetCurrentAircraftSince(since models.AircraftCurrentVersion, filter models.AircraftCurrentFilter) (models.AircraftCurrentDelta, error)
+	GetCurrentAircraftByIcao(search string, trail int) (*models.AircraftCurrentDetail, error)
 	GetAircraftHistoryByIcao(search string) ([]models.AircraftHistoryModel, error)
 	GetAircraftHistoryByIcaoFilterByTimestamp(search string, hour int) ([]models.AircraftHistoryModel, error)
+	GetAircraftHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter) ([]models.AircraftHistoryModel, error)
+	StreamAircraftHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter, handle func(models.AircraftHistoryModel) error) error
+	GetAircraftRegistryByIcao(search string) (*models.AircraftRegistryModel, error)
+	SearchAircraft(search string, limit int) ([]models.AircraftSightingModel, error)
+	GetTrafficStats(filter models.StatsFilter, interval string) ([]models.TrafficStatsModel, error)
+	GetBusiestHours(filter models.StatsFilter, limit int) ([]models.TrafficStatsModel, error)
+	GetAltitudeStats(filter models.StatsFilter, band int) ([]models.AltitudeStatsModel, error)
+	GetAirlineStats(filter models.StatsFilter, limit int) ([]models.AirlineStatsModel, error)
+	GetCoverage(filter models.CoverageFilter) ([]models.CoverageModel, error)
+	GetHeatmap(filter models.HeatmapFilter) ([]models.HeatmapCellModel, error)
+	StreamHistoryExport(filter models.HistoryExportFilter, handle func(models.AircraftHistoryModel) error) error
+	GetApiKey(key string) (*models.ApiKeyModel, error)
 }
 
 type RestImpl struct {
@@ -28,13 +47,282 @@ func (svc *RestImpl) GetCurrentAircraft() ([]models.AircraftCurrentModel, error)
 	return svc.DB.SelectAllColumnsAircraftCurrent()
 }
 
+// GetCurrentAircraftVersion retrieves the version of the current aircraft, for the changes since it to be retrieved
+// with GetCurrentAircraftSince.
+func (svc *RestImpl) GetCurrentAircraftVersion() (models.AircraftCurrentVersion, error) {
+	return svc.DB.SelectAircraftCurrentVersion()
+}
+
+// GetCurrentAircraftWithVersion retrieves the current aircraft matching the filter, or every current aircraft if no
+// filter is set, and the version of aircraft_current they are from.
+func (svc *RestImpl) GetCurrentAircraftWithVersion(filter models.AircraftCurrentFilter) ([]models.AircraftCurrentModel, models.AircraftCurrentVersion, error) {
+	var aircraft []models.AircraftCurrentModel
+	var version models.AircraftCurrentVersion
+
+	// the version and the aircraft are read from the same snapshot, as the reads could go to different replicas
+	err := svc.DB.Snapshot(func(snapshot db.Database) (err error) {
+		version, err = snapshot.SelectAircraftCurrentVersion()
+		if err != nil {
+			return err
+		}
+		if filter == (models.AircraftCurrentFilter{}) {
+			aircraft, err = snapshot.SelectAllColumnsAircraftCurrent()
+		} else {
+			aircraft, err = snapshot.SelectAircraftCurrentFiltered(filter)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, models.AircraftCurrentVersion{}, err
+	}
+	return aircraft, version, nil
+}
+
+// GetCurrentAircraftSince retrieves the current aircraft matching the filter that were added or changed since the
+// version since, and the aircraft that were removed or no longer match the filter. If the removals since that version
+// are no longer kept, every current aircraft matching the filter is retrieved instead.
+func (svc *RestImpl) GetCurrentAircraftSince(since models.AircraftCurrentVersion, filter models.AircraftCurrentFilter) (models.AircraftCurrentDelta, error) {
+	var delta models.AircraftCurrentDelta
+
+	// the version, changes and removals are read from the same snapshot, for the delta to lead up to the version
+	err := svc.DB.Snapshot(func(snapshot db.Database) error {
+		var err error
+		delta, err = currentAircraftSince(snapshot, since, filter)
+		return err
+	})
+	if err != nil {
+		return models.AircraftCurrentDelta{}, err
+	}
+	return delta, nil
+}
+
+// currentAircraftSince retrieves the delta of GetCurrentAircraftSince from snapshot.
+func currentAircraftSince(snapshot db.Database, since models.AircraftCurrentVersion, filter models.AircraftCurrentFilter) (models.AircraftCurrentDelta, error) {
+	version, err := snapshot.SelectAircraftCurrentVersion()
+	if err != nil {
+		return models.AircraftCurrentDelta{}, err
+	}
+
+	delta := models.AircraftCurrentDelta{Version: version}
+	retention := time.Duration(global.CurrentDeltaRetention) * time.Second
+	// a version ahead of the database is from another database, or a replica further ahead
+	// the removals are deleted by their age, which is counted from now rather than from the last change
+	if since.Seq > version.Seq || since.Time.Before(time.Now().Add(-retention)) {
+		delta.Full = true
+		delta.Updated, err = snapshot.SelectAircraftCurrentFiltered(filter)
+		return delta, err
+	}
+
+	changed, err := snapshot.SelectAircraftCurrentChangedSince(since.Seq)
+	if err != nil {
+		return models.AircraftCurrentDelta{}, err
+	}
+	for _, ac := range changed {
+		if filter.Matches(ac) {
+			delta.Updated = append(delta.Updated, ac)
+		} else {
+			delta.Removed = append(delta.Removed, ac.Icao)
+		}
+	}
+
+	removed, err := snapshot.SelectAircraftCurrentRemovedSince(since.Seq)
+	if err != nil {
+		return models.AircraftCurrentDelta{}, err
+	}
+	delta.Removed = append(delta.Removed, removed...)
+
+	return delta, nil
+}
+
+// GetCurrentAircraftByIcao retrieves the current aircraft with the given icao, with its trail of the last trail minutes
+// before its timestamp. The trail ends with the current position. Returns nil if the aircraft is not current.
+func (svc *RestImpl) GetCurrentAircraftByIcao(search string, trail int) (*models.AircraftCurrentDetail, error) {
+	ac, err := svc.DB.SelectAircraftCurrentByIcao(search)
+	if err != nil || ac == nil {
+		return nil, err
+	}
+
+	detail := &models.AircraftCurrentDetail{Aircraft: *ac}
+	if trail <= 0 {
+		return detail, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, ac.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := models.AircraftHistoryFilter{From: timestamp.Add(-time.Duration(trail) * time.Minute), Ascending: true}
+	detail.Trail, err = svc.DB.SelectHistoryByIcaoTimeRange(ac.Icao, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// the current position is only copied to the history on the next update
+	if len(detail.Trail) == 0 || detail.Trail[len(detail.Trail)-1].Timestamp != ac.Timestamp {
+		detail.Trail = append(detail.Trail, models.AircraftHistoryModel{
+			Icao: ac.Icao, Latitude: ac.Latitude, Longitude: ac.Longitude, Timestamp: ac.Timestamp,
+		})
+	}
+
+	return detail, nil
+}
+
 // GetAircraftHistoryByIcao retrieves aircraft history from given icao.
+// History older than the raw history is filled in from the downsampled rollup.
 func (svc *RestImpl) GetAircraftHistoryByIcao(icao string) ([]models.AircraftHistoryModel, error) {
-	return svc.DB.SelectAllColumnHistoryByIcao(icao)
+	history, err := svc.DB.SelectAllColumnHistoryByIcao(icao)
+	if err != nil {
+		return nil, err
+	}
+
+	rollup, err := svc.DB.SelectAllColumnHistoryRollupByIcao(icao)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(history, rollup...), nil
 }
 
 // GetAircraftHistoryByIcaoFilterByTimestamp retrieves aircraft by ICAO code and limits the results by only retrieving
-// data newer than given hour parameter.
+// data newer than given hour parameter. If the hour reaches past the raw history, the downsampled rollup is used for the
+// older part.
 func (svc *RestImpl) GetAircraftHistoryByIcaoFilterByTimestamp(search string, hour int) ([]models.AircraftHistoryModel, error) {
-	return svc.DB.SelectAllColumnHistoryByIcaoFilterByTimestamp(search, hour)
+	history, err := svc.DB.SelectAllColumnHistoryByIcaoFilterByTimestamp(search, hour)
+	if err != nil {
+		return nil, err
+	}
+
+	rollup, err := svc.DB.SelectAllColumnHistoryRollupByIcaoFilterByTimestamp(search, hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(history, rollup...), nil
+}
+
+// GetAircraftHistoryByIcaoTimeRange retrieves aircraft history within the time range of filter, ordered and limited by
+// filter. The downsampled rollup is older than the raw history, so it is only used for the part of the range, and of
+// the limit, that the raw history does not cover.
+func (svc *RestImpl) GetAircraftHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter) ([]models.AircraftHistoryModel, error) {
+	if filter.Ascending {
+		rollup, err := svc.DB.SelectHistoryRollupByIcaoTimeRange(search, filter)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Limit > 0 && len(rollup) >= filter.Limit {
+			return rollup, nil
+		}
+
+		rawFilter := filter
+		if filter.Limit > 0 {
+			rawFilter.Limit = filter.Limit - len(rollup)
+		}
+		history, err := svc.DB.SelectHistoryByIcaoTimeRange(search, rawFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(rollup, history...), nil
+	}
+
+	history, err := svc.DB.SelectHistoryByIcaoTimeRange(search, filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Limit > 0 && len(history) >= filter.Limit {
+		return history, nil
+	}
+
+	rollupFilter := filter
+	if filter.Limit > 0 {
+		rollupFilter.Limit = filter.Limit - len(history)
+	}
+	rollup, err := svc.DB.SelectHistoryRollupByIcaoTimeRange(search, rollupFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(history, rollup...), nil
+}
+
+// StreamAircraftHistoryByIcaoTimeRange calls handle for every row of the aircraft history within the time range of
+// filter, in the order of filter, without buffering the history. The downsampled rollup is streamed before the raw
+// history when ascending, and after it when descending. The limit of filter is not applied.
+func (svc *RestImpl) StreamAircraftHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter,
+	handle func(models.AircraftHistoryModel) error) error {
+	filter.Limit = 0
+
+	if filter.Ascending {
+		err := svc.DB.StreamHistoryRollupByIcaoTimeRange(search, filter, handle)
+		if err != nil {
+			return err
+		}
+		return svc.DB.StreamHistoryByIcaoTimeRange(search, filter, handle)
+	}
+
+	err := svc.DB.StreamHistoryByIcaoTimeRange(search, filter, handle)
+	if err != nil {
+		return err
+	}
+	return svc.DB.StreamHistoryRollupByIcaoTimeRange(search, filter, handle)
+}
+
+// GetAircraftRegistryByIcao retrieves the registry data of the aircraft with the given icao.
+// Returns nil if the aircraft is not registered.
+func (svc *RestImpl) GetAircraftRegistryByIcao(search string) (*models.AircraftRegistryModel, error) {
+	return svc.DB.SelectAircraftRegistryByIcao(search)
+}
+
+// SearchAircraft retrieves at most limit aircraft sightings where the callsign or the ICAO code starts with search,
+// most recently seen first.
+func (svc *RestImpl) SearchAircraft(search string, limit int) ([]models.AircraftSightingModel, error) {
+	return svc.DB.SearchAircraftSightings(search, limit)
+}
+
+// GetTrafficStats retrieves the unique aircraft, positions and messages for every hour or day, given by interval, within
+// the time range of filter.
+func (svc *RestImpl) GetTrafficStats(filter models.StatsFilter, interval string) ([]models.TrafficStatsModel, error) {
+	return svc.DB.SelectTrafficStats(filter, interval)
+}
+
+// GetBusiestHours retrieves the limit hours with the most unique aircraft within the time range of filter.
+func (svc *RestImpl) GetBusiestHours(filter models.StatsFilter, limit int) ([]models.TrafficStatsModel, error) {
+	return svc.DB.SelectBusiestHours(filter, limit)
+}
+
+// GetAltitudeStats retrieves a histogram of the altitudes within the time range of filter, in bands of band feet.
+func (svc *RestImpl) GetAltitudeStats(filter models.StatsFilter, band int) ([]models.AltitudeStatsModel, error) {
+	return svc.DB.SelectAltitudeStats(filter, band)
+}
+
+// GetAirlineStats retrieves the limit airlines, by the prefix of their callsigns, with the most unique aircraft within
+// the time range of filter.
+func (svc *RestImpl) GetAirlineStats(filter models.StatsFilter, limit int) ([]models.AirlineStatsModel, error) {
+	return svc.DB.SelectAirlineStats(filter, limit)
+}
+
+// GetCoverage retrieves the farthest position from the receiver in every altitude band and bearing sector over the
+// days of filter.
+func (svc *RestImpl) GetCoverage(filter models.CoverageFilter) ([]models.CoverageModel, error) {
+	return svc.DB.SelectCoverage(filter)
+}
+
+// GetHeatmap retrieves the number of history positions in every cell of the grid of filter, within its time range and
+// bounding box.
+func (svc *RestImpl) GetHeatmap(filter models.HeatmapFilter) ([]models.HeatmapCellModel, error) {
+	return svc.DB.SelectHeatmap(filter)
+}
+
+// StreamHistoryExport calls handle for every history position of every aircraft within the time range and bounding box
+// of filter, ordered by timestamp. Only the raw history is exported, not the downsampled rollup.
+func (svc *RestImpl) StreamHistoryExport(filter models.HistoryExportFilter,
+	handle func(models.AircraftHistoryModel) error) error {
+	return svc.DB.StreamHistoryExport(filter, handle)
+}
+
+// GetApiKey retrieves the API key key, which is looked up by its hash.
+// Returns nil if there is no such key.
+func (svc *RestImpl) GetApiKey(key string) (*models.ApiKeyModel, error) {
+	return svc.DB.SelectApiKeyByHash(apiKey.Hash(key))
 }
@@ -1,11 +1,14 @@
 package restService
 
 import (
+	"adsb-api/internal/db"
 	"adsb-api/internal/global"
+	"adsb-api/internal/global/models"
 	"adsb-api/internal/utility/mock"
 	"adsb-api/internal/utility/testUtility"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +19,13 @@ func TestMain(m *testing.M) {
 	m.Run()
 }
 
+// expectSnapshot expects a snapshot of mockDB, where the reads of the snapshot are made on mockDB.
+func expectSnapshot(mockDB *mock.MockDatabase) {
+	mockDB.EXPECT().Snapshot(gomock.Any()).DoAndReturn(func(read func(snapshot db.Database) error) error {
+		return read(mockDB)
+	})
+}
+
 func Test_InitRestService(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -75,6 +85,7 @@ func TestRestServiceImpl_GetAircraftHistoryByIcao(t *testing.T) {
 	var search = mockData[0].Icao
 
 	mockDB.EXPECT().SelectAllColumnHistoryByIcao(search).Return(mockData, nil)
+	mockDB.EXPECT().SelectAllColumnHistoryRollupByIcao(search).Return(nil, nil)
 
 	res, err := svc.GetAircraftHistoryByIcao(search)
 
@@ -82,6 +93,45 @@ func TestRestServiceImpl_GetAircraftHistoryByIcao(t *testing.T) {
 	assert.Equal(t, mockData, res)
 }
 
+func TestRestServiceImpl_GetAircraftHistoryByIcao_WithRollup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+
+	svc := &RestImpl{DB: mockDB}
+
+	mockData := testUtility.CreateMockHistAircraftWithIcao(10, "TEST")
+	rollupData := testUtility.CreateMockHistAircraftWithIcao(5, "TEST")
+
+	mockDB.EXPECT().SelectAllColumnHistoryByIcao("TEST").Return(mockData, nil)
+	mockDB.EXPECT().SelectAllColumnHistoryRollupByIcao("TEST").Return(rollupData, nil)
+
+	res, err := svc.GetAircraftHistoryByIcao("TEST")
+
+	assert.Nil(t, err)
+	assert.Equal(t, append(mockData, rollupData...), res)
+}
+
+func TestRestServiceImpl_GetAircraftHistoryByIcao_ErrorRetrievingRollup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+
+	svc := &RestImpl{DB: mockDB}
+
+	var errorMsg = "mockData error selecting rollup data"
+	mockDB.EXPECT().SelectAllColumnHistoryByIcao("search").Return(testUtility.CreateMockHistAircraft(2), nil)
+	mockDB.EXPECT().SelectAllColumnHistoryRollupByIcao("search").Return(nil, errors.New(errorMsg))
+
+	res, err := svc.GetAircraftHistoryByIcao("search")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, errorMsg, err.Error())
+	assert.Nil(t, res)
+}
+
 func TestRestServiceImpl_GetAircraftHistoryByIcao_ErrorRetrievingDbData(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -114,6 +164,7 @@ func TestRestImpl_GetAircraftHistoryByIcaoFilterByTimestamp(t *testing.T) {
 	hour := 1
 
 	mockDB.EXPECT().SelectAllColumnHistoryByIcaoFilterByTimestamp(search, hour).Return(mockData, nil)
+	mockDB.EXPECT().SelectAllColumnHistoryRollupByIcaoFilterByTimestamp(search, hour).Return(nil, nil)
 
 	res, err := svc.GetAircraftHistoryByIcaoFilterByTimestamp(search, hour)
 
@@ -138,3 +189,380 @@ func TestRestImpl_GetAircraftHistoryByIcaoFilterByTimestamp_ErrorRetrievingDbDat
 	assert.Equal(t, errorMsg, err.Error())
 	assert.Nil(t, res)
 }
+
+func TestRestImpl_GetAircraftRegistryByIcao(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+
+	svc := &RestImpl{DB: mockDB}
+
+	registry := &models.AircraftRegistryModel{Icao: "ABC123", Registration: "LN-ABC"}
+	mockDB.EXPECT().SelectAircraftRegistryByIcao("ABC123").Return(registry, nil)
+
+	res, err := svc.GetAircraftRegistryByIcao("ABC123")
+
+	assert.Nil(t, err)
+	assert.Equal(t, registry, res)
+}
+
+func TestRestImpl_GetAircraftHistoryByIcaoTimeRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+
+	svc := &RestImpl{DB: mockDB}
+
+	history := testUtility.CreateMockHistAircraftWithIcao(3, "TEST")
+	rollup := testUtility.CreateMockHistAircraftWithIcao(2, "TEST")
+
+	filter := models.AircraftHistoryFilter{Limit: 4}
+	mockDB.EXPECT().SelectHistoryByIcaoTimeRange("TEST", filter).Return(history, nil)
+	mockDB.EXPECT().SelectHistoryRollupByIcaoTimeRange("TEST", models.AircraftHistoryFilter{Limit: 1}).
+		Return(rollup[:1], nil)
+
+	res, err := svc.GetAircraftHistoryByIcaoTimeRange("TEST", filter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, append(history, rollup[:1]...), res)
+}
+
+func TestRestImpl_GetAircraftHistoryByIcaoTimeRange_Ascending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+
+	svc := &RestImpl{DB: mockDB}
+
+	rollup := testUtility.CreateMockHistAircraftWithIcao(2, "TEST")
+
+	// the limit is reached by the rollup, which is older than the raw history
+	filter := models.AircraftHistoryFilter{Limit: 2, Ascending: true}
+	mockDB.EXPECT().SelectHistoryRollupByIcaoTimeRange("TEST", filter).Return(rollup, nil)
+
+	res, err := svc.GetAircraftHistoryByIcaoTimeRange("TEST", filter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, rollup, res)
+}
+
+func TestRestImpl_StreamAircraftHistoryByIcaoTimeRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+
+	svc := &RestImpl{DB: mockDB}
+
+	// the limit is not applied when streaming
+	filter := models.AircraftHistoryFilter{Ascending: true}
+	gomock.InOrder(
+		mockDB.EXPECT().StreamHistoryRollupByIcaoTimeRange("TEST", filter, gomock.Any()).Return(nil),
+		mockDB.EXPECT().StreamHistoryByIcaoTimeRange("TEST", filter, gomock.Any()).Return(nil),
+	)
+
+	err := svc.StreamAircraftHistoryByIcaoTimeRange("TEST", models.AircraftHistoryFilter{Ascending: true, Limit: 10},
+		func(models.AircraftHistoryModel) error { return nil })
+
+	assert.Nil(t, err)
+}
+
+func TestRestImpl_GetCurrentAircraftSince(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	svc := &RestImpl{DB: mockDB}
+
+	now := time.Now().UTC()
+	version := models.AircraftCurrentVersion{Seq: 100, Time: now}
+	since := models.AircraftCurrentVersion{Seq: 90, Time: now.Add(-time.Minute)}
+
+	onGround := false
+	filter := models.AircraftCurrentFilter{OnGround: &onGround}
+	airborne := models.AircraftCurrentModel{Icao: "AAAAAA"}
+	landed := models.AircraftCurrentModel{Icao: "BBBBBB", OnGround: true}
+
+	expectSnapshot(mockDB)
+	mockDB.EXPECT().SelectAircraftCurrentVersion().Return(version, nil)
+	mockDB.EXPECT().SelectAircraftCurrentChangedSince(since.Seq).Return([]models.AircraftCurrentModel{airborne, landed}, nil)
+	mockDB.EXPECT().SelectAircraftCurrentRemovedSince(since.Seq).Return([]string{"CCCCCC"}, nil)
+
+	delta, err := svc.GetCurrentAircraftSince(since, filter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, models.AircraftCurrentDelta{
+		Updated: []models.AircraftCurrentModel{airborne},
+		// the landed aircraft no longer matches the filter
+		Removed: []string{"BBBBBB", "CCCCCC"},
+		Version: version,
+	}, delta)
+}
+
+func TestRestImpl_GetCurrentAircraftSince_Full(t *testing.T) {
+	now := time.Now().UTC()
+	version := models.AircraftCurrentVersion{Seq: 100, Time: now}
+	retention := time.Duration(global.CurrentDeltaRetention) * time.Second
+
+	tests := []struct {
+		name  string
+		since models.AircraftCurrentVersion
+	}{
+		{"Token older than the retention", models.AircraftCurrentVersion{Seq: 90, Time: now.Add(-retention - time.Second)}},
+		{"Token ahead of the database", models.AircraftCurrentVersion{Seq: 110, Time: now}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockDB := mock.NewMockDatabase(ctrl)
+			svc := &RestImpl{DB: mockDB}
+
+			mockData := testUtility.CreateMockAircraft(5)
+			expectSnapshot(mockDB)
+			mockDB.EXPECT().SelectAircraftCurrentVersion().Return(version, nil)
+			mockDB.EXPECT().SelectAircraftCurrentFiltered(models.AircraftCurrentFilter{}).Return(mockData, nil)
+
+			delta, err := svc.GetCurrentAircraftSince(test.since, models.AircraftCurrentFilter{})
+
+			assert.Nil(t, err)
+			assert.Equal(t, models.AircraftCurrentDelta{Updated: mockData, Full: true, Version: version}, delta)
+		})
+	}
+}
+
+func TestRestImpl_GetCurrentAircraftSince_SnapshotError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	svc := &RestImpl{DB: mockDB}
+
+	mockDB.EXPECT().Snapshot(gomock.Any()).Return(errors.New("connection refused"))
+
+	delta, err := svc.GetCurrentAircraftSince(models.AircraftCurrentVersion{}, models.AircraftCurrentFilter{})
+
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, models.AircraftCurrentDelta{}, delta)
+}
+
+func TestRestImpl_GetCurrentAircraftWithVersion(t *testing.T) {
+	version := models.AircraftCurrentVersion{Seq: 100, Time: time.Now().UTC()}
+	onGround := false
+
+	tests := []struct {
+		name   string
+		filter models.AircraftCurrentFilter
+		setup  func(mockDB *mock.MockDatabase, aircraft []models.AircraftCurrentModel)
+	}{
+		{
+			name: "No filter",
+			setup: func(mockDB *mock.MockDatabase, aircraft []models.AircraftCurrentModel) {
+				mockDB.EXPECT().SelectAllColumnsAircraftCurrent().Return(aircraft, nil)
+			},
+		},
+		{
+			name:   "Filter",
+			filter: models.AircraftCurrentFilter{OnGround: &onGround},
+			setup: func(mockDB *mock.MockDatabase, aircraft []models.AircraftCurrentModel) {
+				mockDB.EXPECT().SelectAircraftCurrentFiltered(models.AircraftCurrentFilter{OnGround: &onGround}).
+					Return(aircraft, nil)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockDB := mock.NewMockDatabase(ctrl)
+			svc := &RestImpl{DB: mockDB}
+
+			mockData := testUtility.CreateMockAircraft(5)
+			expectSnapshot(mockDB)
+			mockDB.EXPECT().SelectAircraftCurrentVersion().Return(version, nil)
+			test.setup(mockDB, mockData)
+
+			res, resVersion, err := svc.GetCurrentAircraftWithVersion(test.filter)
+
+			assert.Nil(t, err)
+			assert.Equal(t, mockData, res)
+			assert.Equal(t, version, resVersion)
+		})
+	}
+}
+
+func TestRestImpl_GetCurrentAircraftByIcao(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	svc := &RestImpl{DB: mockDB}
+
+	aircraft := models.AircraftCurrentModel{Icao: "ABC123", Latitude: 60, Longitude: 5, Timestamp: "2024-01-01T10:05:00Z"}
+	history := []models.AircraftHistoryModel{
+		{Icao: "ABC123", Latitude: 59, Longitude: 5, Timestamp: "2024-01-01T09:58:00Z"},
+		{Icao: "ABC123", Latitude: 59.5, Longitude: 5, Timestamp: "2024-01-01T10:04:00Z"},
+	}
+	filter := models.AircraftHistoryFilter{From: time.Date(2024, 1, 1, 9, 55, 0, 0, time.UTC), Ascending: true}
+
+	mockDB.EXPECT().SelectAircraftCurrentByIcao("abc123").Return(&aircraft, nil)
+	mockDB.EXPECT().SelectHistoryByIcaoTimeRange("ABC123", filter).Return(history, nil)
+
+	detail, err := svc.GetCurrentAircraftByIcao("abc123", 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, aircraft, detail.Aircraft)
+	// the trail ends with the current position, which is not in the history yet
+	assert.Equal(t, append(history, models.AircraftHistoryModel{
+		Icao: "ABC123", Latitude: 60, Longitude: 5, Timestamp: "2024-01-01T10:05:00Z",
+	}), detail.Trail)
+}
+
+func TestRestImpl_GetCurrentAircraftByIcao_NotTracked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	svc := &RestImpl{DB: mockDB}
+
+	mockDB.EXPECT().SelectAircraftCurrentByIcao("ABC123").Return(nil, nil)
+
+	detail, err := svc.GetCurrentAircraftByIcao("ABC123", 10)
+
+	assert.Nil(t, err)
+	assert.Nil(t, detail)
+}
+
+func TestRestImpl_SearchAircraft(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	svc := &RestImpl{DB: mockDB}
+
+	sightings := []models.AircraftSightingModel{{Icao: "4CA2D1", Callsign: "SAS123", LastSeen: "2024-04-11T20:15:18Z"}}
+	mockDB.EXPECT().SearchAircraftSightings("sas", 20).Return(sightings, nil)
+
+	res, err := svc.SearchAircraft("sas", 20)
+
+	assert.Nil(t, err)
+	assert.Equal(t, sightings, res)
+}
+
+func TestRestImpl_Stats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	svc := &RestImpl{DB: mockDB}
+
+	filter := models.StatsFilter{
+		From: time.Date(2024, 4, 8, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC),
+	}
+	traffic := []models.TrafficStatsModel{{Time: "2024-04-11T20:00:00Z", Aircraft: 42, Positions: 3120, Messages: 9360}}
+	altitudes := []models.AltitudeStatsModel{{Min: 35000, Max: 36000, Aircraft: 12, Positions: 840}}
+	airlines := []models.AirlineStatsModel{{Prefix: "SAS", Aircraft: 18, Callsigns: 25, Positions: 1460}}
+
+	mockDB.EXPECT().SelectTrafficStats(filter, "day").Return(traffic, nil)
+	mockDB.EXPECT().SelectBusiestHours(filter, 5).Return(traffic, nil)
+	mockDB.EXPECT().SelectAltitudeStats(filter, 1000).Return(altitudes, nil)
+	mockDB.EXPECT().SelectAirlineStats(filter, 10).Return(airlines, nil)
+
+	trafficRes, err := svc.GetTrafficStats(filter, "day")
+	assert.Nil(t, err)
+	assert.Equal(t, traffic, trafficRes)
+
+	busiestRes, err := svc.GetBusiestHours(filter, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, traffic, busiestRes)
+
+	altitudeRes, err := svc.GetAltitudeStats(filter, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, altitudes, altitudeRes)
+
+	airlineRes, err := svc.GetAirlineStats(filter, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, airlines, airlineRes)
+}
+
+func TestRestImpl_GetCoverage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	svc := &RestImpl{DB: mockDB}
+
+	filter := models.CoverageFilter{From: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)}
+	coverage := []models.CoverageModel{{Band: 10000, Sector: 12, Range: 210, Latitude: 58.5, Longitude: 6.1}}
+	mockDB.EXPECT().SelectCoverage(filter).Return(coverage, nil)
+
+	res, err := svc.GetCoverage(filter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, coverage, res)
+}
+
+func TestRestImpl_GetHeatmap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	svc := &RestImpl{DB: mockDB}
+
+	filter := models.HeatmapFilter{
+		From: time.Date(2024, 4, 11, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 4, 12, 0, 0, 0, 0, time.UTC),
+		Cell: 0.1,
+	}
+	cells := []models.HeatmapCellModel{{X: 52, Y: 603, Count: 120}}
+	mockDB.EXPECT().SelectHeatmap(filter).Return(cells, nil)
+
+	res, err := svc.GetHeatmap(filter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, cells, res)
+}
+
+func TestRestImpl_StreamHistoryExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	svc := &RestImpl{DB: mockDB}
+
+	filter := models.HistoryExportFilter{
+		From: time.Date(2024, 4, 11, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 4, 12, 0, 0, 0, 0, time.UTC),
+	}
+	mockDB.EXPECT().StreamHistoryExport(filter, gomock.Any()).Return(errors.New("expected error"))
+
+	err := svc.StreamHistoryExport(filter, func(models.AircraftHistoryModel) error { return nil })
+
+	assert.EqualError(t, err, "expected error")
+}
+
+func TestRestImpl_GetApiKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+
+	svc := &RestImpl{DB: mockDB}
+
+	key := &models.ApiKeyModel{Name: "dashboard", Prefix: "adsb_abcdef", Scopes: []string{"live"}}
+	// the key is looked up by its SHA-256 hash
+	mockDB.EXPECT().SelectApiKeyByHash("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824").Return(key, nil)
+
+	res, err := svc.GetApiKey("hello")
+
+	assert.Nil(t, err)
+	assert.Equal(t, key, res)
+}
@@ -2,9 +2,11 @@ package sbsService
 
 import (
 	"adsb-api/internal/db"
+	"adsb-api/internal/global"
 	"adsb-api/internal/global/models"
 	"adsb-api/internal/service/cronScheduler"
 	"adsb-api/internal/service/cronScheduler/jobs/cleanupJob"
+	"adsb-api/internal/service/cronScheduler/jobs/coverageJob"
 )
 
 // SbsService represents a service with an interface for retrieving database data through the repository in
@@ -13,6 +15,8 @@ type SbsService interface {
 	CreateAdsbTables() error
 	InsertNewSbsData(aircraft []models.AircraftCurrentModel) error
 	ScheduleCleanUpJob(schedule string, days int) error
+	ScheduleTieredCleanUpJob(schedule string, days int, resolution int, rollupDays int, archiveDir string) error
+	ScheduleCoverageJob(schedule string, latitude float64, longitude float64, sectors int, band int, days int) error
 }
 
 type SbsImpl struct {
@@ -32,6 +36,11 @@ func (svc *SbsImpl) CreateAdsbTables() error {
 		return err
 	}
 
+	err = svc.DB.CreateAircraftCurrentRemovedTable()
+	if err != nil {
+		return err
+	}
+
 	err = svc.DB.Begin()
 	if err != nil {
 		return err
@@ -52,6 +61,36 @@ func (svc *SbsImpl) CreateAdsbTables() error {
 		return err
 	}
 
+	err = svc.DB.CreateAircraftHistoryRollupTable()
+	if err != nil {
+		return err
+	}
+
+	err = svc.DB.CreateAircraftHistoryRollupTimestampIndex()
+	if err != nil {
+		return err
+	}
+
+	err = svc.DB.CreateAircraftSightingTable()
+	if err != nil {
+		return err
+	}
+
+	err = svc.DB.CreateAircraftCoverageTable()
+	if err != nil {
+		return err
+	}
+
+	err = svc.DB.CreateAircraftRegistryTable()
+	if err != nil {
+		return err
+	}
+
+	err = svc.DB.CreateApiKeyTable()
+	if err != nil {
+		return err
+	}
+
 	err = svc.DB.Commit()
 	if err != nil {
 		return err
@@ -61,7 +100,9 @@ func (svc *SbsImpl) CreateAdsbTables() error {
 }
 
 // InsertNewSbsData adds new SBS data to the database.
-// First process the SBS stream and then add that data to the database.
+// First process the SBS stream and then add that data to the database. aircraft_current is updated in place rather than
+// recreated, so that the aircraft that changed and the aircraft that are gone can be tracked for ?since= deltas. The
+// callsign of every aircraft is recorded in aircraft_sighting for searching.
 func (svc *SbsImpl) InsertNewSbsData(aircraft []models.AircraftCurrentModel) error {
 	err := svc.DB.InsertHistoryFromCurrent()
 	if err != nil {
@@ -78,17 +119,26 @@ func (svc *SbsImpl) InsertNewSbsData(aircraft []models.AircraftCurrentModel) err
 		}
 	}()
 
-	err = svc.DB.DropAircraftCurrentTable()
+	err = svc.DB.BulkUpsertAircraftCurrent(aircraft)
 	if err != nil {
 		return err
 	}
 
-	err = svc.DB.CreateAircraftCurrentTable()
+	icaos := make([]string, 0, len(aircraft))
+	for _, ac := range aircraft {
+		icaos = append(icaos, ac.Icao)
+	}
+	err = svc.DB.DeleteAircraftCurrentExcept(icaos)
 	if err != nil {
 		return err
 	}
 
-	err = svc.DB.BulkInsertAircraftCurrent(aircraft)
+	err = svc.DB.DeleteOldAircraftCurrentRemoved(global.CurrentDeltaRetention)
+	if err != nil {
+		return err
+	}
+
+	err = svc.DB.UpsertAircraftSightingsFromCurrent()
 	if err != nil {
 		return err
 	}
@@ -108,6 +158,21 @@ func (svc *SbsImpl) ScheduleCleanUpJob(schedule string, days int) error {
 	return svc.CronScheduler.ScheduleJob(schedule, job.Execute)
 }
 
+// ScheduleTieredCleanUpJob schedules a cleanupJob job that archives old history to archiveDir and downsamples it into
+// the rollup table, keeping one point every resolution seconds, before removing it. The rollup is kept for rollupDays
+// days.
+func (svc *SbsImpl) ScheduleTieredCleanUpJob(schedule string, days int, resolution int, rollupDays int, archiveDir string) error {
+	job := cleanupJob.NewTieredCleanupJob(svc.DB, days, resolution, rollupDays, archiveDir)
+	return svc.CronScheduler.ScheduleJob(schedule, job.Execute)
+}
+
+// ScheduleCoverageJob schedules a coverageJob job that updates the coverage of the receiver at latitude and longitude,
+// in sectors bearing sectors and altitude bands of band feet, from the history. The coverage is kept for days days.
+func (svc *SbsImpl) ScheduleCoverageJob(schedule string, latitude float64, longitude float64, sectors int, band int, days int) error {
+	job := coverageJob.NewCoverageJob(svc.DB, latitude, longitude, sectors, band, days)
+	return svc.CronScheduler.ScheduleJob(schedule, job.Execute)
+}
+
 // StartScheduler starts the cron scheduler.
 // Every job scheduled before this method is called will begin.
 // Jobs scheduled after this method will still be executed.
@@ -37,9 +37,16 @@ func TestSbsServiceImpl_CreateAdsbTables(t *testing.T) {
 	svc := &SbsImpl{DB: mockDB}
 
 	mockDB.EXPECT().CreateAircraftCurrentTable().Return(nil)
+	mockDB.EXPECT().CreateAircraftCurrentRemovedTable().Return(nil)
 	mockDB.EXPECT().Begin().Return(nil)
 	mockDB.EXPECT().CreateAircraftHistoryTable().Return(nil)
 	mockDB.EXPECT().CreateAircraftHistoryTimestampIndex().Return(nil)
+	mockDB.EXPECT().CreateAircraftHistoryRollupTable().Return(nil)
+	mockDB.EXPECT().CreateAircraftHistoryRollupTimestampIndex().Return(nil)
+	mockDB.EXPECT().CreateAircraftSightingTable().Return(nil)
+	mockDB.EXPECT().CreateAircraftCoverageTable().Return(nil)
+	mockDB.EXPECT().CreateAircraftRegistryTable().Return(nil)
+	mockDB.EXPECT().CreateApiKeyTable().Return(nil)
 	mockDB.EXPECT().Commit().Return(nil)
 	err := svc.CreateAdsbTables()
 
@@ -57,6 +64,7 @@ func TestSbsServiceImpl_CreateAdsbTables_WithRollback(t *testing.T) {
 	var errorMsg = "mocking errorMsg creating table, should rollback transaction"
 
 	mockDB.EXPECT().CreateAircraftCurrentTable().Return(nil)
+	mockDB.EXPECT().CreateAircraftCurrentRemovedTable().Return(nil)
 	mockDB.EXPECT().Begin().Return(nil)
 	mockDB.EXPECT().CreateAircraftHistoryTable().Return(nil)
 	mockDB.EXPECT().CreateAircraftHistoryTimestampIndex().Return(errors.New(errorMsg))
@@ -79,9 +87,10 @@ func TestSbsServiceImpl_InsertNewSbsData(t *testing.T) {
 
 	mockDB.EXPECT().InsertHistoryFromCurrent().Return(nil)
 	mockDB.EXPECT().Begin().Return(nil)
-	mockDB.EXPECT().DropAircraftCurrentTable().Return(nil)
-	mockDB.EXPECT().CreateAircraftCurrentTable().Return(nil)
-	mockDB.EXPECT().BulkInsertAircraftCurrent(mockData).Return(nil)
+	mockDB.EXPECT().BulkUpsertAircraftCurrent(mockData).Return(nil)
+	mockDB.EXPECT().DeleteAircraftCurrentExcept(gomock.Len(len(mockData))).Return(nil)
+	mockDB.EXPECT().DeleteOldAircraftCurrentRemoved(global.CurrentDeltaRetention).Return(nil)
+	mockDB.EXPECT().UpsertAircraftSightingsFromCurrent().Return(nil)
 	mockDB.EXPECT().Commit().Return(nil)
 
 	err := svc.InsertNewSbsData(mockData)
@@ -99,11 +108,12 @@ func TestSbsServiceImpl_InsertNewSbsData_WithRollback(t *testing.T) {
 
 	mockData := testUtility.CreateMockAircraft(100)
 
-	var errorMsg = "mocking errorMsg creating table, should rollback transaction"
+	var errorMsg = "mocking errorMsg deleting removed aircraft, should rollback transaction"
 
 	mockDB.EXPECT().InsertHistoryFromCurrent().Return(nil)
 	mockDB.EXPECT().Begin().Return(nil)
-	mockDB.EXPECT().DropAircraftCurrentTable().Return(errors.New(errorMsg))
+	mockDB.EXPECT().BulkUpsertAircraftCurrent(mockData).Return(nil)
+	mockDB.EXPECT().DeleteAircraftCurrentExcept(gomock.Any()).Return(errors.New(errorMsg))
 	mockDB.EXPECT().Rollback().Return(nil)
 
 	err := svc.InsertNewSbsData(mockData)
@@ -151,6 +161,40 @@ func TestSbsImpl_ScheduleCleanUpJob_ErrorScheduleJob(t *testing.T) {
 	assert.Equal(t, errorMessage, err.Error())
 }
 
+func TestSbsImpl_ScheduleTieredCleanUpJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	mockCron := mock.NewMockScheduler(ctrl)
+	svc := &SbsImpl{DB: mockDB, CronScheduler: mockCron}
+
+	schedule := "* * * * *"
+
+	mockCron.EXPECT().ScheduleJob(schedule, gomock.Any()).Return(nil)
+
+	err := svc.ScheduleTieredCleanUpJob(schedule, 5, 60, 30, "")
+
+	assert.Nil(t, err)
+}
+
+func TestSbsImpl_ScheduleCoverageJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mock.NewMockDatabase(ctrl)
+	mockCron := mock.NewMockScheduler(ctrl)
+	svc := &SbsImpl{DB: mockDB, CronScheduler: mockCron}
+
+	schedule := "5 * * * *"
+
+	mockCron.EXPECT().ScheduleJob(schedule, gomock.Any()).Return(nil)
+
+	err := svc.ScheduleCoverageJob(schedule, 60.39, 5.32, 36, 10000, 90)
+
+	assert.Nil(t, err)
+}
+
 func TestSbsImpl_StartScheduler(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -0,0 +1,211 @@
+package streamService
+
+import (
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/service/restService"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// StreamService is an interface representing a live feed of the current aircraft. The current aircraft are polled
+// through the RestService, and the changes since the previous poll are published to every subscriber as an Event.
+type StreamService interface {
+	Poll() error
+	Run(interval time.Duration, stop <-chan struct{})
+	Subscribe(lastEventID string) *Subscription
+}
+
+// Event represents the changes to the current aircraft after an ingestion cycle.
+// A snapshot event holds every current aircraft in Updated and replaces the state of the subscriber.
+type Event struct {
+	// ID is unique to this instance of the service, so a Last-Event-ID from before a restart starts with a snapshot
+	ID       string
+	Snapshot bool
+	Updated  []models.AircraftCurrentModel
+	Removed  []string
+
+	seq uint64
+}
+
+// Subscription represents a subscriber of the live feed.
+type Subscription struct {
+	// Events are sent before any event from C. It is either the events after the Last-Event-ID of the subscriber, or a
+	// snapshot if those events are no longer kept.
+	Events []Event
+	// State is the current aircraft after the last of Events
+	State []models.AircraftCurrentModel
+	// C receives the following events. It is closed if the subscriber does not keep up, and can then resume from the
+	// ID of the last event it received.
+	C <-chan Event
+
+	svc *StreamImpl
+	c   chan Event
+}
+
+// Close unsubscribes from the live feed.
+func (sub *Subscription) Close() {
+	sub.svc.unsubscribe(sub.c)
+}
+
+type StreamImpl struct {
+	RestSvc restService.RestService
+	// HistorySize is the number of events kept for subscribers resuming with Last-Event-ID
+	HistorySize int
+	// BufferSize is the number of events a subscriber can fall behind before it is dropped
+	BufferSize int
+
+	mu          sync.Mutex
+	instance    string
+	seq         uint64
+	state       map[string]models.AircraftCurrentModel
+	history     []Event
+	subscribers map[chan Event]struct{}
+}
+
+// InitStreamService initializes StreamImpl struct, keeping historySize events for resuming subscribers.
+func InitStreamService(svc restService.RestService, historySize int) *StreamImpl {
+	return &StreamImpl{
+		RestSvc:     svc,
+		HistorySize: historySize,
+		BufferSize:  16,
+		instance:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		state:       make(map[string]models.AircraftCurrentModel),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Run polls the current aircraft every interval until stop is closed.
+func (svc *StreamImpl) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := svc.Poll(); err != nil {
+			log.Error().Msgf(errorMsg.ErrorPollingCurrentAircraft+": %q", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Poll retrieves the current aircraft and publishes the aircraft that are new or have changed, and the aircraft that
+// are gone, since the previous poll. Nothing is published if nothing has changed.
+func (svc *StreamImpl) Poll() error {
+	aircraft, err := svc.RestSvc.GetCurrentAircraft()
+	if err != nil {
+		return err
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	var event Event
+	state := make(map[string]models.AircraftCurrentModel, len(aircraft))
+	for _, ac := range aircraft {
+		state[ac.Icao] = ac
+		if previous, exist := svc.state[ac.Icao]; !exist || previous != ac {
+			event.Updated = append(event.Updated, ac)
+		}
+	}
+	for icao := range svc.state {
+		if _, exist := state[icao]; !exist {
+			event.Removed = append(event.Removed, icao)
+		}
+	}
+
+	svc.state = state
+	if len(event.Updated) == 0 && len(event.Removed) == 0 {
+		return nil
+	}
+
+	svc.seq++
+	event.seq = svc.seq
+	event.ID = svc.eventID(svc.seq)
+
+	svc.history = append(svc.history, event)
+	if len(svc.history) > svc.HistorySize {
+		svc.history = svc.history[len(svc.history)-svc.HistorySize:]
+	}
+
+	for c := range svc.subscribers {
+		select {
+		case c <- event:
+		default:
+			// the subscriber does not keep up, it is dropped and can resume from its last event
+			delete(svc.subscribers, c)
+			close(c)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe subscribes to the live feed. If lastEventID is the ID of an event that is still kept, the subscription
+// resumes after it, otherwise it starts with a snapshot.
+func (svc *StreamImpl) Subscribe(lastEventID string) *Subscription {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	c := make(chan Event, svc.BufferSize)
+	svc.subscribers[c] = struct{}{}
+
+	sub := &Subscription{C: c, svc: svc, c: c}
+	for _, ac := range svc.state {
+		sub.State = append(sub.State, ac)
+	}
+
+	if events, ok := svc.eventsAfter(lastEventID); ok {
+		sub.Events = events
+	} else {
+		sub.Events = []Event{{ID: svc.eventID(svc.seq), Snapshot: true, Updated: sub.State, seq: svc.seq}}
+	}
+
+	return sub
+}
+
+// eventsAfter returns the kept events after the event with the given ID. Returns false if the ID is not valid, or
+// if some of the events after it are no longer kept.
+func (svc *StreamImpl) eventsAfter(lastEventID string) ([]Event, bool) {
+	instance, seqStr, found := strings.Cut(lastEventID, "-")
+	if !found || instance != svc.instance {
+		return nil, false
+	}
+
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || seq > svc.seq {
+		return nil, false
+	}
+	if seq == svc.seq {
+		return nil, true
+	}
+	if len(svc.history) == 0 || svc.history[0].seq > seq+1 {
+		return nil, false
+	}
+
+	return append([]Event(nil), svc.history[seq+1-svc.history[0].seq:]...), true
+}
+
+// eventID returns the ID of the event with the given sequence number.
+func (svc *StreamImpl) eventID(seq uint64) string {
+	return svc.instance + "-" + strconv.FormatUint(seq, 10)
+}
+
+// unsubscribe removes the subscriber c, unless it has already been dropped.
+func (svc *StreamImpl) unsubscribe(c chan Event) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	if _, exist := svc.subscribers[c]; exist {
+		delete(svc.subscribers, c)
+		close(c)
+	}
+}
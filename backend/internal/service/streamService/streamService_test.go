@@ -0,0 +1,153 @@
+package streamService
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/utility/mock"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+var (
+	aircraftA = models.AircraftCurrentModel{Icao: "AAA111", Callsign: "TEST1", Altitude: 1000, Latitude: 60, Longitude: 5}
+	aircraftB = models.AircraftCurrentModel{Icao: "BBB222", Callsign: "TEST2", Altitude: 2000, Latitude: 61, Longitude: 6}
+)
+
+// setupPolls returns a StreamImpl polling each of polls in order.
+func setupPolls(t *testing.T, historySize int, polls ...[]models.AircraftCurrentModel) *StreamImpl {
+	ctrl := gomock.NewController(t)
+	mockSvc := mock.NewMockRestService(ctrl)
+	for _, poll := range polls {
+		mockSvc.EXPECT().GetCurrentAircraft().Return(poll, nil)
+	}
+	return InitStreamService(mockSvc, historySize)
+}
+
+func TestStreamImpl_Poll(t *testing.T) {
+	movedB := aircraftB
+	movedB.Latitude = 62
+
+	svc := setupPolls(t, 10,
+		[]models.AircraftCurrentModel{aircraftA, aircraftB},
+		[]models.AircraftCurrentModel{aircraftA, aircraftB},
+		[]models.AircraftCurrentModel{movedB},
+	)
+	sub := svc.Subscribe("")
+	defer sub.Close()
+
+	require.NoError(t, svc.Poll())
+	event := <-sub.C
+	assert.False(t, event.Snapshot)
+	assert.ElementsMatch(t, []models.AircraftCurrentModel{aircraftA, aircraftB}, event.Updated)
+	assert.Empty(t, event.Removed)
+
+	// nothing has changed, so nothing is published
+	require.NoError(t, svc.Poll())
+	assert.Len(t, sub.C, 0)
+
+	require.NoError(t, svc.Poll())
+	event = <-sub.C
+	assert.Equal(t, []models.AircraftCurrentModel{movedB}, event.Updated)
+	assert.Equal(t, []string{aircraftA.Icao}, event.Removed)
+}
+
+func TestStreamImpl_PollError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSvc := mock.NewMockRestService(ctrl)
+	mockSvc.EXPECT().GetCurrentAircraft().Return(nil, errors.New("database error"))
+
+	svc := InitStreamService(mockSvc, 10)
+	assert.Error(t, svc.Poll())
+}
+
+func TestStreamImpl_Subscribe(t *testing.T) {
+	// only the last 2 of the 3 events are kept
+	svc := setupPolls(t, 2,
+		[]models.AircraftCurrentModel{aircraftA},
+		[]models.AircraftCurrentModel{aircraftA, aircraftB},
+		[]models.AircraftCurrentModel{aircraftB},
+	)
+	require.NoError(t, svc.Poll())
+	first := svc.history[0].ID
+	require.NoError(t, svc.Poll())
+	second := svc.history[1].ID
+	require.NoError(t, svc.Poll())
+	third := svc.history[1].ID
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		snapshot    bool
+		events      int
+	}{
+		{name: "No Last-Event-ID", lastEventID: "", snapshot: true},
+		{name: "Invalid Last-Event-ID", lastEventID: "invalid", snapshot: true},
+		{name: "Last-Event-ID from another instance", lastEventID: "other-1", snapshot: true},
+		{name: "Last-Event-ID in the future", lastEventID: svc.eventID(4), snapshot: true},
+		{name: "Events no longer kept", lastEventID: svc.eventID(0), snapshot: true},
+		{name: "Resume after the first event", lastEventID: first, events: 2},
+		{name: "Resume after the second event", lastEventID: second, events: 1},
+		{name: "Resume after the last event", lastEventID: third, events: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sub := svc.Subscribe(test.lastEventID)
+			defer sub.Close()
+
+			assert.Equal(t, []models.AircraftCurrentModel{aircraftB}, sub.State)
+			if test.snapshot {
+				require.Len(t, sub.Events, 1)
+				assert.True(t, sub.Events[0].Snapshot)
+				assert.Equal(t, third, sub.Events[0].ID)
+				assert.Equal(t, []models.AircraftCurrentModel{aircraftB}, sub.Events[0].Updated)
+				return
+			}
+
+			assert.Len(t, sub.Events, test.events)
+			if test.events > 0 {
+				assert.Equal(t, third, sub.Events[len(sub.Events)-1].ID)
+			}
+		})
+	}
+}
+
+func TestStreamImpl_SlowSubscriberDropped(t *testing.T) {
+	var polls [][]models.AircraftCurrentModel
+	for i := 0; i < 3; i++ {
+		ac := aircraftA
+		ac.Altitude = i
+		polls = append(polls, []models.AircraftCurrentModel{ac})
+	}
+
+	svc := setupPolls(t, 10, polls...)
+	svc.BufferSize = 1
+	sub := svc.Subscribe("")
+
+	for range polls {
+		require.NoError(t, svc.Poll())
+	}
+
+	event, open := <-sub.C
+	assert.True(t, open)
+	assert.Equal(t, svc.eventID(1), event.ID)
+	_, open = <-sub.C
+	assert.False(t, open, "subscriber not keeping up should be dropped")
+	assert.Empty(t, svc.subscribers)
+
+	// closing a dropped subscription does nothing
+	sub.Close()
+
+	resumed := svc.Subscribe(event.ID)
+	defer resumed.Close()
+	assert.Len(t, resumed.Events, 2)
+}
@@ -0,0 +1,60 @@
+package apiKey
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// keyPrefix starts every API key, so that a leaked key is easy to recognize
+const keyPrefix = "adsb_"
+
+// prefixLength is the number of characters of a key that are stored in clear text, to tell the keys apart
+const prefixLength = len(keyPrefix) + 6
+
+// Generate returns a new random API key, with 256 bits of randomness.
+func Generate() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+// Hash returns the SHA-256 hash of key as hexadecimal, which is what is stored of the key. A salt is not needed, as the
+// keys are random rather than chosen by their users.
+func Hash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// Prefix returns the start of key that is stored in clear text.
+func Prefix(key string) string {
+	if len(key) < prefixLength {
+		return key
+	}
+	return key[:prefixLength]
+}
+
+// ParseScopes parses a comma separated list of scopes, ignoring case and empty entries. Returns an error if a scope is
+// not in global.ApiKeyScopes.
+func ParseScopes(list string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(list, ",") {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" || slices.Contains(scopes, scope) {
+			continue
+		}
+		if !slices.Contains(global.ApiKeyScopes, scope) {
+			return nil, fmt.Errorf(errorMsg.InvalidApiKeyScopes, strings.Join(global.ApiKeyScopes, ", "))
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
@@ -0,0 +1,63 @@
+package apiKey
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+func TestGenerate(t *testing.T) {
+	key, err := Generate()
+	if err != nil {
+		t.Fatalf("error generating key: %q", err)
+	}
+	other, err := Generate()
+	if err != nil {
+		t.Fatalf("error generating key: %q", err)
+	}
+
+	assert.True(t, strings.HasPrefix(key, keyPrefix))
+	assert.Len(t, key, len(keyPrefix)+43)
+	assert.NotEqual(t, key, other)
+	assert.Equal(t, key[:prefixLength], Prefix(key))
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", Hash("hello"))
+	assert.Len(t, Hash("adsb_key"), 64)
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name, list string
+		scopes     []string
+		err        error
+	}{
+		{name: "Every scope", list: "live,history,export", scopes: []string{"live", "history", "export"}},
+		{name: "Case and spaces", list: " Live , HISTORY", scopes: []string{"live", "history"}},
+		{name: "Duplicates and empty entries", list: "live,,live", scopes: []string{"live"}},
+		{name: "No scopes", list: "", scopes: nil},
+		{
+			name: "Unknown scope",
+			list: "live,admin",
+			err:  fmt.Errorf(errorMsg.InvalidApiKeyScopes, "live, history, export"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scopes, err := ParseScopes(tt.list)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.scopes, scopes)
+		})
+	}
+}
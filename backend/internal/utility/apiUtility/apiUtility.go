@@ -2,50 +2,135 @@ package apiUtility
 
 import (
 	"adsb-api/internal/global/errorMsg"
-	"encoding/json"
+	"compress/gzip"
+	"encoding/xml"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
-	"path"
 	"strings"
 )
 
-// EncodeJsonData encodes a struct to json and writes it to the response writer. Returns an error if the encoding fails.
+// EncodeJsonData encodes a struct to json and writes it to the response writer, regardless of the Accept header of the
+// request. Returns an error if the encoding fails.
 func EncodeJsonData(w http.ResponseWriter, data interface{}) error {
 	w.Header().Add("content-type", "application/json")
 	w.Header().Add("Access-Control-Allow-Origin", "*")
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "\t")
-	return encoder.Encode(data)
+	return jsonEncoder{indent: "\t"}.Encode(w, data)
 }
 
-// ValidateURL checks the validity of an HTTP request URL.
-// 1. Cleans the URL and verifies the URL length
-// 2. It checks parameters in the url against parameter optionalParams,
-// if endpoint does not use parameters leaves params nil.
-//
-// If the URL length exceeds the maximum length or if any of the specified parameters are missing from the request, it
-// writes to the ResponseWriter with appropriate status codes and returns an error.
-func ValidateURL(w http.ResponseWriter, r *http.Request, maxLength int, optionalParams []string) error {
-	url := strings.Split(path.Clean(r.URL.Path), "/")
-	if len(url) > maxLength {
-		http.Error(w, errorMsg.ErrorTongURL, http.StatusRequestURITooLong)
-		return fmt.Errorf("falied to validate URL")
+// EncodeJsonFile encodes a struct to json and writes it to the response writer as a file download named filename.
+// Returns an error if the encoding fails.
+func EncodeJsonFile(w http.ResponseWriter, data interface{}, filename string) error {
+	w.Header().Add("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return EncodeJsonData(w, data)
+}
+
+// EncodeXmlData encodes a struct to xml and writes it to the response writer as a file download named filename, with
+// the contentType of the file format. Returns an error if the encoding fails.
+func EncodeXmlData(w http.ResponseWriter, data interface{}, contentType string, filename string) error {
+	w.Header().Add("content-type", contentType)
+	w.Header().Add("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
 	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	err = encoder.Encode(data)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// JsonStreamWriter writes a JSON response that is encoded while it is written, such as a streamed export.
+// The headers of EncodeJsonData are added on the first write, so that another response can be sent if nothing is
+// written.
+type JsonStreamWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
 
-	if r.URL.Query().Encode() == "" {
-		return nil
+// NewJsonStreamWriter returns a JsonStreamWriter writing to w.
+func NewJsonStreamWriter(w http.ResponseWriter) *JsonStreamWriter {
+	return &JsonStreamWriter{w: w}
+}
+
+// Write writes p to the response, adding the headers first if this is the first write.
+func (jw *JsonStreamWriter) Write(p []byte) (int, error) {
+	if !jw.started {
+		jw.w.Header().Add("content-type", "application/json")
+		jw.w.Header().Add("Access-Control-Allow-Origin", "*")
+		jw.started = true
 	}
+	return jw.w.Write(p)
+}
 
-	query := r.URL.Query()
+// FileStreamWriter writes a file download that is encoded while it is written, such as a bulk export. The file is
+// gzip compressed if the client accepts it. The headers are added on the first write, so that another response can be
+// sent if nothing is written.
+type FileStreamWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	compress    bool
+	gz          *gzip.Writer
+	started     bool
+}
 
-	if len(query) != len(optionalParams) {
-		http.Error(w, fmt.Errorf(errorMsg.ErrorInvalidQueryParams+": %s", strings.Join(optionalParams, ", ")).Error(), http.StatusBadRequest)
-		return fmt.Errorf("falied to validate URL")
+// NewFileStreamWriter returns a FileStreamWriter for a file named filename of contentType, which is gzip compressed if
+// the Accept-Encoding header of r includes gzip.
+func NewFileStreamWriter(w http.ResponseWriter, r *http.Request, contentType string, filename string) *FileStreamWriter {
+	compress := false
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		compress = compress || strings.TrimSpace(strings.Split(encoding, ";")[0]) == "gzip"
 	}
+	return &FileStreamWriter{w: w, contentType: contentType, filename: filename, compress: compress}
+}
 
-	for _, param := range optionalParams {
-		values, ok := query[param]
-		if !ok || len(values) == 0 || values[0] == "" {
+// Write writes p to the response, adding the headers first if this is the first write.
+func (fw *FileStreamWriter) Write(p []byte) (int, error) {
+	if !fw.started {
+		fw.w.Header().Add("content-type", fw.contentType)
+		fw.w.Header().Add("Access-Control-Allow-Origin", "*")
+		fw.w.Header().Add("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fw.filename}))
+		fw.w.Header().Add("Vary", "Accept-Encoding")
+		if fw.compress {
+			fw.w.Header().Add("Content-Encoding", "gzip")
+			fw.gz = gzip.NewWriter(fw.w)
+		}
+		fw.started = true
+	}
+	if fw.gz != nil {
+		return fw.gz.Write(p)
+	}
+	return fw.w.Write(p)
+}
+
+// Started reports whether anything has been written to the response.
+func (fw *FileStreamWriter) Started() bool {
+	return fw.started
+}
+
+// Close ends the gzip stream of a compressed file. It does nothing if the file is not compressed.
+func (fw *FileStreamWriter) Close() error {
+	if fw.gz != nil {
+		return fw.gz.Close()
+	}
+	return nil
+}
+
+// ValidateURL checks the query parameters of an HTTP request URL against optionalParams, if endpoint does not use
+// parameters leaves params nil. The path is left to the router, see the router package.
+//
+// If the request has a parameter that is not in optionalParams or a parameter without a value, it writes to the
+// ResponseWriter with appropriate status codes and returns an error. Any subset of optionalParams is valid.
+func ValidateURL(w http.ResponseWriter, r *http.Request, optionalParams []string) error {
+	for param, values := range r.URL.Query() {
+		if !contains(optionalParams, param) || len(values) == 0 || values[0] == "" {
 			http.Error(w, fmt.Errorf(errorMsg.ErrorInvalidQueryParams+": %s", strings.Join(optionalParams, ", ")).Error(), http.StatusBadRequest)
 			return fmt.Errorf("falied to validate URL")
 		}
@@ -54,6 +139,16 @@ func ValidateURL(w http.ResponseWriter, r *http.Request, maxLength int, optional
 	return nil
 }
 
+// contains reports whether value is in list.
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // NoContent sets the Access-Control-Allow-Origin header to "*"
 // and writes a StatusNoContent header to the response writer.
 func NoContent(w http.ResponseWriter) {
@@ -0,0 +1,64 @@
+package apiUtility
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache describes how a response may be cached. ETag and LastModified are its validators, which are left out if they
+// are empty, and MaxAge is the number of seconds it is fresh.
+type Cache struct {
+	ETag         string
+	LastModified time.Time
+	MaxAge       int
+}
+
+// SetHeaders adds the ETag, Last-Modified and Cache-Control headers of the cache to the response.
+func (c Cache) SetHeaders(w http.ResponseWriter) {
+	if c.ETag != "" {
+		w.Header().Set("ETag", c.ETag)
+	}
+	if !c.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", c.LastModified.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(c.MaxAge))
+}
+
+// NotModified reports whether the client already has the response, by the If-None-Match header of r, or by its
+// If-Modified-Since header if there is no If-None-Match. If so, it sends 304 Not Modified with the headers of the cache.
+func (c Cache) NotModified(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	notModified := false
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		notModified = c.ETag != "" && etagMatches(match, c.ETag)
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		// Last-Modified is sent in whole seconds
+		notModified = !c.LastModified.IsZero() && !c.LastModified.Truncate(time.Second).After(since)
+	}
+	if !notModified {
+		return false
+	}
+
+	c.SetHeaders(w)
+	w.Header().Add("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether etag is in the list of entity tags of an If-None-Match header. Weak tags match their
+// strong tag, as If-None-Match uses the weak comparison.
+func etagMatches(list string, etag string) bool {
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
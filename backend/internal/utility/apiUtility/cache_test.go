@@ -0,0 +1,74 @@
+package apiUtility
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheNotModified(t *testing.T) {
+	modified := time.Date(2024, 1, 1, 10, 0, 0, 500, time.UTC)
+	cache := Cache{ETag: `"42-abc"`, LastModified: modified, MaxAge: 10}
+
+	tests := []struct {
+		name, method, ifNoneMatch, ifModifiedSince string
+		notModified                                bool
+	}{
+		{name: "No conditional headers", notModified: false},
+		{name: "Matching ETag", ifNoneMatch: `"42-abc"`, notModified: true},
+		{name: "Weak ETag", ifNoneMatch: `W/"42-abc"`, notModified: true},
+		{name: "ETag in list", ifNoneMatch: `"41-abc", "42-abc"`, notModified: true},
+		{name: "Any ETag", ifNoneMatch: "*", notModified: true},
+		{name: "Changed ETag", ifNoneMatch: `"41-abc"`, notModified: false},
+		{name: "Not modified since", ifModifiedSince: modified.Format(http.TimeFormat), notModified: true},
+		{name: "Modified since", ifModifiedSince: modified.Add(-time.Second).Format(http.TimeFormat), notModified: false},
+		{name: "Invalid date", ifModifiedSince: "yesterday", notModified: false},
+		{
+			// If-Modified-Since is ignored when there is an If-None-Match
+			name:            "Changed ETag not modified since",
+			ifNoneMatch:     `"41-abc"`,
+			ifModifiedSince: modified.Format(http.TimeFormat),
+			notModified:     false,
+		},
+		{name: "Post request", method: http.MethodPost, ifNoneMatch: `"42-abc"`, notModified: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			if tt.ifModifiedSince != "" {
+				r.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+			w := httptest.NewRecorder()
+
+			assert.Equal(t, tt.notModified, cache.NotModified(w, r))
+			if tt.notModified {
+				assert.Equal(t, http.StatusNotModified, w.Code)
+				assert.Equal(t, `"42-abc"`, w.Header().Get("ETag"))
+				assert.Equal(t, "Mon, 01 Jan 2024 10:00:00 GMT", w.Header().Get("Last-Modified"))
+				assert.Equal(t, "public, max-age=10", w.Header().Get("Cache-Control"))
+			} else {
+				assert.Empty(t, w.Header())
+			}
+		})
+	}
+}
+
+func TestCacheSetHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	Cache{MaxAge: 86400}.SetHeaders(w)
+
+	assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Empty(t, w.Header().Get("Last-Modified"))
+}
@@ -0,0 +1,98 @@
+package apiUtility
+
+import (
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CurrentFilterParams are the query parameters filtering current aircraft
+var CurrentFilterParams = []string{"bbox", "minAltitude", "maxAltitude", "minSpeed", "maxSpeed", "callsign", "onGround"}
+
+// ParseCurrentFilter parses the CurrentFilterParams query parameters to an AircraftCurrentFilter.
+// Returns an error with the message for the first invalid parameter.
+func ParseCurrentFilter(query url.Values) (models.AircraftCurrentFilter, error) {
+	var filter models.AircraftCurrentFilter
+
+	if query.Has("bbox") {
+		bbox, err := ParseBbox(query.Get("bbox"))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterBbox)
+		}
+		filter.Bbox = bbox
+	}
+
+	var err error
+	if filter.MinAltitude, err = parseOptionalInt(query, "minAltitude"); err != nil {
+		return filter, errors.New(errorMsg.InvalidQueryParameterAltitude)
+	}
+	if filter.MaxAltitude, err = parseOptionalInt(query, "maxAltitude"); err != nil {
+		return filter, errors.New(errorMsg.InvalidQueryParameterAltitude)
+	}
+	if filter.MinSpeed, err = parseOptionalInt(query, "minSpeed"); err != nil {
+		return filter, errors.New(errorMsg.InvalidQueryParameterSpeed)
+	}
+	if filter.MaxSpeed, err = parseOptionalInt(query, "maxSpeed"); err != nil {
+		return filter, errors.New(errorMsg.InvalidQueryParameterSpeed)
+	}
+
+	filter.CallsignPrefix = strings.TrimSpace(query.Get("callsign"))
+
+	if query.Has("onGround") {
+		onGround, err := strconv.ParseBool(query.Get("onGround"))
+		if err != nil {
+			return filter, errors.New(errorMsg.InvalidQueryParameterOnGround)
+		}
+		filter.OnGround = &onGround
+	}
+
+	return filter, nil
+}
+
+// ParseBbox parses a minLon,minLat,maxLon,maxLat bounding box. minLon is allowed to be greater than maxLon, for a
+// bounding box crossing the antimeridian.
+func ParseBbox(value string) (*models.BoundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("expected 4 values, got %d", len(parts))
+	}
+
+	var coordinates [4]float64
+	for i, part := range parts {
+		coordinate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		// ParseFloat accepts NaN and Inf, and NaN would pass every range check below
+		if math.IsNaN(coordinate) || math.IsInf(coordinate, 0) {
+			return nil, fmt.Errorf("coordinate %q is not a finite number", part)
+		}
+		coordinates[i] = coordinate
+	}
+
+	bbox := &models.BoundingBox{MinLon: coordinates[0], MinLat: coordinates[1], MaxLon: coordinates[2], MaxLat: coordinates[3]}
+	if math.Abs(bbox.MinLon) > 180 || math.Abs(bbox.MaxLon) > 180 || math.Abs(bbox.MinLat) > 90 ||
+		math.Abs(bbox.MaxLat) > 90 || bbox.MinLat > bbox.MaxLat {
+		return nil, fmt.Errorf("coordinates out of range")
+	}
+
+	return bbox, nil
+}
+
+// parseOptionalInt parses the query parameter name as an integer. Returns nil if the parameter is not given.
+func parseOptionalInt(query url.Values, name string) (*int, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(query.Get(name))
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
@@ -0,0 +1,38 @@
+package apiUtility
+
+import (
+	"adsb-api/internal/global/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBbox(t *testing.T) {
+	tests := []struct {
+		name, value string
+		bbox        *models.BoundingBox
+	}{
+		{name: "Valid", value: "4,58,7,62", bbox: &models.BoundingBox{MinLon: 4, MinLat: 58, MaxLon: 7, MaxLat: 62}},
+		{
+			name:  "Crossing the antimeridian",
+			value: "170, -10, -170, 10",
+			bbox:  &models.BoundingBox{MinLon: 170, MinLat: -10, MaxLon: -170, MaxLat: 10},
+		},
+		{name: "Too few coordinates", value: "4,58,7"},
+		{name: "Not a number", value: "4,58,7,abc"},
+		{name: "NaN", value: "NaN,58,7,62"},
+		{name: "NaN latitude", value: "4,nan,7,62"},
+		{name: "Infinity", value: "4,58,+Inf,62"},
+		{name: "Longitude out of range", value: "-181,58,7,62"},
+		{name: "Latitude out of range", value: "4,58,7,91"},
+		{name: "minLat above maxLat", value: "4,62,7,58"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bbox, err := ParseBbox(tt.value)
+			assert.Equal(t, tt.bbox, bbox)
+			assert.Equal(t, tt.bbox == nil, err != nil)
+		})
+	}
+}
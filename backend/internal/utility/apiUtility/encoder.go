@@ -0,0 +1,146 @@
+package apiUtility
+
+import (
+	"adsb-api/internal/utility/protobuf"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Encoder encodes the data of a response in one format, chosen by the Accept header of the request.
+type Encoder interface {
+	// ContentType is the Content-Type header of the response.
+	ContentType() string
+	// Accepts reports whether the encoder produces the media type, with its params, of an Accept header, and can
+	// encode data.
+	Accepts(mediaType string, params map[string]string, data interface{}) bool
+	// Encode writes data to w.
+	Encode(w io.Writer, data interface{}) error
+}
+
+// encoders are the registered encoders, in the order they are preferred for equally accepted media types. The first is
+// the default, sent if the Accept header is missing or has no media type of a registered encoder.
+var encoders = []Encoder{
+	jsonEncoder{indent: "\t"},
+	jsonEncoder{},
+	msgpackEncoder{},
+	protobufEncoder{},
+}
+
+// RegisterEncoder adds an encoder that handlers can respond with, preferred after the encoders already registered.
+func RegisterEncoder(encoder Encoder) {
+	encoders = append(encoders, encoder)
+}
+
+// EncodeData encodes data with the encoder negotiated from the Accept header of r and writes it to the response
+// writer. Returns an error if the encoding fails.
+func EncodeData(w http.ResponseWriter, r *http.Request, data interface{}) error {
+	encoder := Negotiate(r, data)
+	w.Header().Add("content-type", encoder.ContentType())
+	w.Header().Add("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Vary", "Accept")
+	return encoder.Encode(w, data)
+}
+
+// Negotiate returns the registered encoder of data for the most preferred media type in the Accept header of r, by
+// their q value. The default encoder is returned for a wildcard, or if no media type can be encoded, rather than
+// refusing the request.
+func Negotiate(r *http.Request, data interface{}) Encoder {
+	type accepted struct {
+		mediaType string
+		params    map[string]string
+		q         float64
+	}
+
+	var ranges []accepted
+	for _, value := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(value)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if params["q"] != "" {
+			q, err = strconv.ParseFloat(params["q"], 64)
+			if err != nil || q <= 0 {
+				continue
+			}
+		}
+		ranges = append(ranges, accepted{mediaType: mediaType, params: params, q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, accepted := range ranges {
+		if accepted.mediaType == "*/*" || accepted.mediaType == "application/*" {
+			return encoders[0]
+		}
+		for _, encoder := range encoders {
+			if encoder.Accepts(accepted.mediaType, accepted.params, data) {
+				return encoder
+			}
+		}
+	}
+	return encoders[0]
+}
+
+// jsonEncoder encodes JSON, which is indented by indent, or compact for clients asking for
+// application/json;compact=true.
+type jsonEncoder struct {
+	indent string
+}
+
+func (e jsonEncoder) ContentType() string {
+	return "application/json"
+}
+
+func (e jsonEncoder) Accepts(mediaType string, params map[string]string, _ interface{}) bool {
+	compact := params["compact"] == "true"
+	return (mediaType == "application/json" || mediaType == "application/geo+json") && compact == (e.indent == "")
+}
+
+func (e jsonEncoder) Encode(w io.Writer, data interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", e.indent)
+	return encoder.Encode(data)
+}
+
+// msgpackEncoder encodes MessagePack, with the same keys as the JSON of a response.
+type msgpackEncoder struct{}
+
+func (e msgpackEncoder) ContentType() string {
+	return "application/msgpack"
+}
+
+func (e msgpackEncoder) Accepts(mediaType string, _ map[string]string, _ interface{}) bool {
+	return mediaType == "application/msgpack" || mediaType == "application/x-msgpack" ||
+		mediaType == "application/vnd.msgpack"
+}
+
+func (e msgpackEncoder) Encode(w io.Writer, data interface{}) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	return encoder.Encode(data)
+}
+
+// protobufEncoder encodes the Protocol Buffers messages of the current aircraft and aircraft history responses, see
+// aircraft.proto in the protobuf package.
+type protobufEncoder struct{}
+
+func (e protobufEncoder) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (e protobufEncoder) Accepts(mediaType string, _ map[string]string, data interface{}) bool {
+	return (mediaType == "application/x-protobuf" || mediaType == "application/protobuf") && protobuf.Supports(data)
+}
+
+func (e protobufEncoder) Encode(w io.Writer, data interface{}) error {
+	_, err := w.Write(protobuf.Marshal(data))
+	return err
+}
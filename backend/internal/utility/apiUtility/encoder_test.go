@@ -0,0 +1,45 @@
+package apiUtility
+
+import (
+	"adsb-api/internal/global/geoJSON"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name, accept, contentType string
+		data                      interface{}
+	}{
+		{name: "No Accept header", accept: "", contentType: "application/json"},
+		{name: "Wildcard", accept: "*/*", contentType: "application/json"},
+		{name: "GeoJSON", accept: "application/geo+json", contentType: "application/json"},
+		{name: "MessagePack", accept: "application/x-msgpack", contentType: "application/msgpack"},
+		{
+			name:        "Highest q value",
+			accept:      "application/json;q=0.8, application/x-protobuf;q=0.9, */*;q=0.1",
+			contentType: "application/x-protobuf",
+			data:        geoJSON.FeatureCollectionLineString{},
+		},
+		{
+			name:        "Protocol Buffers of a response without a message",
+			accept:      "application/x-protobuf, application/msgpack;q=0.5",
+			contentType: "application/msgpack",
+			data:        geoJSON.FeatureCollectionPolygon{},
+		},
+		{name: "Refused media type", accept: "application/msgpack;q=0, text/html", contentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequest(http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatalf("error creating request: %q", err)
+			}
+			r.Header.Set("Accept", tt.accept)
+			assert.Equal(t, tt.contentType, Negotiate(r, tt.data).ContentType())
+		})
+	}
+}
@@ -0,0 +1,330 @@
+package archive
+
+import (
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestName is the name of the manifest file in every archive directory
+const ManifestName = "manifest.json"
+
+// Manifest describes the files in an archive directory
+type Manifest struct {
+	Created string `json:"created"`
+	Cutoff  string `json:"cutoff"`
+	Rows    int    `json:"rows"`
+	Files   []File `json:"files"`
+}
+
+// File represents one gzip compressed NDJSON file in an archive, with all history rows of one day
+type File struct {
+	Name   string `json:"name"`
+	Date   string `json:"date"`
+	Rows   int    `json:"rows"`
+	Sha256 string `json:"sha256"`
+}
+
+// WriteArchive writes the history rows streamed by stream to a new archive directory in dir, named after the cutoff
+// timestamp. stream calls handle for every row, ordered by timestamp, and stops at the first error it returns. The rows
+// are written to one gzip compressed NDJSON file per day as they are streamed, so only one row is held in memory, and a
+// manifest with the row count and SHA-256 checksum of every file is written last. The archive is written to a
+// temporary directory that is renamed when everything is written, so an archive directory is either complete or
+// missing. Returns the path of the archive and the number of rows in it. Nothing is written if there are no rows.
+func WriteArchive(dir string, cutoff string,
+	stream func(handle func(models.AircraftHistoryModel) error) error) (path string, rows int, err error) {
+	cutoffTime, err := time.Parse(time.RFC3339Nano, cutoff)
+	if err != nil {
+		return "", 0, err
+	}
+
+	archivePath := archivePath(dir, cutoffTime)
+	if _, err := os.Stat(archivePath); err == nil {
+		return "", 0, fmt.Errorf(errorMsg.ErrorArchiveAlreadyExists+": %s", archivePath)
+	}
+
+	tmpPath := archivePath + ".tmp"
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return "", 0, err
+	}
+	if err := os.MkdirAll(tmpPath, 0o755); err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		if err != nil || rows == 0 {
+			_ = os.RemoveAll(tmpPath)
+		}
+	}()
+
+	manifest := Manifest{
+		Created: time.Now().UTC().Format(time.RFC3339),
+		Cutoff:  cutoff,
+	}
+
+	var current *dayFile
+	err = stream(func(row models.AircraftHistoryModel) error {
+		ts, err := time.Parse(time.RFC3339Nano, row.Timestamp)
+		if err != nil {
+			return err
+		}
+		date := ts.UTC().Format(time.DateOnly)
+
+		if current == nil || current.Date != date {
+			if current != nil {
+				if date < current.Date {
+					return fmt.Errorf(errorMsg.ErrorArchiveRowsNotOrdered+": %s", row.Timestamp)
+				}
+				if err := current.close(); err != nil {
+					return err
+				}
+				manifest.Files = append(manifest.Files, current.File)
+			}
+			if current, err = createDayFile(tmpPath, date); err != nil {
+				return err
+			}
+		}
+
+		manifest.Rows++
+		return current.write(row)
+	})
+	if current != nil {
+		if closeErr := current.close(); err == nil {
+			err = closeErr
+		}
+		manifest.Files = append(manifest.Files, current.File)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	rows = manifest.Rows
+	if rows == 0 {
+		return "", 0, nil
+	}
+
+	if err = writeManifest(filepath.Join(tmpPath, ManifestName), manifest); err != nil {
+		return "", 0, err
+	}
+	if err = os.Rename(tmpPath, archivePath); err != nil {
+		return "", 0, err
+	}
+
+	return archivePath, rows, nil
+}
+
+// FindArchive returns the path and manifest of the archive in dir for the cutoff timestamp, or a nil manifest if there
+// is no such archive. An archive directory is only there once it is complete, as it is renamed from a temporary
+// directory when everything is written.
+func FindArchive(dir string, cutoff string) (string, *Manifest, error) {
+	cutoffTime, err := time.Parse(time.RFC3339Nano, cutoff)
+	if err != nil {
+		return "", nil, err
+	}
+
+	path := archivePath(dir, cutoffTime)
+	manifestFile, err := os.ReadFile(filepath.Join(path, ManifestName))
+	if os.IsNotExist(err) {
+		return path, nil, nil
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(manifestFile, &manifest); err != nil {
+		return "", nil, err
+	}
+	return path, &manifest, nil
+}
+
+// archivePath returns the path of the archive in dir for the cutoff timestamp.
+func archivePath(dir string, cutoff time.Time) string {
+	return filepath.Join(dir, "aircraft_history_"+cutoff.Format("20060102T150405"))
+}
+
+// ReadArchive reads the history rows in the archive directory at path, and calls handle with them in batches of at most
+// batchSize rows, so that only one batch is held in memory. Every file is checked against the row count and checksum in
+// the manifest before its rows are read, and handle is not called for a file that does not match. Stops at the first
+// error returned by handle. Returns the number of rows handled.
+func ReadArchive(path string, batchSize int, handle func(rows []models.AircraftHistoryModel) error) (int, error) {
+	manifestFile, err := os.ReadFile(filepath.Join(path, ManifestName))
+	if err != nil {
+		return 0, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(manifestFile, &manifest); err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	for _, file := range manifest.Files {
+		filePath := filepath.Join(path, file.Name)
+		rows, checksum, err := checkFile(filePath)
+		if err != nil {
+			return handled, err
+		}
+		if checksum != file.Sha256 {
+			return handled, fmt.Errorf(errorMsg.ErrorArchiveChecksumMismatch+": %s", file.Name)
+		}
+		if rows != file.Rows {
+			return handled, fmt.Errorf(errorMsg.ErrorArchiveRowCountMismatch+": %s", file.Name)
+		}
+
+		n, err := readFile(filePath, batchSize, handle)
+		handled += n
+		if err != nil {
+			return handled, err
+		}
+	}
+
+	return handled, nil
+}
+
+// dayFile is a gzip compressed NDJSON file of an archive that is being written, with the rows of one day.
+type dayFile struct {
+	File
+	f       *os.File
+	hash    hash.Hash
+	gz      *gzip.Writer
+	encoder *json.Encoder
+}
+
+// createDayFile creates the file for the rows of date in the archive directory dir.
+func createDayFile(dir string, date string) (*dayFile, error) {
+	name := "aircraft_history_" + date + ".ndjson.gz"
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	day := &dayFile{File: File{Name: name, Date: date}, f: f, hash: sha256.New()}
+	day.gz = gzip.NewWriter(io.MultiWriter(f, day.hash))
+	day.encoder = json.NewEncoder(day.gz)
+	return day, nil
+}
+
+// write writes row to the file.
+func (day *dayFile) write(row models.AircraftHistoryModel) error {
+	day.Rows++
+	return day.encoder.Encode(row)
+}
+
+// close flushes and closes the file, and sets its SHA-256 checksum.
+func (day *dayFile) close() (err error) {
+	defer func() {
+		closeErr := day.f.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	if err = day.gz.Close(); err != nil {
+		return err
+	}
+	if err = day.f.Sync(); err != nil {
+		return err
+	}
+
+	day.Sha256 = hex.EncodeToString(day.hash.Sum(nil))
+	return nil
+}
+
+// checkFile reads the gzip compressed NDJSON file at path without decoding its rows, and returns the number of rows in
+// it together with its SHA-256 checksum.
+func checkFile(path string) (int, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	gz, err := gzip.NewReader(io.TeeReader(f, sum))
+	if err != nil {
+		return 0, "", err
+	}
+	defer gz.Close()
+
+	rows := 0
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		rows++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, "", err
+	}
+
+	// the checksum is of the whole file, also after the end of the gzip stream
+	if _, err := io.Copy(sum, f); err != nil {
+		return 0, "", err
+	}
+	return rows, hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// readFile reads the gzip compressed NDJSON rows of the file at path, and calls handle with them in batches of at most
+// batchSize rows. Returns the number of rows handled.
+func readFile(path string, batchSize int, handle func(rows []models.AircraftHistoryModel) error) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return 0, err
+	}
+	defer gz.Close()
+
+	handled := 0
+	batch := make([]models.AircraftHistoryModel, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := handle(batch); err != nil {
+			return err
+		}
+		handled += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var row models.AircraftHistoryModel
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			return handled, err
+		}
+		batch = append(batch, row)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return handled, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return handled, err
+	}
+
+	return handled, flush()
+}
+
+// writeManifest writes the manifest as indented JSON to path.
+func writeManifest(path string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
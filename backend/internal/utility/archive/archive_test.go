@@ -0,0 +1,212 @@
+package archive
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+// createHistoryRows creates n history rows with an hour between each row, starting at start. Every other row has a
+// callsign, altitude and messages, the others were recorded without them.
+func createHistoryRows(n int, start time.Time) []models.AircraftHistoryModel {
+	var rows []models.AircraftHistoryModel
+	for i := 0; i < n; i++ {
+		row := models.AircraftHistoryModel{
+			Icao:      "TEST",
+			Latitude:  float32(i),
+			Longitude: float32(i),
+			Timestamp: start.Add(time.Duration(i) * time.Hour).Format(time.RFC3339),
+		}
+		if i%2 == 0 {
+			callsign, altitude, messages := "SAS123", 1000*i, i
+			row.Callsign, row.Altitude, row.Messages = &callsign, &altitude, &messages
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// streamRows returns a function streaming rows to the handle of WriteArchive.
+func streamRows(rows []models.AircraftHistoryModel) func(func(models.AircraftHistoryModel) error) error {
+	return func(handle func(models.AircraftHistoryModel) error) error {
+		for _, row := range rows {
+			if err := handle(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestWriteArchive_ReadArchive(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rows := createHistoryRows(24, start)
+
+	path, n, err := WriteArchive(dir, start.Add(48*time.Hour).Format(time.RFC3339), streamRows(rows))
+	if err != nil {
+		t.Fatalf("error writing archive: %q", err)
+	}
+	assert.Equal(t, len(rows), n)
+
+	// rows span two days
+	files, err := filepath.Glob(filepath.Join(path, "*.ndjson.gz"))
+	if err != nil {
+		t.Fatalf("error listing archive files: %q", err)
+	}
+	assert.Len(t, files, 2)
+	assert.FileExists(t, filepath.Join(path, ManifestName))
+
+	// the rows are read in batches, which do not span files
+	var actual []models.AircraftHistoryModel
+	var batches []int
+	n, err = ReadArchive(path, 5, func(batch []models.AircraftHistoryModel) error {
+		actual = append(actual, batch...)
+		batches = append(batches, len(batch))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error reading archive: %q", err)
+	}
+
+	assert.Equal(t, len(rows), n)
+	assert.Equal(t, rows, actual)
+	assert.Equal(t, []int{5, 5, 2, 5, 5, 2}, batches)
+}
+
+func TestReadArchive_HandleError(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	path, _, err := WriteArchive(t.TempDir(), start.Add(24*time.Hour).Format(time.RFC3339),
+		streamRows(createHistoryRows(10, start)))
+	if err != nil {
+		t.Fatalf("error writing archive: %q", err)
+	}
+
+	calls := 0
+	n, err := ReadArchive(path, 4, func([]models.AircraftHistoryModel) error {
+		calls++
+		if calls == 2 {
+			return errors.New("insert failed")
+		}
+		return nil
+	})
+
+	assert.EqualError(t, err, "insert failed")
+	assert.Equal(t, 4, n)
+	assert.Equal(t, 2, calls)
+}
+
+func TestWriteArchive_AlreadyExists(t *testing.T) {
+	dir := t.TempDir()
+	cutoff := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	rows := createHistoryRows(2, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	path, _, err := WriteArchive(dir, cutoff, streamRows(rows))
+	if err != nil {
+		t.Fatalf("error writing archive: %q", err)
+	}
+
+	_, _, err = WriteArchive(dir, cutoff, streamRows(rows))
+	assert.ErrorContains(t, err, errorMsg.ErrorArchiveAlreadyExists)
+
+	found, manifest, err := FindArchive(dir, cutoff)
+	if err != nil {
+		t.Fatalf("error finding archive: %q", err)
+	}
+	assert.Equal(t, path, found)
+	assert.Equal(t, 2, manifest.Rows)
+
+	_, manifest, err = FindArchive(dir, "2024-01-03T00:00:00Z")
+	if err != nil {
+		t.Fatalf("error finding archive: %q", err)
+	}
+	assert.Nil(t, manifest)
+}
+
+func TestWriteArchive_NoRows(t *testing.T) {
+	dir := t.TempDir()
+
+	path, n, err := WriteArchive(dir, "2024-01-02T00:00:00Z", streamRows(nil))
+	if err != nil {
+		t.Fatalf("error writing archive: %q", err)
+	}
+	assert.Empty(t, path)
+	assert.Equal(t, 0, n)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("error listing archive directory: %q", err)
+	}
+	assert.Empty(t, entries)
+}
+
+func TestWriteArchive_NotOrdered(t *testing.T) {
+	dir := t.TempDir()
+	rows := createHistoryRows(2, time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC))
+	rows[0], rows[1] = rows[1], rows[0]
+
+	_, _, err := WriteArchive(dir, "2024-01-03T00:00:00Z", streamRows(rows))
+	assert.ErrorContains(t, err, errorMsg.ErrorArchiveRowsNotOrdered)
+
+	// nothing is left of the archive
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("error listing archive directory: %q", err)
+	}
+	assert.Empty(t, entries)
+}
+
+func TestWriteArchive_InvalidCutoff(t *testing.T) {
+	_, _, err := WriteArchive(t.TempDir(), "invalid", streamRows(nil))
+	assert.Error(t, err)
+}
+
+func TestReadArchive_ChecksumMismatch(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cutoff := start.Add(24 * time.Hour).Format(time.RFC3339)
+	path, _, err := WriteArchive(dir, cutoff, streamRows(createHistoryRows(2, start)))
+	if err != nil {
+		t.Fatalf("error writing archive: %q", err)
+	}
+
+	// replace the archived file with other rows
+	day, err := createDayFile(path, "2024-01-01")
+	if err != nil {
+		t.Fatalf("error overwriting archive file: %q", err)
+	}
+	for _, row := range createHistoryRows(3, start) {
+		if err := day.write(row); err != nil {
+			t.Fatalf("error overwriting archive file: %q", err)
+		}
+	}
+	if err := day.close(); err != nil {
+		t.Fatalf("error overwriting archive file: %q", err)
+	}
+
+	// nothing is read from a file that does not match the manifest
+	n, err := ReadArchive(path, 10, func([]models.AircraftHistoryModel) error {
+		t.Fatalf("rows read from a file that does not match the manifest")
+		return nil
+	})
+	assert.ErrorContains(t, err, errorMsg.ErrorArchiveChecksumMismatch)
+	assert.Equal(t, 0, n)
+}
+
+func TestReadArchive_MissingManifest(t *testing.T) {
+	_, err := ReadArchive(t.TempDir(), 10, func([]models.AircraftHistoryModel) error { return nil })
+	assert.True(t, os.IsNotExist(err))
+}
@@ -3,12 +3,20 @@ package convert
 import (
 	"adsb-api/internal/global/errorMsg"
 	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/gpx"
+	"adsb-api/internal/global/kml"
 	"adsb-api/internal/global/models"
 	"errors"
+	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// metersPerFoot converts the altitude of the aircraft, in feet, to the meters used by KML and GPX
+const metersPerFoot = 0.3048
+
 // MakeTimeStamp concatenates the provided date and time strings, replacing slashes (/) with dashes (-),
 // and removes the trailing ".000" from the time string.
 // The resulting string represents a database postgres timestamp.
@@ -32,6 +40,9 @@ func CurrentModelToGeoJson(aircraft []models.AircraftCurrentModel) (geoJSON.Feat
 			Track:        ac.Track,
 			VerticalRate: ac.VerticalRate,
 			Timestamp:    ac.Timestamp,
+			OnGround:     ac.OnGround,
+
+			RegistryProperties: RegistryModelToProperties(ac.Registry),
 		}
 		feature.Properties = properties
 		feature.Geometry.Type = "Point"
@@ -45,14 +56,58 @@ func CurrentModelToGeoJson(aircraft []models.AircraftCurrentModel) (geoJSON.Feat
 	return featureCollection, nil
 }
 
-// HistoryModelToGeoJson converts an array of AircraftHistoryModel objects to a GeoJSON FeatureCollection
-func HistoryModelToGeoJson(aircraft []models.AircraftHistoryModel) (geoJSON.FeatureCollectionLineString, error) {
+// CurrentDetailToGeoJson converts an AircraftCurrentDetail into a GeoJSON Feature, with the properties of
+// CurrentModelToGeoJson and the trail of the aircraft.
+func CurrentDetailToGeoJson(detail models.AircraftCurrentDetail) (geoJSON.FeatureAircraftDetail, error) {
+	collection, err := CurrentModelToGeoJson([]models.AircraftCurrentModel{detail.Aircraft})
+	if err != nil {
+		return geoJSON.FeatureAircraftDetail{}, err
+	}
+	point := collection.Features[0]
+
+	trail := []geoJSON.TrailPoint{}
+	for _, ac := range detail.Trail {
+		trail = append(trail, geoJSON.TrailPoint{Latitude: ac.Latitude, Longitude: ac.Longitude, Timestamp: ac.Timestamp})
+	}
+
+	var feature geoJSON.FeatureAircraftDetail
+	feature.Type = point.Type
+	feature.Geometry = point.Geometry
+	feature.Properties = geoJSON.AircraftDetailProperties{
+		AircraftCurrentProperties: point.Properties,
+		FirstSeen:                 detail.Aircraft.FirstSeen,
+		LastSeen:                  detail.Aircraft.Timestamp,
+		Messages:                  detail.Aircraft.Messages,
+		Trail:                     trail,
+	}
+	return feature, nil
+}
+
+// HistoryModelToGeoJson converts an array of AircraftHistoryModel objects to a GeoJSON FeatureCollection.
+// If tolerance is above 0, the track is simplified with SimplifyHistory first. The number of points before and after
+// the simplification is added to the feature properties.
+func HistoryModelToGeoJson(aircraft []models.AircraftHistoryModel, tolerance float64) (geoJSON.FeatureCollectionLineString, error) {
 	if len(aircraft) < 2 {
 		return geoJSON.FeatureCollectionLineString{}, errors.New(errorMsg.ErrorGeoJsonTooFewCoordinates)
 	}
+	return historyToGeoJson(aircraft, tolerance), nil
+}
+
+// HistoryPageToGeoJson converts a page of a paginated history to a GeoJSON FeatureCollection like
+// HistoryModelToGeoJson. A page may hold a single point, as the client joins the pages into the whole track.
+func HistoryPageToGeoJson(aircraft []models.AircraftHistoryModel, tolerance float64) (geoJSON.FeatureCollectionLineString, error) {
+	if len(aircraft) == 0 {
+		return geoJSON.FeatureCollectionLineString{}, errors.New(errorMsg.ErrorGeoJsonTooFewCoordinates)
+	}
+	return historyToGeoJson(aircraft, tolerance), nil
+}
+
+// historyToGeoJson converts aircraft, holding at least one point, to a GeoJSON FeatureCollection.
+func historyToGeoJson(aircraft []models.AircraftHistoryModel, tolerance float64) geoJSON.FeatureCollectionLineString {
+	simplified := SimplifyHistory(aircraft, tolerance)
 
 	var coordinates [][]float32
-	for _, ac := range aircraft {
+	for _, ac := range simplified {
 		point := []float32{ac.Longitude, ac.Latitude}
 		coordinates = append(coordinates, point)
 	}
@@ -61,6 +116,8 @@ func HistoryModelToGeoJson(aircraft []models.AircraftHistoryModel) (geoJSON.Feat
 	var feature geoJSON.FeatureLineString
 	feature.Type = "Feature"
 	feature.Properties.Icao = aircraft[0].Icao
+	feature.Properties.OriginalPoints = len(aircraft)
+	feature.Properties.Points = len(simplified)
 	feature.Geometry.Coordinates = coordinates
 	feature.Geometry.Type = "LineString"
 	features = append(features, feature)
@@ -68,10 +125,185 @@ func HistoryModelToGeoJson(aircraft []models.AircraftHistoryModel) (geoJSON.Feat
 	var featureCollection geoJSON.FeatureCollectionLineString
 	featureCollection.Features = features
 	featureCollection.Type = "FeatureCollection"
-	return featureCollection, nil
+	return featureCollection
+}
+
+// HistoryModelToKml converts an array of AircraftHistoryModel objects to a KML document with a gx:Track of the
+// aircraft, in chronological order. If tolerance is above 0, the track is simplified with SimplifyHistory first.
+// The track is extruded from the ground at its altitude, or clamped to the ground if a position has no altitude.
+// description is added to the placemark, and left out if empty.
+func HistoryModelToKml(aircraft []models.AircraftHistoryModel, tolerance float64, description string) (kml.Kml, error) {
+	if len(aircraft) < 2 {
+		return kml.Kml{}, errors.New(errorMsg.ErrorGeoJsonTooFewCoordinates)
+	}
+
+	simplified := SimplifyHistory(chronological(aircraft), tolerance)
+
+	track := kml.Track{Extrude: 1, AltitudeMode: "absolute"}
+	for _, ac := range simplified {
+		if ac.Altitude == nil {
+			track.Extrude, track.AltitudeMode = 0, "clampToGround"
+		}
+	}
+	for _, ac := range simplified {
+		var altitude float64
+		if ac.Altitude != nil {
+			altitude = float64(*ac.Altitude) * metersPerFoot
+		}
+		track.When = append(track.When, ac.Timestamp)
+		track.Coords = append(track.Coords, fmt.Sprintf("%v %v %.1f", ac.Longitude, ac.Latitude, altitude))
+	}
+
+	return kml.Kml{
+		Xmlns:   "http://www.opengis.net/kml/2.2",
+		XmlnsGx: "http://www.google.com/kml/ext/2.2",
+		Document: kml.Document{
+			Name: aircraft[0].Icao,
+			Placemarks: []kml.Placemark{
+				{Name: aircraft[0].Icao, Description: description, Track: track},
+			},
+		},
+	}, nil
+}
+
+// HistoryModelToGpx converts an array of AircraftHistoryModel objects to a GPX document with a trk of the aircraft, in
+// chronological order. If tolerance is above 0, the track is simplified with SimplifyHistory first. description is
+// added to the trk, and left out if empty.
+func HistoryModelToGpx(aircraft []models.AircraftHistoryModel, tolerance float64, description string) (gpx.Gpx, error) {
+	if len(aircraft) < 2 {
+		return gpx.Gpx{}, errors.New(errorMsg.ErrorGeoJsonTooFewCoordinates)
+	}
+
+	var segment gpx.TrackSegment
+	for _, ac := range SimplifyHistory(chronological(aircraft), tolerance) {
+		point := gpx.TrackPoint{Latitude: ac.Latitude, Longitude: ac.Longitude, Time: ac.Timestamp}
+		if ac.Altitude != nil {
+			ele := math.Round(float64(*ac.Altitude)*metersPerFoot*10) / 10
+			point.Ele = &ele
+		}
+		segment.Points = append(segment.Points, point)
+	}
+
+	return gpx.Gpx{
+		Xmlns:    "http://www.topografix.com/GPX/1/1",
+		Version:  "1.1",
+		Creator:  "adsb-api",
+		Metadata: gpx.Metadata{Name: aircraft[0].Icao},
+		Tracks: []gpx.Track{
+			{Name: aircraft[0].Icao, Desc: description, Segments: []gpx.TrackSegment{segment}},
+		},
+	}, nil
+}
+
+// chronological returns the history, which is ordered by timestamp, from the oldest to the newest position.
+// A history ordered from the newest position is returned as a reversed copy.
+func chronological(aircraft []models.AircraftHistoryModel) []models.AircraftHistoryModel {
+	first, firstErr := time.Parse(time.RFC3339Nano, aircraft[0].Timestamp)
+	last, lastErr := time.Parse(time.RFC3339Nano, aircraft[len(aircraft)-1].Timestamp)
+	if firstErr != nil || lastErr != nil || !first.After(last) {
+		return aircraft
+	}
+
+	reversed := make([]models.AircraftHistoryModel, len(aircraft))
+	for i, ac := range aircraft {
+		reversed[len(aircraft)-1-i] = ac
+	}
+	return reversed
+}
+
+// CoverageToGeoJson converts the coverage of a window, ordered by band and sector, into a GeoJSON Polygon feature for
+// every altitude band of band feet. The polygon connects the farthest position of each sector with coverage, in order
+// of bearing. Bands with coverage in fewer than three sectors are left out, as they do not make a polygon.
+func CoverageToGeoJson(coverage []models.CoverageModel, band int, window geoJSON.CoverageWindow) []geoJSON.FeaturePolygon {
+	var features []geoJSON.FeaturePolygon
+	for start := 0; start < len(coverage); {
+		end := start
+		for end < len(coverage) && coverage[end].Band == coverage[start].Band {
+			end++
+		}
+		sectors := coverage[start:end]
+		start = end
+
+		if len(sectors) < 3 {
+			continue
+		}
+
+		var feature geoJSON.FeaturePolygon
+		feature.Type = "Feature"
+		feature.Properties = geoJSON.CoverageProperties{
+			CoverageWindow: window,
+			MinAltitude:    sectors[0].Band,
+			MaxAltitude:    sectors[0].Band + band,
+			Sectors:        len(sectors),
+		}
+
+		var ring [][]float32
+		for _, sector := range sectors {
+			ring = append(ring, []float32{sector.Longitude, sector.Latitude})
+			feature.Properties.MaxRange = math.Max(feature.Properties.MaxRange, sector.Range)
+		}
+		// a linear ring is closed
+		ring = append(ring, ring[0])
+
+		feature.Geometry.Type = "Polygon"
+		feature.Geometry.Coordinates = [][][]float32{ring}
+		features = append(features, feature)
+	}
+
+	return features
+}
+
+// HeatmapToGeoJson converts the cells of a heatmap grid of cell degrees into a GeoJSON FeatureCollection with a
+// Polygon feature for every cell.
+func HeatmapToGeoJson(cells []models.HeatmapCellModel, cell float64) geoJSON.FeatureCollectionHeatmap {
+	featureCollection := geoJSON.FeatureCollectionHeatmap{Type: "FeatureCollection"}
+	for _, c := range cells {
+		minLon, minLat := float32(cellCorner(c.X, cell)), float32(cellCorner(c.Y, cell))
+		maxLon, maxLat := float32(cellCorner(c.X+1, cell)), float32(cellCorner(c.Y+1, cell))
+
+		var feature geoJSON.FeatureHeatmap
+		feature.Type = "Feature"
+		feature.Properties.Count = c.Count
+		feature.Geometry.Type = "Polygon"
+		feature.Geometry.Coordinates = [][][]float32{{
+			{minLon, minLat}, {maxLon, minLat}, {maxLon, maxLat}, {minLon, maxLat}, {minLon, minLat},
+		}}
+		featureCollection.Features = append(featureCollection.Features, feature)
+	}
+
+	return featureCollection
+}
+
+// HeatmapToArray converts the cells of a heatmap grid of cell degrees into a compact array of the minimum longitude,
+// minimum latitude and count of every cell.
+func HeatmapToArray(cells []models.HeatmapCellModel, cell float64) [][3]float64 {
+	array := make([][3]float64, 0, len(cells))
+	for _, c := range cells {
+		array = append(array, [3]float64{cellCorner(c.X, cell), cellCorner(c.Y, cell), float64(c.Count)})
+	}
+	return array
+}
+
+// cellCorner returns the coordinate in degrees of column or row i of a grid of cell degrees, rounded to 6 decimals to
+// leave out floating point noise.
+func cellCorner(i int, cell float64) float64 {
+	return math.Round(float64(i)*cell*1e6) / 1e6
+}
+
+// RegistryModelToProperties converts an AircraftRegistryModel into the GeoJSON registry properties of an aircraft.
+func RegistryModelToProperties(registry models.AircraftRegistryModel) geoJSON.RegistryProperties {
+	return geoJSON.RegistryProperties{
+		Registration: registry.Registration,
+		TypeCode:     registry.TypeCode,
+		Manufacturer: registry.Manufacturer,
+		Model:        registry.Model,
+		Operator:     registry.Operator,
+		Year:         registry.Year,
+	}
 }
 
 // SbsToAircraftCurrent converts the provided SBS messages (msg1, msg3, msg4) into an AircraftCurrentModel.
+// The aircraft is on ground if the IsOnGround field of msg3 is set. Messages counts the three messages.
 func SbsToAircraftCurrent(msg1 []string, msg3 []string, msg4 []string) (models.AircraftCurrentModel, error) {
 	icao := msg1[4]
 	date := msg1[8]
@@ -113,6 +345,8 @@ func SbsToAircraftCurrent(msg1 []string, msg3 []string, msg4 []string) (models.A
 		return models.AircraftCurrentModel{}, err
 	}
 
+	onGround := len(msg3) > 21 && (msg3[21] == "-1" || msg3[21] == "1")
+
 	return models.AircraftCurrentModel{
 		Icao:         icao,
 		Callsign:     callsign,
@@ -123,5 +357,7 @@ func SbsToAircraftCurrent(msg1 []string, msg3 []string, msg4 []string) (models.A
 		Track:        int(track),
 		VerticalRate: vspeed,
 		Timestamp:    timestamp,
+		OnGround:     onGround,
+		Messages:     3,
 	}, nil
 }
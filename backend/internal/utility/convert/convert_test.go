@@ -3,13 +3,17 @@ package convert
 import (
 	"adsb-api/internal/global"
 	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/models"
 	"adsb-api/internal/utility/testUtility"
 	"log"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/xeipuuv/gojsonschema"
 )
 
@@ -60,9 +64,44 @@ func TestConvertCurrentModelToGeoJson(t *testing.T) {
 	}
 }
 
+func TestConvertCurrentModelToGeoJson_WithRegistry(t *testing.T) {
+	var mockData = testUtility.CreateMockAircraft(2)
+	mockData[0].Registry = models.AircraftRegistryModel{Icao: "0", Registration: "LN-ABC", TypeCode: "B738", Year: 2010}
+
+	geoJson, err := CurrentModelToGeoJson(mockData)
+	if err != nil {
+		t.Fatalf("error converting model data to GeoJSON: %q", err)
+	}
+
+	assert.Equal(t, "LN-ABC", geoJson.Features[0].Properties.Registration)
+	assert.Equal(t, "B738", geoJson.Features[0].Properties.TypeCode)
+	assert.Equal(t, 2010, geoJson.Features[0].Properties.Year)
+	assert.Equal(t, geoJSON.RegistryProperties{}, geoJson.Features[1].Properties.RegistryProperties)
+}
+
+func TestSbsToAircraftCurrent_OnGround(t *testing.T) {
+	msg1 := strings.Split("MSG,1,0,0,E80451,0,2024/03/29,11:45:05.000,2024/03/29,11:45:05.000,TAM8112,,,,,,,,,,,", ",")
+	msg3 := strings.Split("MSG,3,0,0,E80451,0,2024/03/29,11:45:05.000,2024/03/29,11:45:05.000,,0,,,19.329620,-99.196991,,,,,,-1", ",")
+	msg4 := strings.Split("MSG,4,0,0,E80451,0,2024/03/29,11:45:05.000,2024/03/29,11:45:05.000,,,12.0,334.964325,,,0,,,,,", ",")
+
+	ac, err := SbsToAircraftCurrent(msg1, msg3, msg4)
+	if err != nil {
+		t.Fatalf("error converting SBS data: %q", err)
+	}
+	assert.True(t, ac.OnGround)
+	assert.Equal(t, 3, ac.Messages)
+
+	msg3[21] = "0"
+	ac, err = SbsToAircraftCurrent(msg1, msg3, msg4)
+	if err != nil {
+		t.Fatalf("error converting SBS data: %q", err)
+	}
+	assert.False(t, ac.OnGround)
+}
+
 func TestConvertHistoryModelToGeoJson(t *testing.T) {
 	var mockData = testUtility.CreateMockHistAircraft(2)
-	geoJson, err := HistoryModelToGeoJson(mockData)
+	geoJson, err := HistoryModelToGeoJson(mockData, 0)
 	if err != nil {
 		t.Errorf("error converting model data to GeoJSON: %q", err)
 	}
@@ -84,8 +123,192 @@ func TestConvertHistoryModelToGeoJson(t *testing.T) {
 
 func TestConvertHistoryModelToGeoJson_TooFewCoordinates(t *testing.T) {
 	var mockData = testUtility.CreateMockHistAircraft(1)
-	_, err := HistoryModelToGeoJson(mockData)
+	_, err := HistoryModelToGeoJson(mockData, 0)
 	if err == nil {
 		t.Errorf("expected error: %s", errorMsg.ErrorGeoJsonTooFewCoordinates)
 	}
 }
+
+func TestConvertHistoryPageToGeoJson(t *testing.T) {
+	var mockData = testUtility.CreateMockHistAircraft(1)
+	page, err := HistoryPageToGeoJson(mockData, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float32{{mockData[0].Longitude, mockData[0].Latitude}}, page.Features[0].Geometry.Coordinates)
+
+	_, err = HistoryPageToGeoJson(nil, 0)
+	assert.EqualError(t, err, errorMsg.ErrorGeoJsonTooFewCoordinates)
+}
+
+func TestConvertHistoryModelToGeoJson_Simplified(t *testing.T) {
+	// straight line along the latitude
+	var mockData []models.AircraftHistoryModel
+	for i := 0; i < 100; i++ {
+		mockData = append(mockData, models.AircraftHistoryModel{Icao: "TEST", Latitude: 60 + float32(i)*0.001, Longitude: 10})
+	}
+
+	geoJson, err := HistoryModelToGeoJson(mockData, 10)
+	if err != nil {
+		t.Fatalf("error converting model data to GeoJSON: %q", err)
+	}
+
+	feature := geoJson.Features[0]
+	assert.Equal(t, 100, feature.Properties.OriginalPoints)
+	assert.Equal(t, 2, feature.Properties.Points)
+	assert.Equal(t, [][]float32{{10, 60}, {10, mockData[99].Latitude}}, feature.Geometry.Coordinates)
+}
+
+func TestConvertHistoryModelToKml(t *testing.T) {
+	low, high := 1000, 35000
+	// newest position first, as the history is selected
+	mockData := []models.AircraftHistoryModel{
+		{Icao: "ABC123", Latitude: 60.5, Longitude: 5.5, Timestamp: "2024-01-01T10:05:00Z", Altitude: &high},
+		{Icao: "ABC123", Latitude: 60, Longitude: 5, Timestamp: "2024-01-01T10:00:00Z", Altitude: &low},
+	}
+
+	document, err := HistoryModelToKml(mockData, 0, "LN-ABC")
+	if err != nil {
+		t.Fatalf("error converting model data to KML: %q", err)
+	}
+
+	placemark := document.Document.Placemarks[0]
+	assert.Equal(t, "ABC123", placemark.Name)
+	assert.Equal(t, "LN-ABC", placemark.Description)
+	assert.Equal(t, 1, placemark.Track.Extrude)
+	assert.Equal(t, "absolute", placemark.Track.AltitudeMode)
+	assert.Equal(t, []string{"2024-01-01T10:00:00Z", "2024-01-01T10:05:00Z"}, placemark.Track.When)
+	assert.Equal(t, []string{"5 60 304.8", "5.5 60.5 10668.0"}, placemark.Track.Coords)
+
+	// a position without an altitude clamps the track to the ground
+	mockData[0].Altitude = nil
+	document, err = HistoryModelToKml(mockData, 0, "")
+	if err != nil {
+		t.Fatalf("error converting model data to KML: %q", err)
+	}
+	assert.Equal(t, 0, document.Document.Placemarks[0].Track.Extrude)
+	assert.Equal(t, "clampToGround", document.Document.Placemarks[0].Track.AltitudeMode)
+
+	_, err = HistoryModelToKml(mockData[:1], 0, "")
+	assert.EqualError(t, err, errorMsg.ErrorGeoJsonTooFewCoordinates)
+}
+
+func TestConvertHistoryModelToGpx(t *testing.T) {
+	altitude := 35000
+	mockData := []models.AircraftHistoryModel{
+		{Icao: "ABC123", Latitude: 60, Longitude: 5, Timestamp: "2024-01-01T10:00:00Z"},
+		{Icao: "ABC123", Latitude: 60.5, Longitude: 5.5, Timestamp: "2024-01-01T10:05:00Z", Altitude: &altitude},
+	}
+
+	document, err := HistoryModelToGpx(mockData, 0, "LN-ABC")
+	if err != nil {
+		t.Fatalf("error converting model data to GPX: %q", err)
+	}
+
+	assert.Equal(t, "1.1", document.Version)
+	assert.Equal(t, "LN-ABC", document.Tracks[0].Desc)
+	points := document.Tracks[0].Segments[0].Points
+	assert.Len(t, points, 2)
+	assert.Nil(t, points[0].Ele)
+	assert.Equal(t, "2024-01-01T10:00:00Z", points[0].Time)
+	assert.Equal(t, 10668.0, *points[1].Ele)
+	assert.Equal(t, float32(60.5), points[1].Latitude)
+
+	_, err = HistoryModelToGpx(mockData[:1], 0, "")
+	assert.EqualError(t, err, errorMsg.ErrorGeoJsonTooFewCoordinates)
+}
+
+func TestSimplifyHistory_NoTolerance(t *testing.T) {
+	var mockData = testUtility.CreateMockHistAircraft(10)
+
+	assert.Equal(t, mockData, SimplifyHistory(mockData, 0))
+}
+
+func TestSimplifyHistory_KeepsTurnPoints(t *testing.T) {
+	// north for 10 points, then a right turn east for 10 points
+	var mockData []models.AircraftHistoryModel
+	for i := 0; i < 10; i++ {
+		mockData = append(mockData, models.AircraftHistoryModel{Icao: "TEST", Latitude: 60 + float32(i)*0.01, Longitude: 10})
+	}
+	for i := 1; i <= 10; i++ {
+		mockData = append(mockData, models.AircraftHistoryModel{Icao: "TEST", Latitude: 60.09, Longitude: 10 + float32(i)*0.01})
+	}
+
+	// a tolerance larger than the track would remove the turn point without turn detection
+	simplified := SimplifyHistory(mockData, 100000)
+
+	assert.Equal(t, []models.AircraftHistoryModel{mockData[0], mockData[9], mockData[19]}, simplified)
+}
+
+func TestSimplifyHistory_KeepsDeviation(t *testing.T) {
+	// a point about 1.1 km off a straight line, with a heading change below the turn angle
+	mockData := []models.AircraftHistoryModel{
+		{Icao: "TEST", Latitude: 60, Longitude: 10},
+		{Icao: "TEST", Latitude: 60.1, Longitude: 10.02},
+		{Icao: "TEST", Latitude: 60.2, Longitude: 10},
+	}
+
+	assert.Equal(t, mockData, SimplifyHistory(mockData, 500))
+	assert.Equal(t, []models.AircraftHistoryModel{mockData[0], mockData[2]}, SimplifyHistory(mockData, 2000))
+}
+
+func TestCoverageToGeoJson(t *testing.T) {
+	coverage := []models.CoverageModel{
+		// two sectors in the lowest band do not make a polygon
+		{Band: 0, Sector: 0, Range: 40, Latitude: 60.7, Longitude: 5.3},
+		{Band: 0, Sector: 9, Range: 35, Latitude: 60.4, Longitude: 5.9},
+		{Band: 10000, Sector: 0, Range: 150, Latitude: 61.7, Longitude: 5.3},
+		{Band: 10000, Sector: 12, Range: 210, Latitude: 58.5, Longitude: 6.1},
+		{Band: 10000, Sector: 27, Range: 120, Latitude: 60.4, Longitude: 3.1},
+	}
+	window := geoJSON.CoverageWindow{Window: "window", From: "2024-04-01"}
+
+	features := CoverageToGeoJson(coverage, 10000, window)
+
+	assert.Len(t, features, 1)
+	feature := features[0]
+	assert.Equal(t, geoJSON.CoverageProperties{CoverageWindow: window, MinAltitude: 10000, MaxAltitude: 20000,
+		MaxRange: 210, Sectors: 3}, feature.Properties)
+	assert.Equal(t, [][][]float32{{{5.3, 61.7}, {6.1, 58.5}, {3.1, 60.4}, {5.3, 61.7}}}, feature.Geometry.Coordinates)
+
+	collection := geoJSON.FeatureCollectionPolygon{Type: "FeatureCollection", Features: features}
+	result, err := gojsonschema.Validate(schemaLoader, gojsonschema.NewGoLoader(collection))
+	if err != nil {
+		t.Fatalf("Error validating coverage: %s", err.Error())
+	}
+	if !result.Valid() {
+		t.Errorf("Coverage does not follow the GeoJSON standard")
+		for _, desc := range result.Errors() {
+			t.Logf("- %s", desc)
+		}
+	}
+}
+
+func TestHeatmapToGeoJson(t *testing.T) {
+	cells := []models.HeatmapCellModel{{X: 52, Y: 603, Count: 120}, {X: -1, Y: 603, Count: 4}}
+
+	featureCollection := HeatmapToGeoJson(cells, 0.1)
+
+	assert.Len(t, featureCollection.Features, 2)
+	assert.Equal(t, 120, featureCollection.Features[0].Properties.Count)
+	assert.Equal(t, [][][]float32{{{5.2, 60.3}, {5.3, 60.3}, {5.3, 60.4}, {5.2, 60.4}, {5.2, 60.3}}},
+		featureCollection.Features[0].Geometry.Coordinates)
+	assert.Equal(t, [][][]float32{{{-0.1, 60.3}, {0, 60.3}, {0, 60.4}, {-0.1, 60.4}, {-0.1, 60.3}}},
+		featureCollection.Features[1].Geometry.Coordinates)
+
+	result, err := gojsonschema.Validate(schemaLoader, gojsonschema.NewGoLoader(featureCollection))
+	if err != nil {
+		t.Fatalf("Error validating heatmap: %s", err.Error())
+	}
+	if !result.Valid() {
+		t.Errorf("Heatmap does not follow the GeoJSON standard")
+		for _, desc := range result.Errors() {
+			t.Logf("- %s", desc)
+		}
+	}
+}
+
+func TestHeatmapToArray(t *testing.T) {
+	cells := []models.HeatmapCellModel{{X: 52, Y: 603, Count: 120}, {X: -1, Y: 603, Count: 4}}
+
+	assert.Equal(t, [][3]float64{{5.2, 60.3, 120}, {-0.1, 60.3, 4}}, HeatmapToArray(cells, 0.1))
+	assert.Empty(t, HeatmapToArray(nil, 0.1))
+}
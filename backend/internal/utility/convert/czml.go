@@ -0,0 +1,206 @@
+package convert
+
+import (
+	"adsb-api/internal/global/czml"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	// czmlMultiplier is the number of seconds of history played back every second
+	czmlMultiplier = 60
+	// czmlTrailTime is the number of seconds of the path drawn behind each aircraft
+	czmlTrailTime = 600
+)
+
+// czmlColor is the RGBA color of the point and path of each aircraft
+var czmlColor = []int{255, 200, 0, 255}
+
+// HistoryModelToCzml converts an array of AircraftHistoryModel objects to a CZML document for playback in Cesium, with
+// a document packet and a packet with the sampled positions of the aircraft, in chronological order. The clock of the
+// document spans the history. If tolerance is above 0, the track is simplified with SimplifyHistory first. description
+// is added to the aircraft packet, and left out if empty.
+func HistoryModelToCzml(aircraft []models.AircraftHistoryModel, tolerance float64, description string) ([]czml.Packet, error) {
+	if len(aircraft) < 2 {
+		return nil, errors.New(errorMsg.ErrorGeoJsonTooFewCoordinates)
+	}
+
+	simplified := SimplifyHistory(chronological(aircraft), tolerance)
+
+	packet, err := aircraftPacket(simplified, description)
+	if err != nil {
+		return nil, err
+	}
+
+	start, _ := parseTimestamp(simplified[0].Timestamp)
+	end, _ := parseTimestamp(simplified[len(simplified)-1].Timestamp)
+	return []czml.Packet{documentPacket(aircraft[0].Icao, start, end), packet}, nil
+}
+
+// documentPacket returns the document packet of a CZML document named name, with a clock from start to end.
+func documentPacket(name string, start time.Time, end time.Time) czml.Packet {
+	return czml.Packet{
+		Id:      "document",
+		Name:    name,
+		Version: "1.0",
+		Clock: &czml.Clock{
+			Interval:    czmlInterval(start, end),
+			CurrentTime: start.UTC().Format(time.RFC3339),
+			Multiplier:  czmlMultiplier,
+			Range:       "LOOP_STOP",
+			Step:        "SYSTEM_CLOCK_MULTIPLIER",
+		},
+	}
+}
+
+// aircraftPacket returns the CZML packet of an aircraft with a sample of its position at every point of the history,
+// which has to be in chronological order. The height of a position without an altitude is the last known altitude of
+// the aircraft, or 0 if there is none. The samples are linearly interpolated by the client, and the aircraft is only
+// shown between its first and last sample.
+func aircraftPacket(aircraft []models.AircraftHistoryModel, description string) (czml.Packet, error) {
+	epoch, err := parseTimestamp(aircraft[0].Timestamp)
+	if err != nil {
+		return czml.Packet{}, err
+	}
+
+	var samples []float64
+	var last time.Time
+	var height float64
+	for _, ac := range aircraft {
+		last, err = parseTimestamp(ac.Timestamp)
+		if err != nil {
+			return czml.Packet{}, err
+		}
+		if ac.Altitude != nil {
+			height = float64(*ac.Altitude) * metersPerFoot
+		}
+		samples = append(samples, last.Sub(epoch).Seconds(), degrees(ac.Longitude), degrees(ac.Latitude), height)
+	}
+
+	return czml.Packet{
+		Id:           aircraft[0].Icao,
+		Name:         aircraft[0].Icao,
+		Description:  description,
+		Availability: czmlInterval(epoch, last),
+		Position: &czml.Position{
+			Epoch:                     epoch.UTC().Format(time.RFC3339),
+			CartographicDegrees:       samples,
+			InterpolationAlgorithm:    "LAGRANGE",
+			InterpolationDegree:       1,
+			ForwardExtrapolationType:  "NONE",
+			BackwardExtrapolationType: "NONE",
+		},
+		Point: &czml.Point{PixelSize: 8, Color: czml.Color{Rgba: czmlColor}},
+		Path: &czml.Path{
+			Width:      2,
+			LeadTime:   0,
+			TrailTime:  czmlTrailTime,
+			Resolution: 5,
+			Material:   czml.Material{SolidColor: czml.SolidColor{Color: czml.Color{Rgba: czmlColor}}},
+		},
+	}, nil
+}
+
+// parseTimestamp parses a timestamp of the history, which is RFC 3339 as read from the database, or a date and time
+// in UTC.
+func parseTimestamp(timestamp string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return time.Parse(time.DateTime, timestamp)
+	}
+	return t, nil
+}
+
+// degrees widens a coordinate to float64 without the digits float32 can not represent, so that it is encoded as it
+// was stored.
+func degrees(coordinate float32) float64 {
+	widened, _ := strconv.ParseFloat(strconv.FormatFloat(float64(coordinate), 'f', -1, 32), 64)
+	return widened
+}
+
+// czmlInterval formats start and end as an ISO 8601 interval.
+func czmlInterval(start time.Time, end time.Time) string {
+	return start.UTC().Format(time.RFC3339) + "/" + end.UTC().Format(time.RFC3339)
+}
+
+// historyCzmlStream writes a history export of several aircraft as a CZML document, with a packet for every aircraft.
+// The rows have to be ordered by aircraft and then by timestamp, so that only the history of one aircraft is held in
+// memory at a time.
+type historyCzmlStream struct {
+	w        *bufio.Writer
+	document czml.Packet
+	aircraft []models.AircraftHistoryModel
+	rows     int
+}
+
+// NewHistoryCzmlStream returns a HistoryExportStream writing a CZML document named name to w, with a clock from start
+// to end.
+func NewHistoryCzmlStream(w io.Writer, name string, start time.Time, end time.Time) HistoryExportStream {
+	return &historyCzmlStream{w: bufio.NewWriter(w), document: documentPacket(name, start, end)}
+}
+
+func (stream *historyCzmlStream) Write(ac models.AircraftHistoryModel) error {
+	if stream.rows == 0 {
+		err := stream.writePacket('[', stream.document)
+		if err != nil {
+			return err
+		}
+	} else if stream.aircraft[0].Icao != ac.Icao {
+		err := stream.writeAircraft()
+		if err != nil {
+			return err
+		}
+	}
+
+	stream.rows++
+	stream.aircraft = append(stream.aircraft, ac)
+	return nil
+}
+
+func (stream *historyCzmlStream) Rows() int {
+	return stream.rows
+}
+
+func (stream *historyCzmlStream) Close() error {
+	if stream.rows > 0 {
+		err := stream.writeAircraft()
+		if err != nil {
+			return err
+		}
+		_, err = stream.w.WriteString("]\n")
+		if err != nil {
+			return err
+		}
+	}
+	return stream.w.Flush()
+}
+
+// writeAircraft writes the packet of the aircraft held in memory, and lets go of its history.
+func (stream *historyCzmlStream) writeAircraft() error {
+	packet, err := aircraftPacket(stream.aircraft, "")
+	if err != nil {
+		return err
+	}
+	stream.aircraft = stream.aircraft[:0]
+	return stream.writePacket(',', packet)
+}
+
+// writePacket writes packet after the separator, which opens the array or separates it from the previous packet.
+func (stream *historyCzmlStream) writePacket(separator byte, packet czml.Packet) error {
+	data, err := json.Marshal(packet)
+	if err != nil {
+		return err
+	}
+	err = stream.w.WriteByte(separator)
+	if err != nil {
+		return err
+	}
+	_, err = stream.w.Write(data)
+	return err
+}
@@ -0,0 +1,83 @@
+package convert
+
+import (
+	"adsb-api/internal/global/czml"
+	"adsb-api/internal/global/models"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryModelToCzml(t *testing.T) {
+	altitude := 10000
+	// newest first, as the history is read from the database
+	mockData := []models.AircraftHistoryModel{
+		{Icao: "ABC123", Latitude: 60, Longitude: 6, Timestamp: "2024-04-11T10:01:00Z"},
+		{Icao: "ABC123", Latitude: 60, Longitude: 5.5, Timestamp: "2024-04-11T10:00:30Z", Altitude: &altitude},
+		{Icao: "ABC123", Latitude: 60, Longitude: 5, Timestamp: "2024-04-11T10:00:00Z"},
+	}
+
+	packets, err := HistoryModelToCzml(mockData, 0, "LN-ABC")
+	if err != nil {
+		t.Fatalf("error converting history to CZML: %q", err)
+	}
+	assert.Len(t, packets, 2)
+
+	document := packets[0]
+	assert.Equal(t, "document", document.Id)
+	assert.Equal(t, "1.0", document.Version)
+	assert.Equal(t, "2024-04-11T10:00:00Z/2024-04-11T10:01:00Z", document.Clock.Interval)
+	assert.Equal(t, "2024-04-11T10:00:00Z", document.Clock.CurrentTime)
+
+	aircraft := packets[1]
+	assert.Equal(t, "ABC123", aircraft.Id)
+	assert.Equal(t, "LN-ABC", aircraft.Description)
+	assert.Equal(t, "2024-04-11T10:00:00Z/2024-04-11T10:01:00Z", aircraft.Availability)
+	assert.Equal(t, "2024-04-11T10:00:00Z", aircraft.Position.Epoch)
+	assert.Equal(t, "LAGRANGE", aircraft.Position.InterpolationAlgorithm)
+	assert.Equal(t, 1, aircraft.Position.InterpolationDegree)
+	// the height is 0 before the first altitude, and the last known altitude after it
+	assert.Equal(t, []float64{0, 5, 60, 0, 30, 5.5, 60, 3048, 60, 6, 60, 3048}, aircraft.Position.CartographicDegrees)
+
+	_, err = HistoryModelToCzml(mockData[:1], 0, "")
+	assert.Error(t, err)
+}
+
+func TestHistoryCzmlStream(t *testing.T) {
+	mockData := []models.AircraftHistoryModel{
+		{Icao: "484506", Latitude: 59, Longitude: 4, Timestamp: "2024-04-11T10:00:05Z"},
+		{Icao: "484506", Latitude: 59, Longitude: 5, Timestamp: "2024-04-11T10:00:10Z"},
+		{Icao: "4CA2D1", Latitude: 60, Longitude: 5, Timestamp: "2024-04-11T10:00:00Z"},
+	}
+
+	var buf bytes.Buffer
+	start := time.Date(2024, 4, 11, 10, 0, 0, 0, time.UTC)
+	stream := NewHistoryCzmlStream(&buf, "history", start, start.Add(time.Hour))
+	for _, ac := range mockData {
+		err := stream.Write(ac)
+		if err != nil {
+			t.Fatalf("error writing to stream: %q", err)
+		}
+	}
+	err := stream.Close()
+	if err != nil {
+		t.Fatalf("error closing stream: %q", err)
+	}
+	assert.Equal(t, 3, stream.Rows())
+
+	var packets []czml.Packet
+	err = json.Unmarshal(buf.Bytes(), &packets)
+	if err != nil {
+		t.Fatalf("error decoding CZML: %q", err)
+	}
+	assert.Len(t, packets, 3)
+	assert.Equal(t, "history", packets[0].Name)
+	assert.Equal(t, "2024-04-11T10:00:00Z/2024-04-11T11:00:00Z", packets[0].Clock.Interval)
+	assert.Equal(t, "484506", packets[1].Id)
+	assert.Equal(t, []float64{0, 4, 59, 0, 5, 5, 59, 0}, packets[1].Position.CartographicDegrees)
+	assert.Equal(t, "4CA2D1", packets[2].Id)
+	assert.Equal(t, "2024-04-11T10:00:00Z/2024-04-11T10:00:00Z", packets[2].Availability)
+}
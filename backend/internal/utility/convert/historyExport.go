@@ -0,0 +1,90 @@
+package convert
+
+import (
+	"adsb-api/internal/global/models"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// historyCsvHeader is the header row of a CSV history export
+var historyCsvHeader = []string{"icao", "timestamp", "latitude", "longitude", "altitude"}
+
+// HistoryExportStream writes the rows of a history export one at a time, so the export never has to be held in memory.
+// Rows are buffered, and Close writes the rest of them.
+type HistoryExportStream interface {
+	Write(ac models.AircraftHistoryModel) error
+	Rows() int
+	Close() error
+}
+
+// historyCsvStream writes a history export as CSV, with a header row before the first row. The altitude is left empty
+// for positions without one.
+type historyCsvStream struct {
+	w    *csv.Writer
+	rows int
+}
+
+// NewHistoryCsvStream returns a HistoryExportStream writing CSV to w.
+func NewHistoryCsvStream(w io.Writer) HistoryExportStream {
+	return &historyCsvStream{w: csv.NewWriter(w)}
+}
+
+func (stream *historyCsvStream) Write(ac models.AircraftHistoryModel) error {
+	if stream.rows == 0 {
+		err := stream.w.Write(historyCsvHeader)
+		if err != nil {
+			return err
+		}
+	}
+	stream.rows++
+
+	var altitude string
+	if ac.Altitude != nil {
+		altitude = strconv.Itoa(*ac.Altitude)
+	}
+	return stream.w.Write([]string{
+		ac.Icao,
+		ac.Timestamp,
+		strconv.FormatFloat(float64(ac.Latitude), 'f', -1, 32),
+		strconv.FormatFloat(float64(ac.Longitude), 'f', -1, 32),
+		altitude,
+	})
+}
+
+func (stream *historyCsvStream) Rows() int {
+	return stream.rows
+}
+
+func (stream *historyCsvStream) Close() error {
+	stream.w.Flush()
+	return stream.w.Error()
+}
+
+// historyNdjsonStream writes a history export as newline delimited JSON, with one AircraftHistoryModel on each line.
+type historyNdjsonStream struct {
+	w       *bufio.Writer
+	encoder *json.Encoder
+	rows    int
+}
+
+// NewHistoryNdjsonStream returns a HistoryExportStream writing NDJSON to w.
+func NewHistoryNdjsonStream(w io.Writer) HistoryExportStream {
+	buffered := bufio.NewWriter(w)
+	return &historyNdjsonStream{w: buffered, encoder: json.NewEncoder(buffered)}
+}
+
+func (stream *historyNdjsonStream) Write(ac models.AircraftHistoryModel) error {
+	stream.rows++
+	return stream.encoder.Encode(ac)
+}
+
+func (stream *historyNdjsonStream) Rows() int {
+	return stream.rows
+}
+
+func (stream *historyNdjsonStream) Close() error {
+	return stream.w.Flush()
+}
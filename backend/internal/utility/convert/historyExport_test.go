@@ -0,0 +1,60 @@
+package convert
+
+import (
+	"adsb-api/internal/global/models"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryExportStreams(t *testing.T) {
+	altitude := 35000
+	mockData := []models.AircraftHistoryModel{
+		{Icao: "4CA2D1", Latitude: 60.123456, Longitude: -0.5, Timestamp: "2024-04-11T10:00:00Z", Altitude: &altitude},
+		{Icao: "484506", Latitude: 59.1, Longitude: 4.75, Timestamp: "2024-04-11T10:00:05Z"},
+	}
+
+	tests := []struct {
+		name      string
+		newStream func(buf *bytes.Buffer) HistoryExportStream
+		expected  string
+	}{
+		{
+			name:      "CSV",
+			newStream: func(buf *bytes.Buffer) HistoryExportStream { return NewHistoryCsvStream(buf) },
+			expected: "icao,timestamp,latitude,longitude,altitude\n" +
+				"4CA2D1,2024-04-11T10:00:00Z,60.123455,-0.5,35000\n" +
+				"484506,2024-04-11T10:00:05Z,59.1,4.75,\n",
+		},
+		{
+			name:      "NDJSON",
+			newStream: func(buf *bytes.Buffer) HistoryExportStream { return NewHistoryNdjsonStream(buf) },
+			expected: `{"icao":"4CA2D1","latitude":60.123455,"longitude":-0.5,"timestamp":"2024-04-11T10:00:00Z","altitude":35000}` + "\n" +
+				`{"icao":"484506","latitude":59.1,"longitude":4.75,"timestamp":"2024-04-11T10:00:05Z"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			stream := tt.newStream(&buf)
+			for _, ac := range mockData {
+				err := stream.Write(ac)
+				if err != nil {
+					t.Fatalf("error writing to stream: %q", err)
+				}
+			}
+
+			// rows are buffered until the stream is closed
+			assert.Equal(t, 0, buf.Len())
+
+			err := stream.Close()
+			if err != nil {
+				t.Fatalf("error closing stream: %q", err)
+			}
+			assert.Equal(t, 2, stream.Rows())
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package convert
+
+import (
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/models"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// HistoryGeoJsonStream writes the history of an aircraft as the same GeoJSON FeatureCollection as HistoryModelToGeoJson,
+// one point at a time, so the history never has to be held in memory. The properties are written after the geometry,
+// when the number of points is known.
+//
+// Nothing is written until the second point, so that a history with too few coordinates can still be answered with
+// another response by the caller.
+type HistoryGeoJsonStream struct {
+	w      *bufio.Writer
+	first  models.AircraftHistoryModel
+	points int
+}
+
+// NewHistoryGeoJsonStream returns a HistoryGeoJsonStream writing to w.
+func NewHistoryGeoJsonStream(w io.Writer) *HistoryGeoJsonStream {
+	return &HistoryGeoJsonStream{w: bufio.NewWriter(w)}
+}
+
+// Started reports whether anything has been written yet.
+func (stream *HistoryGeoJsonStream) Started() bool {
+	return stream.points > 1
+}
+
+// Write adds the position of ac to the LineString.
+func (stream *HistoryGeoJsonStream) Write(ac models.AircraftHistoryModel) error {
+	stream.points++
+
+	switch stream.points {
+	case 1:
+		stream.first = ac
+		return nil
+	case 2:
+		_, err := stream.w.WriteString(`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"LineString","coordinates":[`)
+		if err != nil {
+			return err
+		}
+		err = stream.writePoint(stream.first)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := stream.w.WriteByte(',')
+	if err != nil {
+		return err
+	}
+	return stream.writePoint(ac)
+}
+
+// Close writes the properties, with the registry properties of the aircraft, and the end of the FeatureCollection.
+// Returns an error without writing anything if the history has less than 2 points.
+func (stream *HistoryGeoJsonStream) Close(registry geoJSON.RegistryProperties) error {
+	if !stream.Started() {
+		return errors.New(errorMsg.ErrorGeoJsonTooFewCoordinates)
+	}
+
+	var feature geoJSON.FeatureLineString
+	feature.Properties.Icao = stream.first.Icao
+	feature.Properties.OriginalPoints = stream.points
+	feature.Properties.Points = stream.points
+	feature.Properties.RegistryProperties = registry
+
+	properties, err := json.Marshal(feature.Properties)
+	if err != nil {
+		return err
+	}
+
+	_, err = stream.w.WriteString(`]},"properties":`)
+	if err != nil {
+		return err
+	}
+	_, err = stream.w.Write(properties)
+	if err != nil {
+		return err
+	}
+	_, err = stream.w.WriteString("}]}\n")
+	if err != nil {
+		return err
+	}
+
+	return stream.w.Flush()
+}
+
+// writePoint writes the [longitude, latitude] coordinates of ac, with the shortest representation of a float32.
+func (stream *HistoryGeoJsonStream) writePoint(ac models.AircraftHistoryModel) error {
+	buf := make([]byte, 0, 32)
+	buf = append(buf, '[')
+	buf = strconv.AppendFloat(buf, float64(ac.Longitude), 'f', -1, 32)
+	buf = append(buf, ',')
+	buf = strconv.AppendFloat(buf, float64(ac.Latitude), 'f', -1, 32)
+	buf = append(buf, ']')
+
+	_, err := stream.w.Write(buf)
+	return err
+}
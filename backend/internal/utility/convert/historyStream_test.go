@@ -0,0 +1,61 @@
+package convert
+
+import (
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/utility/testUtility"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryGeoJsonStream(t *testing.T) {
+	mockData := testUtility.CreateMockHistAircraft(10)
+	mockData[3].Latitude = 59.123456
+	mockData[3].Longitude = 10.654321
+
+	var buf bytes.Buffer
+	stream := NewHistoryGeoJsonStream(&buf)
+	for _, ac := range mockData {
+		err := stream.Write(ac)
+		if err != nil {
+			t.Fatalf("error writing to stream: %q", err)
+		}
+	}
+
+	registry := geoJSON.RegistryProperties{Registration: "LN-ABC"}
+	err := stream.Close(registry)
+	if err != nil {
+		t.Fatalf("error closing stream: %q", err)
+	}
+
+	var actual geoJSON.FeatureCollectionLineString
+	err = json.Unmarshal(buf.Bytes(), &actual)
+	if err != nil {
+		t.Fatalf("error decoding stream: %q", err)
+	}
+
+	expected, err := HistoryModelToGeoJson(mockData, 0)
+	if err != nil {
+		t.Fatalf("error converting model data to GeoJSON: %q", err)
+	}
+	expected.Features[0].Properties.RegistryProperties = registry
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestHistoryGeoJsonStream_TooFewCoordinates(t *testing.T) {
+	var buf bytes.Buffer
+	stream := NewHistoryGeoJsonStream(&buf)
+
+	err := stream.Write(testUtility.CreateMockHistAircraft(1)[0])
+	if err != nil {
+		t.Fatalf("error writing to stream: %q", err)
+	}
+
+	assert.False(t, stream.Started())
+	assert.EqualError(t, stream.Close(geoJSON.RegistryProperties{}), errorMsg.ErrorGeoJsonTooFewCoordinates)
+	assert.Equal(t, 0, buf.Len())
+}
@@ -0,0 +1,131 @@
+package convert
+
+import (
+	"adsb-api/internal/global/models"
+	"math"
+)
+
+const (
+	// metersPerDegreeLat is the approximate length of one degree of latitude
+	metersPerDegreeLat = 110540
+	// metersPerDegreeLong is the approximate length of one degree of longitude at the equator
+	metersPerDegreeLong = 111320
+	// turnAngle is the change of heading in degrees between two segments that makes a point a turn point
+	turnAngle = 30
+)
+
+// point is a history position projected to meters around the first position of the track
+type point struct {
+	x, y float64
+}
+
+// SimplifyHistory simplifies the track of an aircraft with the Ramer–Douglas–Peucker algorithm.
+// Tolerance is the maximum distance in meters a removed point may have from the simplified track.
+// The first and last points, and every point where the heading changes more than turnAngle degrees, are always kept.
+// A tolerance of 0 or less returns the track unchanged.
+func SimplifyHistory(aircraft []models.AircraftHistoryModel, tolerance float64) []models.AircraftHistoryModel {
+	if tolerance <= 0 || len(aircraft) < 3 {
+		return aircraft
+	}
+
+	points := projectHistory(aircraft)
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+	markTurnPoints(points, keep)
+
+	// simplify every section between two kept points on its own
+	start := 0
+	for i := 1; i < len(points); i++ {
+		if keep[i] {
+			simplifySection(points, keep, start, i, tolerance)
+			start = i
+		}
+	}
+
+	var simplified []models.AircraftHistoryModel
+	for i, ac := range aircraft {
+		if keep[i] {
+			simplified = append(simplified, ac)
+		}
+	}
+	return simplified
+}
+
+// projectHistory projects the positions of the track to an equirectangular plane in meters.
+func projectHistory(aircraft []models.AircraftHistoryModel) []point {
+	lat0 := float64(aircraft[0].Latitude)
+	long0 := float64(aircraft[0].Longitude)
+	scale := math.Cos(lat0 * math.Pi / 180)
+
+	points := make([]point, len(aircraft))
+	for i, ac := range aircraft {
+		points[i] = point{
+			x: (float64(ac.Longitude) - long0) * metersPerDegreeLong * scale,
+			y: (float64(ac.Latitude) - lat0) * metersPerDegreeLat,
+		}
+	}
+	return points
+}
+
+// markTurnPoints marks every point where the heading of the track changes more than turnAngle degrees.
+// Points at the same position as the previous point are skipped when calculating the heading.
+func markTurnPoints(points []point, keep []bool) {
+	prev := 0
+	prevHeading := math.NaN()
+	for i := 1; i < len(points); i++ {
+		dx, dy := points[i].x-points[prev].x, points[i].y-points[prev].y
+		if dx == 0 && dy == 0 {
+			continue
+		}
+
+		heading := math.Atan2(dy, dx) * 180 / math.Pi
+		if !math.IsNaN(prevHeading) {
+			diff := math.Abs(heading - prevHeading)
+			if diff > 180 {
+				diff = 360 - diff
+			}
+			if diff > turnAngle {
+				keep[prev] = true
+			}
+		}
+
+		prev = i
+		prevHeading = heading
+	}
+}
+
+// simplifySection runs the Ramer–Douglas–Peucker algorithm on the points between first and last, marking the points to
+// keep. A stack is used instead of recursion, since tracks can have thousands of points.
+func simplifySection(points []point, keep []bool, first int, last int, tolerance float64) {
+	stack := [][2]int{{first, last}}
+	for len(stack) > 0 {
+		section := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		maxDistance, index := 0.0, -1
+		for i := section[0] + 1; i < section[1]; i++ {
+			distance := segmentDistance(points[i], points[section[0]], points[section[1]])
+			if distance > maxDistance {
+				maxDistance, index = distance, i
+			}
+		}
+
+		if index != -1 && maxDistance > tolerance {
+			keep[index] = true
+			stack = append(stack, [2]int{section[0], index}, [2]int{index, section[1]})
+		}
+	}
+}
+
+// segmentDistance returns the distance from p to the line segment between a and b.
+func segmentDistance(p point, a point, b point) float64 {
+	dx, dy := b.x-a.x, b.y-a.y
+	if dx == 0 && dy == 0 {
+		return math.Hypot(p.x-a.x, p.y-a.y)
+	}
+
+	t := ((p.x-a.x)*dx + (p.y-a.y)*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p.x-(a.x+t*dx), p.y-(a.y+t*dy))
+}
@@ -5,6 +5,7 @@
 package mock
 
 import (
+	db "adsb-api/internal/db"
 	models "adsb-api/internal/global/models"
 	reflect "reflect"
 
@@ -62,6 +63,48 @@ func (mr *MockDatabaseMockRecorder) BulkInsertAircraftCurrent(aircraft interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkInsertAircraftCurrent", reflect.TypeOf((*MockDatabase)(nil).BulkInsertAircraftCurrent), aircraft)
 }
 
+// BulkInsertAircraftHistory mocks base method.
+func (m *MockDatabase) BulkInsertAircraftHistory(aircraft []models.AircraftHistoryModel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkInsertAircraftHistory", aircraft)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BulkInsertAircraftHistory indicates an expected call of BulkInsertAircraftHistory.
+func (mr *MockDatabaseMockRecorder) BulkInsertAircraftHistory(aircraft interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkInsertAircraftHistory", reflect.TypeOf((*MockDatabase)(nil).BulkInsertAircraftHistory), aircraft)
+}
+
+// BulkUpsertAircraftCurrent mocks base method.
+func (m *MockDatabase) BulkUpsertAircraftCurrent(aircraft []models.AircraftCurrentModel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkUpsertAircraftCurrent", aircraft)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BulkUpsertAircraftCurrent indicates an expected call of BulkUpsertAircraftCurrent.
+func (mr *MockDatabaseMockRecorder) BulkUpsertAircraftCurrent(aircraft interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkUpsertAircraftCurrent", reflect.TypeOf((*MockDatabase)(nil).BulkUpsertAircraftCurrent), aircraft)
+}
+
+// BulkUpsertAircraftRegistry mocks base method.
+func (m *MockDatabase) BulkUpsertAircraftRegistry(registry []models.AircraftRegistryModel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkUpsertAircraftRegistry", registry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BulkUpsertAircraftRegistry indicates an expected call of BulkUpsertAircraftRegistry.
+func (mr *MockDatabaseMockRecorder) BulkUpsertAircraftRegistry(registry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkUpsertAircraftRegistry", reflect.TypeOf((*MockDatabase)(nil).BulkUpsertAircraftRegistry), registry)
+}
+
 // Close mocks base method.
 func (m *MockDatabase) Close() error {
 	m.ctrl.T.Helper()
@@ -90,6 +133,34 @@ func (mr *MockDatabaseMockRecorder) Commit() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockDatabase)(nil).Commit))
 }
 
+// CreateAircraftCoverageTable mocks base method.
+func (m *MockDatabase) CreateAircraftCoverageTable() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAircraftCoverageTable")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAircraftCoverageTable indicates an expected call of CreateAircraftCoverageTable.
+func (mr *MockDatabaseMockRecorder) CreateAircraftCoverageTable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAircraftCoverageTable", reflect.TypeOf((*MockDatabase)(nil).CreateAircraftCoverageTable))
+}
+
+// CreateAircraftCurrentRemovedTable mocks base method.
+func (m *MockDatabase) CreateAircraftCurrentRemovedTable() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAircraftCurrentRemovedTable")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAircraftCurrentRemovedTable indicates an expected call of CreateAircraftCurrentRemovedTable.
+func (mr *MockDatabaseMockRecorder) CreateAircraftCurrentRemovedTable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAircraftCurrentRemovedTable", reflect.TypeOf((*MockDatabase)(nil).CreateAircraftCurrentRemovedTable))
+}
+
 // CreateAircraftCurrentTable mocks base method.
 func (m *MockDatabase) CreateAircraftCurrentTable() error {
 	m.ctrl.T.Helper()
@@ -104,6 +175,34 @@ func (mr *MockDatabaseMockRecorder) CreateAircraftCurrentTable() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAircraftCurrentTable", reflect.TypeOf((*MockDatabase)(nil).CreateAircraftCurrentTable))
 }
 
+// CreateAircraftHistoryRollupTable mocks base method.
+func (m *MockDatabase) CreateAircraftHistoryRollupTable() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAircraftHistoryRollupTable")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAircraftHistoryRollupTable indicates an expected call of CreateAircraftHistoryRollupTable.
+func (mr *MockDatabaseMockRecorder) CreateAircraftHistoryRollupTable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAircraftHistoryRollupTable", reflect.TypeOf((*MockDatabase)(nil).CreateAircraftHistoryRollupTable))
+}
+
+// CreateAircraftHistoryRollupTimestampIndex mocks base method.
+func (m *MockDatabase) CreateAircraftHistoryRollupTimestampIndex() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAircraftHistoryRollupTimestampIndex")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAircraftHistoryRollupTimestampIndex indicates an expected call of CreateAircraftHistoryRollupTimestampIndex.
+func (mr *MockDatabaseMockRecorder) CreateAircraftHistoryRollupTimestampIndex() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAircraftHistoryRollupTimestampIndex", reflect.TypeOf((*MockDatabase)(nil).CreateAircraftHistoryRollupTimestampIndex))
+}
+
 // CreateAircraftHistoryTable mocks base method.
 func (m *MockDatabase) CreateAircraftHistoryTable() error {
 	m.ctrl.T.Helper()
@@ -132,18 +231,159 @@ func (mr *MockDatabaseMockRecorder) CreateAircraftHistoryTimestampIndex() *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAircraftHistoryTimestampIndex", reflect.TypeOf((*MockDatabase)(nil).CreateAircraftHistoryTimestampIndex))
 }
 
+// CreateAircraftRegistryTable mocks base method.
+func (m *MockDatabase) CreateAircraftRegistryTable() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAircraftRegistryTable")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAircraftRegistryTable indicates an expected call of CreateAircraftRegistryTable.
+func (mr *MockDatabaseMockRecorder) CreateAircraftRegistryTable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAircraftRegistryTable", reflect.TypeOf((*MockDatabase)(nil).CreateAircraftRegistryTable))
+}
+
+// CreateAircraftSightingTable mocks base method.
+func (m *MockDatabase) CreateAircraftSightingTable() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAircraftSightingTable")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAircraftSightingTable indicates an expected call of CreateAircraftSightingTable.
+func (mr *MockDatabaseMockRecorder) CreateAircraftSightingTable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAircraftSightingTable", reflect.TypeOf((*MockDatabase)(nil).CreateAircraftSightingTable))
+}
+
+// CreateApiKeyTable mocks base method.
+func (m *MockDatabase) CreateApiKeyTable() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKeyTable")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateApiKeyTable indicates an expected call of CreateApiKeyTable.
+func (mr *MockDatabaseMockRecorder) CreateApiKeyTable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKeyTable", reflect.TypeOf((*MockDatabase)(nil).CreateApiKeyTable))
+}
+
+// DeleteAircraftCurrentExcept mocks base method.
+func (m *MockDatabase) DeleteAircraftCurrentExcept(icaos []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAircraftCurrentExcept", icaos)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAircraftCurrentExcept indicates an expected call of DeleteAircraftCurrentExcept.
+func (mr *MockDatabaseMockRecorder) DeleteAircraftCurrentExcept(icaos interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAircraftCurrentExcept", reflect.TypeOf((*MockDatabase)(nil).DeleteAircraftCurrentExcept), icaos)
+}
+
+// DeleteApiKey mocks base method.
+func (m *MockDatabase) DeleteApiKey(name string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteApiKey", name)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteApiKey indicates an expected call of DeleteApiKey.
+func (mr *MockDatabaseMockRecorder) DeleteApiKey(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteApiKey", reflect.TypeOf((*MockDatabase)(nil).DeleteApiKey), name)
+}
+
+// DeleteHistoryBefore mocks base method.
+func (m *MockDatabase) DeleteHistoryBefore(cutoff string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHistoryBefore", cutoff)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHistoryBefore indicates an expected call of DeleteHistoryBefore.
+func (mr *MockDatabaseMockRecorder) DeleteHistoryBefore(cutoff interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHistoryBefore", reflect.TypeOf((*MockDatabase)(nil).DeleteHistoryBefore), cutoff)
+}
+
+// DeleteOldAircraftCurrentRemoved mocks base method.
+func (m *MockDatabase) DeleteOldAircraftCurrentRemoved(seconds int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOldAircraftCurrentRemoved", seconds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOldAircraftCurrentRemoved indicates an expected call of DeleteOldAircraftCurrentRemoved.
+func (mr *MockDatabaseMockRecorder) DeleteOldAircraftCurrentRemoved(seconds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldAircraftCurrentRemoved", reflect.TypeOf((*MockDatabase)(nil).DeleteOldAircraftCurrentRemoved), seconds)
+}
+
+// DeleteOldAircraftSightings mocks base method.
+func (m *MockDatabase) DeleteOldAircraftSightings(days int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOldAircraftSightings", days)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOldAircraftSightings indicates an expected call of DeleteOldAircraftSightings.
+func (mr *MockDatabaseMockRecorder) DeleteOldAircraftSightings(days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldAircraftSightings", reflect.TypeOf((*MockDatabase)(nil).DeleteOldAircraftSightings), days)
+}
+
+// DeleteOldCoverage mocks base method.
+func (m *MockDatabase) DeleteOldCoverage(days int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOldCoverage", days)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOldCoverage indicates an expected call of DeleteOldCoverage.
+func (mr *MockDatabaseMockRecorder) DeleteOldCoverage(days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldCoverage", reflect.TypeOf((*MockDatabase)(nil).DeleteOldCoverage), days)
+}
+
 // DeleteOldHistory mocks base method.
-func (m *MockDatabase) DeleteOldHistory(time int) error {
+func (m *MockDatabase) DeleteOldHistory(days int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteOldHistory", time)
+	ret := m.ctrl.Call(m, "DeleteOldHistory", days)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteOldHistory indicates an expected call of DeleteOldHistory.
-func (mr *MockDatabaseMockRecorder) DeleteOldHistory(time interface{}) *gomock.Call {
+func (mr *MockDatabaseMockRecorder) DeleteOldHistory(days interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldHistory", reflect.TypeOf((*MockDatabase)(nil).DeleteOldHistory), time)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldHistory", reflect.TypeOf((*MockDatabase)(nil).DeleteOldHistory), days)
+}
+
+// DeleteOldHistoryRollup mocks base method.
+func (m *MockDatabase) DeleteOldHistoryRollup(days int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOldHistoryRollup", days)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOldHistoryRollup indicates an expected call of DeleteOldHistoryRollup.
+func (mr *MockDatabaseMockRecorder) DeleteOldHistoryRollup(days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldHistoryRollup", reflect.TypeOf((*MockDatabase)(nil).DeleteOldHistoryRollup), days)
 }
 
 // DropAircraftCurrentTable mocks base method.
@@ -160,6 +400,20 @@ func (mr *MockDatabaseMockRecorder) DropAircraftCurrentTable() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropAircraftCurrentTable", reflect.TypeOf((*MockDatabase)(nil).DropAircraftCurrentTable))
 }
 
+// InsertApiKey mocks base method.
+func (m *MockDatabase) InsertApiKey(key models.ApiKeyModel, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertApiKey", key, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertApiKey indicates an expected call of InsertApiKey.
+func (mr *MockDatabaseMockRecorder) InsertApiKey(key, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertApiKey", reflect.TypeOf((*MockDatabase)(nil).InsertApiKey), key, hash)
+}
+
 // InsertHistoryFromCurrent mocks base method.
 func (m *MockDatabase) InsertHistoryFromCurrent() error {
 	m.ctrl.T.Helper()
@@ -174,6 +428,20 @@ func (mr *MockDatabaseMockRecorder) InsertHistoryFromCurrent() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertHistoryFromCurrent", reflect.TypeOf((*MockDatabase)(nil).InsertHistoryFromCurrent))
 }
 
+// InsertHistoryRollup mocks base method.
+func (m *MockDatabase) InsertHistoryRollup(cutoff string, resolution int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertHistoryRollup", cutoff, resolution)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertHistoryRollup indicates an expected call of InsertHistoryRollup.
+func (mr *MockDatabaseMockRecorder) InsertHistoryRollup(cutoff, resolution interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertHistoryRollup", reflect.TypeOf((*MockDatabase)(nil).InsertHistoryRollup), cutoff, resolution)
+}
+
 // Rollback mocks base method.
 func (m *MockDatabase) Rollback() error {
 	m.ctrl.T.Helper()
@@ -188,6 +456,126 @@ func (mr *MockDatabaseMockRecorder) Rollback() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockDatabase)(nil).Rollback))
 }
 
+// SearchAircraftSightings mocks base method.
+func (m *MockDatabase) SearchAircraftSightings(search string, limit int) ([]models.AircraftSightingModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchAircraftSightings", search, limit)
+	ret0, _ := ret[0].([]models.AircraftSightingModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchAircraftSightings indicates an expected call of SearchAircraftSightings.
+func (mr *MockDatabaseMockRecorder) SearchAircraftSightings(search, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchAircraftSightings", reflect.TypeOf((*MockDatabase)(nil).SearchAircraftSightings), search, limit)
+}
+
+// SelectAircraftCurrentByIcao mocks base method.
+func (m *MockDatabase) SelectAircraftCurrentByIcao(search string) (*models.AircraftCurrentModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAircraftCurrentByIcao", search)
+	ret0, _ := ret[0].(*models.AircraftCurrentModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAircraftCurrentByIcao indicates an expected call of SelectAircraftCurrentByIcao.
+func (mr *MockDatabaseMockRecorder) SelectAircraftCurrentByIcao(search interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAircraftCurrentByIcao", reflect.TypeOf((*MockDatabase)(nil).SelectAircraftCurrentByIcao), search)
+}
+
+// SelectAircraftCurrentChangedSince mocks base method.
+func (m *MockDatabase) SelectAircraftCurrentChangedSince(seq int64) ([]models.AircraftCurrentModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAircraftCurrentChangedSince", seq)
+	ret0, _ := ret[0].([]models.AircraftCurrentModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAircraftCurrentChangedSince indicates an expected call of SelectAircraftCurrentChangedSince.
+func (mr *MockDatabaseMockRecorder) SelectAircraftCurrentChangedSince(seq interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAircraftCurrentChangedSince", reflect.TypeOf((*MockDatabase)(nil).SelectAircraftCurrentChangedSince), seq)
+}
+
+// SelectAircraftCurrentFiltered mocks base method.
+func (m *MockDatabase) SelectAircraftCurrentFiltered(filter models.AircraftCurrentFilter) ([]models.AircraftCurrentModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAircraftCurrentFiltered", filter)
+	ret0, _ := ret[0].([]models.AircraftCurrentModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAircraftCurrentFiltered indicates an expected call of SelectAircraftCurrentFiltered.
+func (mr *MockDatabaseMockRecorder) SelectAircraftCurrentFiltered(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAircraftCurrentFiltered", reflect.TypeOf((*MockDatabase)(nil).SelectAircraftCurrentFiltered), filter)
+}
+
+// SelectAircraftCurrentRemovedSince mocks base method.
+func (m *MockDatabase) SelectAircraftCurrentRemovedSince(seq int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAircraftCurrentRemovedSince", seq)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAircraftCurrentRemovedSince indicates an expected call of SelectAircraftCurrentRemovedSince.
+func (mr *MockDatabaseMockRecorder) SelectAircraftCurrentRemovedSince(seq interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAircraftCurrentRemovedSince", reflect.TypeOf((*MockDatabase)(nil).SelectAircraftCurrentRemovedSince), seq)
+}
+
+// SelectAircraftCurrentVersion mocks base method.
+func (m *MockDatabase) SelectAircraftCurrentVersion() (models.AircraftCurrentVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAircraftCurrentVersion")
+	ret0, _ := ret[0].(models.AircraftCurrentVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAircraftCurrentVersion indicates an expected call of SelectAircraftCurrentVersion.
+func (mr *MockDatabaseMockRecorder) SelectAircraftCurrentVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAircraftCurrentVersion", reflect.TypeOf((*MockDatabase)(nil).SelectAircraftCurrentVersion))
+}
+
+// SelectAircraftRegistryByIcao mocks base method.
+func (m *MockDatabase) SelectAircraftRegistryByIcao(search string) (*models.AircraftRegistryModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAircraftRegistryByIcao", search)
+	ret0, _ := ret[0].(*models.AircraftRegistryModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAircraftRegistryByIcao indicates an expected call of SelectAircraftRegistryByIcao.
+func (mr *MockDatabaseMockRecorder) SelectAircraftRegistryByIcao(search interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAircraftRegistryByIcao", reflect.TypeOf((*MockDatabase)(nil).SelectAircraftRegistryByIcao), search)
+}
+
+// SelectAirlineStats mocks base method.
+func (m *MockDatabase) SelectAirlineStats(filter models.StatsFilter, limit int) ([]models.AirlineStatsModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAirlineStats", filter, limit)
+	ret0, _ := ret[0].([]models.AirlineStatsModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAirlineStats indicates an expected call of SelectAirlineStats.
+func (mr *MockDatabaseMockRecorder) SelectAirlineStats(filter, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAirlineStats", reflect.TypeOf((*MockDatabase)(nil).SelectAirlineStats), filter, limit)
+}
+
 // SelectAllColumnHistoryByIcao mocks base method.
 func (m *MockDatabase) SelectAllColumnHistoryByIcao(search string) ([]models.AircraftHistoryModel, error) {
 	m.ctrl.T.Helper()
@@ -218,6 +606,36 @@ func (mr *MockDatabaseMockRecorder) SelectAllColumnHistoryByIcaoFilterByTimestam
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAllColumnHistoryByIcaoFilterByTimestamp", reflect.TypeOf((*MockDatabase)(nil).SelectAllColumnHistoryByIcaoFilterByTimestamp), search, hour)
 }
 
+// SelectAllColumnHistoryRollupByIcao mocks base method.
+func (m *MockDatabase) SelectAllColumnHistoryRollupByIcao(search string) ([]models.AircraftHistoryModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAllColumnHistoryRollupByIcao", search)
+	ret0, _ := ret[0].([]models.AircraftHistoryModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAllColumnHistoryRollupByIcao indicates an expected call of SelectAllColumnHistoryRollupByIcao.
+func (mr *MockDatabaseMockRecorder) SelectAllColumnHistoryRollupByIcao(search interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAllColumnHistoryRollupByIcao", reflect.TypeOf((*MockDatabase)(nil).SelectAllColumnHistoryRollupByIcao), search)
+}
+
+// SelectAllColumnHistoryRollupByIcaoFilterByTimestamp mocks base method.
+func (m *MockDatabase) SelectAllColumnHistoryRollupByIcaoFilterByTimestamp(search string, hour int) ([]models.AircraftHistoryModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAllColumnHistoryRollupByIcaoFilterByTimestamp", search, hour)
+	ret0, _ := ret[0].([]models.AircraftHistoryModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAllColumnHistoryRollupByIcaoFilterByTimestamp indicates an expected call of SelectAllColumnHistoryRollupByIcaoFilterByTimestamp.
+func (mr *MockDatabaseMockRecorder) SelectAllColumnHistoryRollupByIcaoFilterByTimestamp(search, hour interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAllColumnHistoryRollupByIcaoFilterByTimestamp", reflect.TypeOf((*MockDatabase)(nil).SelectAllColumnHistoryRollupByIcaoFilterByTimestamp), search, hour)
+}
+
 // SelectAllColumnsAircraftCurrent mocks base method.
 func (m *MockDatabase) SelectAllColumnsAircraftCurrent() ([]models.AircraftCurrentModel, error) {
 	m.ctrl.T.Helper()
@@ -232,3 +650,251 @@ func (mr *MockDatabaseMockRecorder) SelectAllColumnsAircraftCurrent() *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAllColumnsAircraftCurrent", reflect.TypeOf((*MockDatabase)(nil).SelectAllColumnsAircraftCurrent))
 }
+
+// SelectAltitudeStats mocks base method.
+func (m *MockDatabase) SelectAltitudeStats(filter models.StatsFilter, band int) ([]models.AltitudeStatsModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAltitudeStats", filter, band)
+	ret0, _ := ret[0].([]models.AltitudeStatsModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAltitudeStats indicates an expected call of SelectAltitudeStats.
+func (mr *MockDatabaseMockRecorder) SelectAltitudeStats(filter, band interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAltitudeStats", reflect.TypeOf((*MockDatabase)(nil).SelectAltitudeStats), filter, band)
+}
+
+// SelectApiKeyByHash mocks base method.
+func (m *MockDatabase) SelectApiKeyByHash(hash string) (*models.ApiKeyModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectApiKeyByHash", hash)
+	ret0, _ := ret[0].(*models.ApiKeyModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectApiKeyByHash indicates an expected call of SelectApiKeyByHash.
+func (mr *MockDatabaseMockRecorder) SelectApiKeyByHash(hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectApiKeyByHash", reflect.TypeOf((*MockDatabase)(nil).SelectApiKeyByHash), hash)
+}
+
+// SelectApiKeys mocks base method.
+func (m *MockDatabase) SelectApiKeys() ([]models.ApiKeyModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectApiKeys")
+	ret0, _ := ret[0].([]models.ApiKeyModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectApiKeys indicates an expected call of SelectApiKeys.
+func (mr *MockDatabaseMockRecorder) SelectApiKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectApiKeys", reflect.TypeOf((*MockDatabase)(nil).SelectApiKeys))
+}
+
+// SelectBusiestHours mocks base method.
+func (m *MockDatabase) SelectBusiestHours(filter models.StatsFilter, limit int) ([]models.TrafficStatsModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectBusiestHours", filter, limit)
+	ret0, _ := ret[0].([]models.TrafficStatsModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectBusiestHours indicates an expected call of SelectBusiestHours.
+func (mr *MockDatabaseMockRecorder) SelectBusiestHours(filter, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectBusiestHours", reflect.TypeOf((*MockDatabase)(nil).SelectBusiestHours), filter, limit)
+}
+
+// SelectCoverage mocks base method.
+func (m *MockDatabase) SelectCoverage(filter models.CoverageFilter) ([]models.CoverageModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectCoverage", filter)
+	ret0, _ := ret[0].([]models.CoverageModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectCoverage indicates an expected call of SelectCoverage.
+func (mr *MockDatabaseMockRecorder) SelectCoverage(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectCoverage", reflect.TypeOf((*MockDatabase)(nil).SelectCoverage), filter)
+}
+
+// SelectHeatmap mocks base method.
+func (m *MockDatabase) SelectHeatmap(filter models.HeatmapFilter) ([]models.HeatmapCellModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectHeatmap", filter)
+	ret0, _ := ret[0].([]models.HeatmapCellModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectHeatmap indicates an expected call of SelectHeatmap.
+func (mr *MockDatabaseMockRecorder) SelectHeatmap(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectHeatmap", reflect.TypeOf((*MockDatabase)(nil).SelectHeatmap), filter)
+}
+
+// SelectHistoryByIcaoTimeRange mocks base method.
+func (m *MockDatabase) SelectHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter) ([]models.AircraftHistoryModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectHistoryByIcaoTimeRange", search, filter)
+	ret0, _ := ret[0].([]models.AircraftHistoryModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectHistoryByIcaoTimeRange indicates an expected call of SelectHistoryByIcaoTimeRange.
+func (mr *MockDatabaseMockRecorder) SelectHistoryByIcaoTimeRange(search, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectHistoryByIcaoTimeRange", reflect.TypeOf((*MockDatabase)(nil).SelectHistoryByIcaoTimeRange), search, filter)
+}
+
+// SelectHistoryCutoff mocks base method.
+func (m *MockDatabase) SelectHistoryCutoff(days int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectHistoryCutoff", days)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectHistoryCutoff indicates an expected call of SelectHistoryCutoff.
+func (mr *MockDatabaseMockRecorder) SelectHistoryCutoff(days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectHistoryCutoff", reflect.TypeOf((*MockDatabase)(nil).SelectHistoryCutoff), days)
+}
+
+// SelectHistoryRollupByIcaoTimeRange mocks base method.
+func (m *MockDatabase) SelectHistoryRollupByIcaoTimeRange(search string, filter models.AircraftHistoryFilter) ([]models.AircraftHistoryModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectHistoryRollupByIcaoTimeRange", search, filter)
+	ret0, _ := ret[0].([]models.AircraftHistoryModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectHistoryRollupByIcaoTimeRange indicates an expected call of SelectHistoryRollupByIcaoTimeRange.
+func (mr *MockDatabaseMockRecorder) SelectHistoryRollupByIcaoTimeRange(search, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectHistoryRollupByIcaoTimeRange", reflect.TypeOf((*MockDatabase)(nil).SelectHistoryRollupByIcaoTimeRange), search, filter)
+}
+
+// SelectTrafficStats mocks base method.
+func (m *MockDatabase) SelectTrafficStats(filter models.StatsFilter, interval string) ([]models.TrafficStatsModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectTrafficStats", filter, interval)
+	ret0, _ := ret[0].([]models.TrafficStatsModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectTrafficStats indicates an expected call of SelectTrafficStats.
+func (mr *MockDatabaseMockRecorder) SelectTrafficStats(filter, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectTrafficStats", reflect.TypeOf((*MockDatabase)(nil).SelectTrafficStats), filter, interval)
+}
+
+// Snapshot mocks base method.
+func (m *MockDatabase) Snapshot(read func(db.Database) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot", read)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockDatabaseMockRecorder) Snapshot(read interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockDatabase)(nil).Snapshot), read)
+}
+
+// StreamHistoryBefore mocks base method.
+func (m *MockDatabase) StreamHistoryBefore(cutoff string, handle func(models.AircraftHistoryModel) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamHistoryBefore", cutoff, handle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamHistoryBefore indicates an expected call of StreamHistoryBefore.
+func (mr *MockDatabaseMockRecorder) StreamHistoryBefore(cutoff, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamHistoryBefore", reflect.TypeOf((*MockDatabase)(nil).StreamHistoryBefore), cutoff, handle)
+}
+
+// StreamHistoryByIcaoTimeRange mocks base method.
+func (m *MockDatabase) StreamHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter, handle func(models.AircraftHistoryModel) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamHistoryByIcaoTimeRange", search, filter, handle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamHistoryByIcaoTimeRange indicates an expected call of StreamHistoryByIcaoTimeRange.
+func (mr *MockDatabaseMockRecorder) StreamHistoryByIcaoTimeRange(search, filter, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamHistoryByIcaoTimeRange", reflect.TypeOf((*MockDatabase)(nil).StreamHistoryByIcaoTimeRange), search, filter, handle)
+}
+
+// StreamHistoryExport mocks base method.
+func (m *MockDatabase) StreamHistoryExport(filter models.HistoryExportFilter, handle func(models.AircraftHistoryModel) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamHistoryExport", filter, handle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamHistoryExport indicates an expected call of StreamHistoryExport.
+func (mr *MockDatabaseMockRecorder) StreamHistoryExport(filter, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamHistoryExport", reflect.TypeOf((*MockDatabase)(nil).StreamHistoryExport), filter, handle)
+}
+
+// StreamHistoryRollupByIcaoTimeRange mocks base method.
+func (m *MockDatabase) StreamHistoryRollupByIcaoTimeRange(search string, filter models.AircraftHistoryFilter, handle func(models.AircraftHistoryModel) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamHistoryRollupByIcaoTimeRange", search, filter, handle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamHistoryRollupByIcaoTimeRange indicates an expected call of StreamHistoryRollupByIcaoTimeRange.
+func (mr *MockDatabaseMockRecorder) StreamHistoryRollupByIcaoTimeRange(search, filter, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamHistoryRollupByIcaoTimeRange", reflect.TypeOf((*MockDatabase)(nil).StreamHistoryRollupByIcaoTimeRange), search, filter, handle)
+}
+
+// UpsertAircraftSightingsFromCurrent mocks base method.
+func (m *MockDatabase) UpsertAircraftSightingsFromCurrent() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertAircraftSightingsFromCurrent")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertAircraftSightingsFromCurrent indicates an expected call of UpsertAircraftSightingsFromCurrent.
+func (mr *MockDatabaseMockRecorder) UpsertAircraftSightingsFromCurrent() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertAircraftSightingsFromCurrent", reflect.TypeOf((*MockDatabase)(nil).UpsertAircraftSightingsFromCurrent))
+}
+
+// UpsertCoverageFromHistory mocks base method.
+func (m *MockDatabase) UpsertCoverageFromHistory(latitude, longitude float64, sectors, band int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertCoverageFromHistory", latitude, longitude, sectors, band)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertCoverageFromHistory indicates an expected call of UpsertCoverageFromHistory.
+func (mr *MockDatabaseMockRecorder) UpsertCoverageFromHistory(latitude, longitude, sectors, band interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertCoverageFromHistory", reflect.TypeOf((*MockDatabase)(nil).UpsertCoverageFromHistory), latitude, longitude, sectors, band)
+}
@@ -64,6 +64,111 @@ func (mr *MockRestServiceMockRecorder) GetAircraftHistoryByIcaoFilterByTimestamp
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAircraftHistoryByIcaoFilterByTimestamp", reflect.TypeOf((*MockRestService)(nil).GetAircraftHistoryByIcaoFilterByTimestamp), search, hour)
 }
 
+// GetAircraftHistoryByIcaoTimeRange mocks base method.
+func (m *MockRestService) GetAircraftHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter) ([]models.AircraftHistoryModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAircraftHistoryByIcaoTimeRange", search, filter)
+	ret0, _ := ret[0].([]models.AircraftHistoryModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAircraftHistoryByIcaoTimeRange indicates an expected call of GetAircraftHistoryByIcaoTimeRange.
+func (mr *MockRestServiceMockRecorder) GetAircraftHistoryByIcaoTimeRange(search, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAircraftHistoryByIcaoTimeRange", reflect.TypeOf((*MockRestService)(nil).GetAircraftHistoryByIcaoTimeRange), search, filter)
+}
+
+// GetAircraftRegistryByIcao mocks base method.
+func (m *MockRestService) GetAircraftRegistryByIcao(search string) (*models.AircraftRegistryModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAircraftRegistryByIcao", search)
+	ret0, _ := ret[0].(*models.AircraftRegistryModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAircraftRegistryByIcao indicates an expected call of GetAircraftRegistryByIcao.
+func (mr *MockRestServiceMockRecorder) GetAircraftRegistryByIcao(search interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAircraftRegistryByIcao", reflect.TypeOf((*MockRestService)(nil).GetAircraftRegistryByIcao), search)
+}
+
+// GetAirlineStats mocks base method.
+func (m *MockRestService) GetAirlineStats(filter models.StatsFilter, limit int) ([]models.AirlineStatsModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAirlineStats", filter, limit)
+	ret0, _ := ret[0].([]models.AirlineStatsModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAirlineStats indicates an expected call of GetAirlineStats.
+func (mr *MockRestServiceMockRecorder) GetAirlineStats(filter, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAirlineStats", reflect.TypeOf((*MockRestService)(nil).GetAirlineStats), filter, limit)
+}
+
+// GetAltitudeStats mocks base method.
+func (m *MockRestService) GetAltitudeStats(filter models.StatsFilter, band int) ([]models.AltitudeStatsModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAltitudeStats", filter, band)
+	ret0, _ := ret[0].([]models.AltitudeStatsModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAltitudeStats indicates an expected call of GetAltitudeStats.
+func (mr *MockRestServiceMockRecorder) GetAltitudeStats(filter, band interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAltitudeStats", reflect.TypeOf((*MockRestService)(nil).GetAltitudeStats), filter, band)
+}
+
+// GetApiKey mocks base method.
+func (m *MockRestService) GetApiKey(key string) (*models.ApiKeyModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKey", key)
+	ret0, _ := ret[0].(*models.ApiKeyModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKey indicates an expected call of GetApiKey.
+func (mr *MockRestServiceMockRecorder) GetApiKey(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKey", reflect.TypeOf((*MockRestService)(nil).GetApiKey), key)
+}
+
+// GetBusiestHours mocks base method.
+func (m *MockRestService) GetBusiestHours(filter models.StatsFilter, limit int) ([]models.TrafficStatsModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBusiestHours", filter, limit)
+	ret0, _ := ret[0].([]models.TrafficStatsModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBusiestHours indicates an expected call of GetBusiestHours.
+func (mr *MockRestServiceMockRecorder) GetBusiestHours(filter, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBusiestHours", reflect.TypeOf((*MockRestService)(nil).GetBusiestHours), filter, limit)
+}
+
+// GetCoverage mocks base method.
+func (m *MockRestService) GetCoverage(filter models.CoverageFilter) ([]models.CoverageModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoverage", filter)
+	ret0, _ := ret[0].([]models.CoverageModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoverage indicates an expected call of GetCoverage.
+func (mr *MockRestServiceMockRecorder) GetCoverage(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoverage", reflect.TypeOf((*MockRestService)(nil).GetCoverage), filter)
+}
+
 // GetCurrentAircraft mocks base method.
 func (m *MockRestService) GetCurrentAircraft() ([]models.AircraftCurrentModel, error) {
 	m.ctrl.T.Helper()
@@ -77,4 +182,138 @@ func (m *MockRestService) GetCurrentAircraft() ([]models.AircraftCurrentModel, e
 func (mr *MockRestServiceMockRecorder) GetCurrentAircraft() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentAircraft", reflect.TypeOf((*MockRestService)(nil).GetCurrentAircraft))
-}
\ No newline at end of file
+}
+
+// GetCurrentAircraftByIcao mocks base method.
+func (m *MockRestService) GetCurrentAircraftByIcao(search string, trail int) (*models.AircraftCurrentDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrentAircraftByIcao", search, trail)
+	ret0, _ := ret[0].(*models.AircraftCurrentDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrentAircraftByIcao indicates an expected call of GetCurrentAircraftByIcao.
+func (mr *MockRestServiceMockRecorder) GetCurrentAircraftByIcao(search, trail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentAircraftByIcao", reflect.TypeOf((*MockRestService)(nil).GetCurrentAircraftByIcao), search, trail)
+}
+
+// GetCurrentAircraftSince mocks base method.
+func (m *MockRestService) GetCurrentAircraftSince(since models.AircraftCurrentVersion, filter models.AircraftCurrentFilter) (models.AircraftCurrentDelta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrentAircraftSince", since, filter)
+	ret0, _ := ret[0].(models.AircraftCurrentDelta)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrentAircraftSince indicates an expected call of GetCurrentAircraftSince.
+func (mr *MockRestServiceMockRecorder) GetCurrentAircraftSince(since, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentAircraftSince", reflect.TypeOf((*MockRestService)(nil).GetCurrentAircraftSince), since, filter)
+}
+
+// GetCurrentAircraftVersion mocks base method.
+func (m *MockRestService) GetCurrentAircraftVersion() (models.AircraftCurrentVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrentAircraftVersion")
+	ret0, _ := ret[0].(models.AircraftCurrentVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrentAircraftVersion indicates an expected call of GetCurrentAircraftVersion.
+func (mr *MockRestServiceMockRecorder) GetCurrentAircraftVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentAircraftVersion", reflect.TypeOf((*MockRestService)(nil).GetCurrentAircraftVersion))
+}
+
+// GetCurrentAircraftWithVersion mocks base method.
+func (m *MockRestService) GetCurrentAircraftWithVersion(filter models.AircraftCurrentFilter) ([]models.AircraftCurrentModel, models.AircraftCurrentVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrentAircraftWithVersion", filter)
+	ret0, _ := ret[0].([]models.AircraftCurrentModel)
+	ret1, _ := ret[1].(models.AircraftCurrentVersion)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetCurrentAircraftWithVersion indicates an expected call of GetCurrentAircraftWithVersion.
+func (mr *MockRestServiceMockRecorder) GetCurrentAircraftWithVersion(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentAircraftWithVersion", reflect.TypeOf((*MockRestService)(nil).GetCurrentAircraftWithVersion), filter)
+}
+
+// GetHeatmap mocks base method.
+func (m *MockRestService) GetHeatmap(filter models.HeatmapFilter) ([]models.HeatmapCellModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHeatmap", filter)
+	ret0, _ := ret[0].([]models.HeatmapCellModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHeatmap indicates an expected call of GetHeatmap.
+func (mr *MockRestServiceMockRecorder) GetHeatmap(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHeatmap", reflect.TypeOf((*MockRestService)(nil).GetHeatmap), filter)
+}
+
+// GetTrafficStats mocks base method.
+func (m *MockRestService) GetTrafficStats(filter models.StatsFilter, interval string) ([]models.TrafficStatsModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrafficStats", filter, interval)
+	ret0, _ := ret[0].([]models.TrafficStatsModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrafficStats indicates an expected call of GetTrafficStats.
+func (mr *MockRestServiceMockRecorder) GetTrafficStats(filter, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrafficStats", reflect.TypeOf((*MockRestService)(nil).GetTrafficStats), filter, interval)
+}
+
+// SearchAircraft mocks base method.
+func (m *MockRestService) SearchAircraft(search string, limit int) ([]models.AircraftSightingModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchAircraft", search, limit)
+	ret0, _ := ret[0].([]models.AircraftSightingModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchAircraft indicates an expected call of SearchAircraft.
+func (mr *MockRestServiceMockRecorder) SearchAircraft(search, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchAircraft", reflect.TypeOf((*MockRestService)(nil).SearchAircraft), search, limit)
+}
+
+// StreamAircraftHistoryByIcaoTimeRange mocks base method.
+func (m *MockRestService) StreamAircraftHistoryByIcaoTimeRange(search string, filter models.AircraftHistoryFilter, handle func(models.AircraftHistoryModel) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamAircraftHistoryByIcaoTimeRange", search, filter, handle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamAircraftHistoryByIcaoTimeRange indicates an expected call of StreamAircraftHistoryByIcaoTimeRange.
+func (mr *MockRestServiceMockRecorder) StreamAircraftHistoryByIcaoTimeRange(search, filter, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamAircraftHistoryByIcaoTimeRange", reflect.TypeOf((*MockRestService)(nil).StreamAircraftHistoryByIcaoTimeRange), search, filter, handle)
+}
+
+// StreamHistoryExport mocks base method.
+func (m *MockRestService) StreamHistoryExport(filter models.HistoryExportFilter, handle func(models.AircraftHistoryModel) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamHistoryExport", filter, handle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamHistoryExport indicates an expected call of StreamHistoryExport.
+func (mr *MockRestServiceMockRecorder) StreamHistoryExport(filter, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamHistoryExport", reflect.TypeOf((*MockRestService)(nil).StreamHistoryExport), filter, handle)
+}
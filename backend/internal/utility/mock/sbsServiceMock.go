@@ -48,44 +48,58 @@ func (mr *MockSbsServiceMockRecorder) CreateAdsbTables() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAdsbTables", reflect.TypeOf((*MockSbsService)(nil).CreateAdsbTables))
 }
 
-// InitAndStartCleanUpJob mocks base method.
-func (m *MockSbsService) InitAndStartCleanUpJob() error {
+// InsertNewSbsData mocks base method.
+func (m *MockSbsService) InsertNewSbsData(aircraft []models.AircraftCurrentModel) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ScheduleCleanUpJob")
+	ret := m.ctrl.Call(m, "InsertNewSbsData", aircraft)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// InitAndStartCleanUpJob indicates an expected call of InitAndStartCleanUpJob.
-func (mr *MockSbsServiceMockRecorder) InitAndStartCleanUpJob() *gomock.Call {
+// InsertNewSbsData indicates an expected call of InsertNewSbsData.
+func (mr *MockSbsServiceMockRecorder) InsertNewSbsData(aircraft interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleCleanUpJob", reflect.TypeOf((*MockSbsService)(nil).InitAndStartCleanUpJob))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertNewSbsData", reflect.TypeOf((*MockSbsService)(nil).InsertNewSbsData), aircraft)
 }
 
-// InsertNewSbsData mocks base method.
-func (m *MockSbsService) InsertNewSbsData(aircraft []models.AircraftCurrentModel) error {
+// ScheduleCleanUpJob mocks base method.
+func (m *MockSbsService) ScheduleCleanUpJob(schedule string, days int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertNewSbsData", aircraft)
+	ret := m.ctrl.Call(m, "ScheduleCleanUpJob", schedule, days)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// InsertNewSbsData indicates an expected call of InsertNewSbsData.
-func (mr *MockSbsServiceMockRecorder) InsertNewSbsData(aircraft interface{}) *gomock.Call {
+// ScheduleCleanUpJob indicates an expected call of ScheduleCleanUpJob.
+func (mr *MockSbsServiceMockRecorder) ScheduleCleanUpJob(schedule, days interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertNewSbsData", reflect.TypeOf((*MockSbsService)(nil).InsertNewSbsData), aircraft)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleCleanUpJob", reflect.TypeOf((*MockSbsService)(nil).ScheduleCleanUpJob), schedule, days)
+}
+
+// ScheduleCoverageJob mocks base method.
+func (m *MockSbsService) ScheduleCoverageJob(schedule string, latitude, longitude float64, sectors, band, days int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleCoverageJob", schedule, latitude, longitude, sectors, band, days)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleCoverageJob indicates an expected call of ScheduleCoverageJob.
+func (mr *MockSbsServiceMockRecorder) ScheduleCoverageJob(schedule, latitude, longitude, sectors, band, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleCoverageJob", reflect.TypeOf((*MockSbsService)(nil).ScheduleCoverageJob), schedule, latitude, longitude, sectors, band, days)
 }
 
-// StartScheduler mocks base method.
-func (m *MockSbsService) StartScheduler() error {
+// ScheduleTieredCleanUpJob mocks base method.
+func (m *MockSbsService) ScheduleTieredCleanUpJob(schedule string, days, resolution, rollupDays int, archiveDir string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StartScheduler")
+	ret := m.ctrl.Call(m, "ScheduleTieredCleanUpJob", schedule, days, resolution, rollupDays, archiveDir)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// StartScheduler indicates an expected call of StartScheduler.
-func (mr *MockSbsServiceMockRecorder) StartScheduler() *gomock.Call {
+// ScheduleTieredCleanUpJob indicates an expected call of ScheduleTieredCleanUpJob.
+func (mr *MockSbsServiceMockRecorder) ScheduleTieredCleanUpJob(schedule, days, resolution, rollupDays, archiveDir interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartScheduler", reflect.TypeOf((*MockSbsService)(nil).StartScheduler))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleTieredCleanUpJob", reflect.TypeOf((*MockSbsService)(nil).ScheduleTieredCleanUpJob), schedule, days, resolution, rollupDays, archiveDir)
 }
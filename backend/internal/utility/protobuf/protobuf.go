@@ -0,0 +1,141 @@
+// Package protobuf encodes the current aircraft and aircraft history responses as the Protocol Buffers messages of
+// aircraft.proto. The messages are written field by field, so that no generated code has to be kept in sync with the
+// GeoJSON responses.
+package protobuf
+
+import (
+	"adsb-api/internal/global/geoJSON"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Supports reports whether data is a response with a message in aircraft.proto.
+func Supports(data interface{}) bool {
+	switch data.(type) {
+	case geoJSON.FeatureCollectionPoint, geoJSON.FeatureAircraftDetail, geoJSON.FeatureCollectionLineString:
+		return true
+	}
+	return false
+}
+
+// Marshal encodes data as its message in aircraft.proto: an AircraftCurrentList, AircraftDetail or AircraftHistory.
+// Returns nil if data is not supported.
+func Marshal(data interface{}) []byte {
+	var m message
+	switch data := data.(type) {
+	case geoJSON.FeatureCollectionPoint:
+		for _, feature := range data.Features {
+			m.message(1, aircraftCurrent(feature.Properties, feature.Geometry.Coordinates))
+		}
+		for _, icao := range data.Removed {
+			m = protowire.AppendTag(m, 2, protowire.BytesType)
+			m = protowire.AppendString(m, icao)
+		}
+		m.bool(3, data.Full)
+		m.string(4, data.Token)
+	case geoJSON.FeatureAircraftDetail:
+		m.message(1, aircraftCurrent(data.Properties.AircraftCurrentProperties, data.Geometry.Coordinates))
+		m.string(2, data.Properties.FirstSeen)
+		m.string(3, data.Properties.LastSeen)
+		m.int(4, data.Properties.Messages)
+		for _, point := range data.Properties.Trail {
+			var trail message
+			trail.float(1, point.Latitude)
+			trail.float(2, point.Longitude)
+			trail.string(3, point.Timestamp)
+			m.message(5, trail)
+		}
+	case geoJSON.FeatureCollectionLineString:
+		// a history response has a single feature
+		for _, feature := range data.Features {
+			m.string(1, feature.Properties.Icao)
+			m.int(2, feature.Properties.OriginalPoints)
+			m.int(3, feature.Properties.Points)
+			m.registry(4, feature.Properties.RegistryProperties)
+			for _, coordinates := range feature.Geometry.Coordinates {
+				var position message
+				position.float(1, coordinates[1])
+				position.float(2, coordinates[0])
+				m.message(5, position)
+			}
+		}
+		m.string(6, data.Next)
+	default:
+		return nil
+	}
+	return m
+}
+
+// aircraftCurrent returns the AircraftCurrent message of an aircraft, where coordinates is the latitude and longitude
+// of its GeoJSON Point.
+func aircraftCurrent(properties geoJSON.AircraftCurrentProperties, coordinates []float32) message {
+	var m message
+	m.string(1, properties.Icao)
+	m.string(2, properties.Callsign)
+	if len(coordinates) == 2 {
+		m.float(3, coordinates[0])
+		m.float(4, coordinates[1])
+	}
+	m.int(5, properties.Altitude)
+	m.int(6, properties.Speed)
+	m.int(7, properties.Track)
+	m.int(8, properties.VerticalRate)
+	m.string(9, properties.Timestamp)
+	m.bool(10, properties.OnGround)
+	m.registry(11, properties.RegistryProperties)
+	return m
+}
+
+// message is an encoded message, where every field with its default value is left out.
+type message []byte
+
+func (m *message) string(num protowire.Number, v string) {
+	if v != "" {
+		*m = protowire.AppendTag(*m, num, protowire.BytesType)
+		*m = protowire.AppendString(*m, v)
+	}
+}
+
+// int appends an int32 field, which is encoded as a sign extended varint.
+func (m *message) int(num protowire.Number, v int) {
+	if v != 0 {
+		*m = protowire.AppendTag(*m, num, protowire.VarintType)
+		*m = protowire.AppendVarint(*m, uint64(int64(int32(v))))
+	}
+}
+
+func (m *message) bool(num protowire.Number, v bool) {
+	if v {
+		*m = protowire.AppendTag(*m, num, protowire.VarintType)
+		*m = protowire.AppendVarint(*m, protowire.EncodeBool(v))
+	}
+}
+
+func (m *message) float(num protowire.Number, v float32) {
+	if v != 0 {
+		*m = protowire.AppendTag(*m, num, protowire.Fixed32Type)
+		*m = protowire.AppendFixed32(*m, math.Float32bits(v))
+	}
+}
+
+// message appends an embedded message field. It is appended even if the embedded message is empty, so that every
+// element of a repeated field is kept.
+func (m *message) message(num protowire.Number, v message) {
+	*m = protowire.AppendTag(*m, num, protowire.BytesType)
+	*m = protowire.AppendBytes(*m, v)
+}
+
+// registry appends the Registry message of an aircraft, which is left out if the aircraft is not registered.
+func (m *message) registry(num protowire.Number, properties geoJSON.RegistryProperties) {
+	var registry message
+	registry.string(1, properties.Registration)
+	registry.string(2, properties.TypeCode)
+	registry.string(3, properties.Manufacturer)
+	registry.string(4, properties.Model)
+	registry.string(5, properties.Operator)
+	registry.int(6, properties.Year)
+	if len(registry) > 0 {
+		m.message(num, registry)
+	}
+}
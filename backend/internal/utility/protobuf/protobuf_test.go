@@ -0,0 +1,112 @@
+package protobuf
+
+import (
+	"adsb-api/internal/global/geoJSON"
+	"adsb-api/internal/global/models"
+	"adsb-api/internal/utility/convert"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// field is a decoded field of a message, where an embedded message or string is kept as bytes
+type field struct {
+	num   protowire.Number
+	value interface{}
+}
+
+// decode decodes the fields of a message, failing the test if it is not valid.
+func decode(t *testing.T, b []byte) []field {
+	var fields []field
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("error decoding tag: %q", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var value interface{}
+		switch typ {
+		case protowire.VarintType:
+			value, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var bits uint32
+			bits, n = protowire.ConsumeFixed32(b)
+			value = math.Float32frombits(bits)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		default:
+			t.Fatalf("unexpected wire type %d of field %d", typ, num)
+		}
+		if n < 0 {
+			t.Fatalf("error decoding field %d: %q", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+		fields = append(fields, field{num: num, value: value})
+	}
+	return fields
+}
+
+func TestMarshal_AircraftCurrentList(t *testing.T) {
+	collection, err := convert.CurrentModelToGeoJson([]models.AircraftCurrentModel{
+		{Icao: "ABC123", Callsign: "SAS123", Latitude: 60.5, Longitude: 5.25, Altitude: 35000, VerticalRate: -500,
+			Timestamp: "2024-01-01T10:00:00Z", Registry: models.AircraftRegistryModel{Registration: "LN-ABC"}},
+		{Icao: "DEF456", OnGround: true},
+	})
+	if err != nil {
+		t.Fatalf("error converting aircraft: %q", err)
+	}
+	collection.Removed = []string{"GHI789"}
+	collection.Token = "token"
+
+	fields := decode(t, Marshal(collection))
+	assert.Len(t, fields, 4)
+	assert.Equal(t, protowire.Number(1), fields[0].num)
+	assert.Equal(t, protowire.Number(1), fields[1].num)
+	assert.Equal(t, []field{{2, []byte("GHI789")}, {4, []byte("token")}}, fields[2:])
+
+	aircraft := decode(t, fields[0].value.([]byte))
+	assert.Equal(t, []field{
+		{1, []byte("ABC123")},
+		{2, []byte("SAS123")},
+		{3, float32(60.5)},
+		{4, float32(5.25)},
+		{5, uint64(35000)},
+		// a negative int32 is sign extended to 64 bits
+		{8, uint64(math.MaxUint64 - 499)},
+		{9, []byte("2024-01-01T10:00:00Z")},
+		{11, protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), "LN-ABC")},
+	}, aircraft)
+
+	// the registry is left out of an aircraft that is not registered
+	assert.Equal(t, []field{{1, []byte("DEF456")}, {10, uint64(1)}}, decode(t, fields[1].value.([]byte)))
+}
+
+func TestMarshal_AircraftHistory(t *testing.T) {
+	history, err := convert.HistoryModelToGeoJson([]models.AircraftHistoryModel{
+		{Icao: "ABC123", Latitude: 60, Longitude: 5},
+		{Icao: "ABC123", Latitude: 61, Longitude: 6},
+	}, 0)
+	if err != nil {
+		t.Fatalf("error converting history: %q", err)
+	}
+	history.Next = "cursor"
+
+	fields := decode(t, Marshal(history))
+	assert.Len(t, fields, 6)
+	assert.Equal(t, []field{{1, []byte("ABC123")}, {2, uint64(2)}, {3, uint64(2)}}, fields[:3])
+	// the positions are in the order of the LineString, with the latitude first
+	assert.Equal(t, protowire.Number(5), fields[3].num)
+	assert.Equal(t, []field{{1, float32(60)}, {2, float32(5)}}, decode(t, fields[3].value.([]byte)))
+	assert.Equal(t, protowire.Number(5), fields[4].num)
+	assert.Equal(t, []field{{1, float32(61)}, {2, float32(6)}}, decode(t, fields[4].value.([]byte)))
+	assert.Equal(t, field{6, []byte("cursor")}, fields[5])
+}
+
+func TestMarshal_Unsupported(t *testing.T) {
+	assert.False(t, Supports(geoJSON.FeatureCollectionPolygon{}))
+	assert.Nil(t, Marshal(geoJSON.FeatureCollectionPolygon{}))
+	assert.True(t, Supports(geoJSON.FeatureAircraftDetail{}))
+}
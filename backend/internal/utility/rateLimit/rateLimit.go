@@ -0,0 +1,84 @@
+package rateLimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter rate limits clients with a token bucket each. The bucket of a client with a limit of n requests per window
+// holds up to n tokens, and is refilled with n tokens over the window. Every request takes a token, so a client may
+// send a burst of n requests, and then a request every window/n.
+type Limiter struct {
+	window    time.Duration
+	now       func() time.Time
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+// bucket is the token bucket of a client.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Result is the state of the bucket of a client after a request.
+type Result struct {
+	Allowed    bool          // the request took a token
+	Limit      int           // requests per window
+	Remaining  int           // whole tokens left in the bucket
+	Reset      time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until the next token, if the request was not allowed
+}
+
+// NewLimiter returns a Limiter refilling the buckets over window.
+func NewLimiter(window time.Duration) *Limiter {
+	return &Limiter{window: window, now: time.Now, buckets: map[string]*bucket{}}
+}
+
+// Take takes a token from the bucket of client, which has a limit of requests per window. The request is not allowed
+// if the bucket is empty.
+func (l *Limiter) Take(client string, limit int) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	// tokens per nanosecond
+	rate := float64(limit) / float64(l.window)
+
+	b, found := l.buckets[client]
+	if !found {
+		b = &bucket{tokens: float64(limit), updated: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(float64(limit), b.tokens+float64(now.Sub(b.updated))*rate)
+	b.updated = now
+
+	result := Result{Limit: limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) / rate))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration(math.Ceil((float64(limit) - b.tokens) / rate))
+	return result
+}
+
+// prune deletes the buckets that have been refilled, at most once every window, so that the clients that are gone do
+// not keep their buckets. A bucket that has not been used for a window is full, whatever its limit.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.window {
+		return
+	}
+	for client, b := range l.buckets {
+		if now.Sub(b.updated) >= l.window {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastPrune = now
+}
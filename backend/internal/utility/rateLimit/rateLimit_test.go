@@ -0,0 +1,77 @@
+package rateLimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLimiter returns a Limiter with a window of a minute, and the clock it reads the time from.
+func newTestLimiter() (*Limiter, *time.Time) {
+	clock := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(time.Minute)
+	limiter.now = func() time.Time { return clock }
+	return limiter, &clock
+}
+
+func TestTake_Burst(t *testing.T) {
+	limiter, _ := newTestLimiter()
+
+	for i := 0; i < 3; i++ {
+		result := limiter.Take("client", 3)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result := limiter.Take("client", 3)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	// a token every 20 seconds
+	assert.Equal(t, 20*time.Second, result.RetryAfter)
+	assert.Equal(t, time.Minute, result.Reset)
+}
+
+func TestTake_Refill(t *testing.T) {
+	limiter, clock := newTestLimiter()
+
+	for i := 0; i < 60; i++ {
+		limiter.Take("client", 60)
+	}
+	assert.False(t, limiter.Take("client", 60).Allowed)
+
+	*clock = clock.Add(time.Second)
+	result := limiter.Take("client", 60)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// the bucket is never filled above the limit
+	*clock = clock.Add(time.Hour)
+	result = limiter.Take("client", 60)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 59, result.Remaining)
+	assert.Equal(t, time.Second, result.Reset)
+}
+
+func TestTake_Clients(t *testing.T) {
+	limiter, _ := newTestLimiter()
+
+	assert.True(t, limiter.Take("first", 1).Allowed)
+	assert.False(t, limiter.Take("first", 1).Allowed)
+	assert.True(t, limiter.Take("second", 1).Allowed)
+}
+
+func TestPrune(t *testing.T) {
+	limiter, clock := newTestLimiter()
+
+	limiter.Take("idle", 10)
+	*clock = clock.Add(30 * time.Second)
+	limiter.Take("active", 10)
+
+	*clock = clock.Add(40 * time.Second)
+	limiter.Take("active", 10)
+
+	assert.NotContains(t, limiter.buckets, "idle")
+	assert.Contains(t, limiter.buckets, "active")
+}
@@ -0,0 +1,151 @@
+package registry
+
+import (
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"encoding/csv"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// columnNames maps the fields of AircraftRegistryModel to the column names used by common public aircraft databases,
+// like the OpenSky Network aircraft database and BaseStation exports.
+var columnNames = map[string][]string{
+	"icao":         {"icao24", "icao", "modes", "mode_s", "hex"},
+	"registration": {"registration", "reg", "r"},
+	"typeCode":     {"typecode", "icaotypecode", "type_code", "icao_type", "t"},
+	"manufacturer": {"manufacturername", "manufacturer"},
+	"model":        {"model", "type"},
+	"operator":     {"operator", "registeredowners", "owner", "operatorname"},
+	"year":         {"built", "year", "yearbuilt", "year_built"},
+}
+
+var icaoRegex = regexp.MustCompile(`^[0-9A-F]{6}$`)
+
+// ReadCsv reads aircraft registry rows from a CSV file with a header row.
+// The columns are found by their name in the header, see columnNames, and only the icao column is required.
+// Rows without a valid 24-bit hexadecimal ICAO address are skipped. The rows are passed to handle in batches of at
+// most batchSize rows, where every ICAO address is only present once. Returns the number of rows read and skipped.
+func ReadCsv(r io.Reader, batchSize int, handle func([]models.AircraftRegistryModel) error) (read int, skipped int, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	columns := findColumns(header)
+	if _, ok := columns["icao"]; !ok {
+		return 0, 0, errors.New(errorMsg.ErrorRegistryMissingIcaoColumn)
+	}
+
+	batch := make(map[string]models.AircraftRegistryModel)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		rows := make([]models.AircraftRegistryModel, 0, len(batch))
+		for _, row := range batch {
+			rows = append(rows, row)
+		}
+		batch = make(map[string]models.AircraftRegistryModel)
+		return handle(rows)
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return read, skipped, err
+		}
+
+		row, ok := parseRecord(record, columns)
+		if !ok {
+			skipped++
+			continue
+		}
+
+		batch[row.Icao] = row
+		read++
+
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return read, skipped, err
+			}
+		}
+	}
+
+	return read, skipped, flush()
+}
+
+// findColumns returns the index of every known column in header.
+func findColumns(header []string) map[string]int {
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(cleanField(strings.TrimPrefix(name, "\ufeff")))
+		for field, names := range columnNames {
+			if _, found := columns[field]; found {
+				continue
+			}
+			for _, n := range names {
+				if name == n {
+					columns[field] = i
+				}
+			}
+		}
+	}
+	return columns
+}
+
+// parseRecord converts a CSV record to an AircraftRegistryModel. Returns false if the record has no valid ICAO address.
+func parseRecord(record []string, columns map[string]int) (models.AircraftRegistryModel, bool) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return cleanField(record[i])
+	}
+
+	icao := strings.ToUpper(field("icao"))
+	if !icaoRegex.MatchString(icao) {
+		return models.AircraftRegistryModel{}, false
+	}
+
+	return models.AircraftRegistryModel{
+		Icao:         icao,
+		Registration: field("registration"),
+		TypeCode:     field("typeCode"),
+		Manufacturer: field("manufacturer"),
+		Model:        field("model"),
+		Operator:     field("operator"),
+		Year:         parseYear(field("year")),
+	}, true
+}
+
+// parseYear returns the year at the start of a date like 2008 or 2008-05-01, or 0 if there is no year.
+func parseYear(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
+
+// cleanField trims whitespace and the single quotes some databases use for quoting.
+func cleanField(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		value = value[1 : len(value)-1]
+	}
+	return strings.TrimSpace(value)
+}
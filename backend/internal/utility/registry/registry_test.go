@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"adsb-api/internal/global"
+	"adsb-api/internal/global/errorMsg"
+	"adsb-api/internal/global/models"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	global.InitTestEnvironment()
+	m.Run()
+}
+
+// readAll reads every row in data with ReadCsv, sorted by icao.
+func readAll(t *testing.T, data string, batchSize int) ([]models.AircraftRegistryModel, int, int) {
+	var rows []models.AircraftRegistryModel
+	read, skipped, err := ReadCsv(strings.NewReader(data), batchSize, func(batch []models.AircraftRegistryModel) error {
+		assert.LessOrEqual(t, len(batch), batchSize)
+		rows = append(rows, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error reading csv: %q", err)
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Icao < rows[j].Icao })
+	return rows, read, skipped
+}
+
+func TestReadCsv_OpenSky(t *testing.T) {
+	data := `'icao24','registration','manufacturername','model','typecode','operator','built'
+'4ca2d6','EI-DCL','Boeing','737-8AS','B738','Ryanair','2004-10-01'
+'478f44','LN-NOL','Boeing','737-8JP','B738','Norwegian',''
+'invalid','X','','','','',''
+`
+	rows, read, skipped := readAll(t, data, 10)
+
+	assert.Equal(t, 2, read)
+	assert.Equal(t, 1, skipped)
+	assert.Equal(t, []models.AircraftRegistryModel{
+		{Icao: "478F44", Registration: "LN-NOL", TypeCode: "B738", Manufacturer: "Boeing", Model: "737-8JP", Operator: "Norwegian"},
+		{Icao: "4CA2D6", Registration: "EI-DCL", TypeCode: "B738", Manufacturer: "Boeing", Model: "737-8AS", Operator: "Ryanair", Year: 2004},
+	}, rows)
+}
+
+func TestReadCsv_BaseStation(t *testing.T) {
+	data := "ModeS,Registration,ICAOTypeCode,Manufacturer,Type,RegisteredOwners,YearBuilt\n" +
+		"\"4CA2D6\",\"EI-DCL\",\"B738\",\"Boeing\",\"737-8AS\",\"Ryanair, Ltd\",\"2004\"\n"
+
+	rows, read, _ := readAll(t, data, 10)
+
+	assert.Equal(t, 1, read)
+	assert.Equal(t, "Ryanair, Ltd", rows[0].Operator)
+	assert.Equal(t, 2004, rows[0].Year)
+}
+
+func TestReadCsv_Batches(t *testing.T) {
+	data := "icao24\n000001\n000002\n000003\n000002\n000004\n000005\n"
+
+	rows, read, _ := readAll(t, data, 2)
+
+	assert.Equal(t, 6, read)
+	assert.Len(t, rows, 6)
+}
+
+func TestReadCsv_MissingIcaoColumn(t *testing.T) {
+	_, _, err := ReadCsv(strings.NewReader("registration\nLN-ABC\n"), 10, func([]models.AircraftRegistryModel) error {
+		return nil
+	})
+
+	assert.EqualError(t, err, errorMsg.ErrorRegistryMissingIcaoColumn)
+}
+
+func TestReadCsv_ErrorHandlingBatch(t *testing.T) {
+	_, _, err := ReadCsv(strings.NewReader("icao24\n000001\n"), 10, func([]models.AircraftRegistryModel) error {
+		return errors.New("expected error")
+	})
+
+	assert.EqualError(t, err, "expected error")
+}
@@ -0,0 +1,15 @@
+// Package resources embeds the files of the resources directory that are served by the REST API.
+package resources
+
+import _ "embed"
+
+// GeoJsonSchema is the JSON Schema of GeoJSON, which the responses of the API are validated against.
+//
+//go:embed schemas/geoJson.json
+var GeoJsonSchema []byte
+
+// OpenApi is the OpenAPI document of the REST API, where the GeoJSON schema is left empty to be filled in with
+// GeoJsonSchema.
+//
+//go:embed openapi.json
+var OpenApi []byte
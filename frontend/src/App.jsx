@@ -27,7 +27,7 @@ function App() {
   // Retrieve aircrafts from API and update the current aircraft list
   const retrievePlanes = async () =>{
     try{
-      const data = await callAPI(`${process.env.REACT_APP_SERVER}/aircraft/current/`);
+      const data = await callAPI(`${process.env.REACT_APP_SERVER}/v1/aircraft/current/`);
       if (selected !== null){
         let newSelected = findAircraftByIcaoOrCallsign(selected.properties.icao, data.features);
         if(newSelected !== null){
@@ -57,9 +57,9 @@ function App() {
     
     let url;
     if (hours === 'all'){
-      url = `${process.env.REACT_APP_SERVER}/aircraft/history/${icao}`
+      url = `${process.env.REACT_APP_SERVER}/v1/aircraft/history/${icao}`
     }else{
-      url = `${process.env.REACT_APP_SERVER}/aircraft/history/${icao}?hour=${hours}`
+      url = `${process.env.REACT_APP_SERVER}/v1/aircraft/history/${icao}?hour=${hours}`
     }
     try{
       const data = await callAPI(url);